@@ -0,0 +1,248 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/jmigpin/editor/core/gosource"
+	"github.com/jmigpin/editor/util/iout"
+	"github.com/jmigpin/editor/util/uiutil/event"
+)
+
+// Code completion popup for .go files, shown in the context float box at the text cursor. Filters the candidates while typing.
+type CompletionPopup struct {
+	ed   *Editor
+	erow *ERow
+	res  *gosource.CCResult
+	seq  int // ignore results of older requests
+
+	str   string // filter of the shown candidates
+	shown bool
+}
+
+func NewCompletionPopup(ed *Editor) *CompletionPopup {
+	cp := &CompletionPopup{ed: ed}
+	ed.UI.Root.ContextFloatBox.OnClickEntry = cp.insert
+	return cp
+}
+
+//----------
+
+// The documentation popup shares the float box.
+func (cp *CompletionPopup) visible() bool {
+	return cp.shown && cp.ed.UI.Root.ContextFloatBox.Visible()
+}
+
+func (cp *CompletionPopup) Toggle() {
+	if cp.visible() {
+		cp.Hide()
+		return
+	}
+	if err := cp.start(); err != nil {
+		cp.ed.Error(err)
+	}
+}
+
+func (cp *CompletionPopup) Hide() {
+	cp.seq++
+	cp.erow = nil
+	cp.res = nil
+	cp.shown = false
+	cp.ed.UI.Root.ContextFloatBox.Hide()
+}
+
+//----------
+
+// Runs the code completion in the background (type checking can be slow).
+func (cp *CompletionPopup) start() error {
+	erow, ok := cp.ed.ActiveERow()
+	if !ok {
+		return fmt.Errorf("no active row")
+	}
+	if !erow.Info.IsFileButNotDir() || filepath.Ext(erow.Info.Name()) != ".go" {
+		return fmt.Errorf("code completion: not a .go file")
+	}
+	ta := erow.Row.TextArea
+	b, err := erow.TextAreaBytesCopy()
+	if err != nil {
+		return err
+	}
+	index := ta.TextCursor.Index()
+	filename := erow.Info.Name()
+
+	cp.seq++
+	seq := cp.seq
+	go func() {
+		res, err := gosource.CodeCompletion(filename, b, index)
+		cp.ed.UI.RunOnUIGoRoutine(func() {
+			if seq != cp.seq {
+				return
+			}
+			if err != nil {
+				cp.ed.Errorf("code completion: %v", err)
+				return
+			}
+			cp.erow = erow
+			cp.res = res
+			cp.update()
+		})
+	}()
+	return nil
+}
+
+// Filters the candidates with the text typed since the start index, and updates the popup. Hides the popup if the cursor left the completed word.
+func (cp *CompletionPopup) update() {
+	if cp.res == nil || !erowIsOpen(cp.erow) {
+		cp.Hide()
+		return
+	}
+	ta := cp.erow.Row.TextArea
+	start := cp.res.StartIndex
+	ci := ta.TextCursor.Index()
+	b, err := ta.Bytes()
+	if err != nil || ci < start || ci > len(b) {
+		cp.Hide()
+		return
+	}
+	str := string(b[start:ci])
+	if !isIdentStr(str) {
+		cp.Hide()
+		return
+	}
+
+	if cp.shown && str == cp.str {
+		return // keep selection
+	}
+
+	cp.res.Filter(str)
+	if len(cp.res.Objs) == 0 {
+		cp.Hide()
+		return
+	}
+
+	cfb := cp.ed.UI.Root.ContextFloatBox
+	cfb.SetEntries(strings.Split(cp.res.Str, "\n"))
+	cp.updateDoc()
+
+	// below the start of the word being completed
+	p := ta.GetPoint(start)
+	p.Y += ta.LineHeight()
+	cfb.Show(p)
+	cp.str = str
+	cp.shown = true
+}
+
+func (cp *CompletionPopup) updateDoc() {
+	cfb := cp.ed.UI.Root.ContextFloatBox
+	i := cfb.Selected()
+	if i < 0 || i >= len(cp.res.Objs) {
+		cfb.SetDoc("")
+		return
+	}
+	obj := cp.res.Objs[i]
+	s := obj.String()
+	if c, ok := cp.res.Comment(obj); ok {
+		s += "\n\n" + c
+	}
+	cfb.SetDoc(s)
+}
+
+func (cp *CompletionPopup) move(n int) {
+	cp.ed.UI.Root.ContextFloatBox.MoveSelection(n)
+	cp.updateDoc()
+}
+
+// Replaces the text typed since the start index with the candidate name.
+func (cp *CompletionPopup) insert(i int) {
+	defer cp.Hide()
+	if cp.res == nil || i < 0 || i >= len(cp.res.Objs) || !erowIsOpen(cp.erow) {
+		return
+	}
+	ta := cp.erow.Row.TextArea
+	tc := ta.TextCursor
+	start := cp.res.StartIndex
+	ci := tc.Index()
+	if ci < start {
+		return
+	}
+	name := cp.res.Objs[i].Name()
+	var err error
+	tc.Edit(func() {
+		err = iout.DeleteInsert(tc.RW(), start, ci-start, []byte(name))
+	})
+	if err != nil {
+		cp.ed.Error(err)
+		return
+	}
+	tc.SetSelectionOff()
+	tc.SetIndex(start + len(name))
+}
+
+func erowIsOpen(erow *ERow) bool {
+	if erow == nil {
+		return false
+	}
+	for _, e := range erow.Info.ERows {
+		if e == erow {
+			return true
+		}
+	}
+	return false
+}
+
+//----------
+
+// Keys: f1 toggles the popup. While visible: up/down select, enter inserts, esc closes. Other keys reach the textarea and the candidates are filtered afterwards.
+func (cp *CompletionPopup) handleInput(wi *event.WindowInput) event.Handle {
+	switch t := wi.Event.(type) {
+	case *event.KeyDown:
+		m := t.Mods.ClearLocks()
+		if m.Is(event.ModNone) && t.KeySym == event.KSymF1 {
+			cp.Toggle()
+			return event.Handled
+		}
+		if !cp.visible() {
+			return event.NotHandled
+		}
+		if m.Is(event.ModNone) {
+			switch t.KeySym {
+			case event.KSymUp:
+				cp.move(-1)
+				return event.Handled
+			case event.KSymDown:
+				cp.move(1)
+				return event.Handled
+			case event.KSymReturn:
+				cp.insert(cp.ed.UI.Root.ContextFloatBox.Selected())
+				return event.Handled
+			case event.KSymEscape:
+				return event.Handled // closed on keyup
+			}
+		}
+		// update after the textarea handles the key
+		cp.ed.UI.RunOnUIGoRoutine(cp.update)
+	case *event.KeyUp:
+		if cp.visible() && t.KeySym == event.KSymEscape {
+			cp.Hide()
+			return event.Handled
+		}
+	case *event.MouseDown:
+		if cp.visible() && !wi.Point.In(cp.ed.UI.Root.ContextFloatBox.Bounds) {
+			cp.Hide()
+		}
+	}
+	return event.NotHandled
+}
+
+//----------
+
+func isIdentStr(s string) bool {
+	for _, ru := range s {
+		if !(ru == '_' || unicode.IsLetter(ru) || unicode.IsDigit(ru)) {
+			return false
+		}
+	}
+	return true
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 )
 
@@ -24,8 +25,23 @@ func RegisterContentCmd(fn ContentCmdFn) {
 }
 
 func RunContentCmds(erow *ERow, index int) {
+	runContentCmds(erow, index, contentCmds)
+}
+
+// Runs the content cmds registered after fn. Used by cmds that reply later (ex: language server) to continue when they end up not handling the content.
+func RunNextContentCmds(erow *ERow, index int, fn ContentCmdFn) {
+	p := reflect.ValueOf(fn).Pointer()
+	for i, fn2 := range contentCmds {
+		if reflect.ValueOf(fn2).Pointer() == p {
+			runContentCmds(erow, index, contentCmds[i+1:])
+			return
+		}
+	}
+}
+
+func runContentCmds(erow *ERow, index int, cmds []ContentCmdFn) {
 	errs := []string{}
-	for i, fn := range contentCmds {
+	for i, fn := range cmds {
 		handled, err := fn(erow, index)
 		if err != nil {
 			if handled {
@@ -3,6 +3,8 @@ package contentcmds
 import "github.com/jmigpin/editor/core"
 
 func init() {
+	core.RegisterContentCmd(recoverFile)   // before filename, the line has a filename
+	core.RegisterContentCmd(lspDefinition) // before godefinition, if registered
 	core.RegisterContentCmd(goDefinition)
 	core.RegisterContentCmd(filename)
 	core.RegisterContentCmd(openSession)
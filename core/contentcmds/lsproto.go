@@ -0,0 +1,17 @@
+package contentcmds
+
+import (
+	"github.com/jmigpin/editor/core"
+)
+
+// Definition from the language server registered for the file extension (if any). Handled right away, the definition opens when the server replies, or the next content cmds run if there is none.
+func lspDefinition(erow *core.ERow, index int) (bool, error) {
+	if erow.Info.IsDir() || erow.Ed.LSProtoMan == nil {
+		return false, nil
+	}
+	if !erow.Ed.LSProtoMan.Has(erow.Info.Name()) {
+		return false, nil
+	}
+	next := func() { core.RunNextContentCmds(erow, index, lspDefinition) }
+	return true, core.LSProtoDefinition(erow, index, next)
+}
@@ -0,0 +1,29 @@
+package contentcmds
+
+import (
+	"strings"
+
+	"github.com/jmigpin/editor/core"
+)
+
+// Lines in the format "RecoverFile <filename>" (ex: "+Recovery" row).
+func recoverFile(erow *core.ERow, index int) (bool, error) {
+	str := erow.Row.TextArea.Str()
+
+	// line at index
+	a := strings.LastIndex(str[:index], "\n") + 1
+	b := strings.Index(str[index:], "\n")
+	if b < 0 {
+		b = len(str)
+	} else {
+		b += index
+	}
+	line := str[a:b]
+
+	cmdSpace := "RecoverFile "
+	if !strings.HasPrefix(line, cmdSpace) {
+		return false, nil
+	}
+	name := line[len(cmdSpace):]
+	return true, core.RecoverFile(erow.Ed, name)
+}
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/jmigpin/editor/core/toolbarparser"
+	"github.com/jmigpin/editor/ui"
+	"github.com/jmigpin/editor/util/diffutil"
+	"github.com/jmigpin/editor/util/evreg"
+)
+
+// Shows a unified diff between the file on disk and the row content in the "+Diff" row.
+func DiffDiskCmd(erow *ERow) error {
+	if !erow.Info.IsFileButNotDir() {
+		return fmt.Errorf("not a file: %v", erow.Info.Name())
+	}
+	b, err := erow.Row.TextArea.Bytes()
+	if err != nil {
+		return err
+	}
+	disk, err := ioutil.ReadFile(erow.Info.Name())
+	if err != nil {
+		return err
+	}
+	name := erow.Info.Name()
+	return showDiff(erow.Ed, name+" (disk)", name+" (row)", disk, b)
+}
+
+//----------
+
+// Usage: DiffRows [-side] <name>
+// Compares the row with another open row (name is relative to the row directory). Flags: -side (highlights the changed lines in both rows instead of showing a unified diff).
+func DiffRowsCmd(erow *ERow, part *toolbarparser.Part) error {
+	side := false
+	args := []string{}
+	for _, a := range part.Args[1:] {
+		s := a.UnquotedStr()
+		if s == "-side" {
+			side = true
+		} else {
+			args = append(args, s)
+		}
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("expecting row name argument")
+	}
+	name := args[0]
+	if !isSpecialName(name) && !filepath.IsAbs(name) {
+		name = filepath.Join(erow.Info.Dir(), name)
+	}
+	info, ok := erow.Ed.ERowInfos[name]
+	if !ok || len(info.ERows) == 0 {
+		return fmt.Errorf("row not found: %v", name)
+	}
+	erow2 := info.ERows[0]
+	if erow2.Info == erow.Info {
+		return fmt.Errorf("same row content: %v", name)
+	}
+
+	a, err := erow.Row.TextArea.Bytes()
+	if err != nil {
+		return err
+	}
+	b, err := erow2.Row.TextArea.Bytes()
+	if err != nil {
+		return err
+	}
+
+	if !side {
+		return showDiff(erow.Ed, erow.Info.Name(), erow2.Info.Name(), a, b)
+	}
+
+	// deleted lines in the first row, inserted lines in the second row
+	la, lb := diffutil.SplitLines(a), diffutil.SplitLines(b)
+	oa, ob := lineOffsets(la), lineOffsets(lb)
+	ops := diffutil.Lines(la, lb)
+	deleted, inserted := [][2]int{}, [][2]int{}
+	for _, o := range ops {
+		switch o.Type {
+		case diffutil.Delete:
+			deleted = append(deleted, [2]int{oa[o.A], oa[o.A+o.N]})
+		case diffutil.Insert:
+			inserted = append(inserted, [2]int{ob[o.B], ob[o.B+o.N]})
+		}
+	}
+	if len(deleted) == 0 && len(inserted) == 0 {
+		erow.Ed.Messagef("no differences: %v, %v", erow.Info.Name(), erow2.Info.Name())
+	}
+	erow.Row.TextArea.SetDiffLines(nil, deleted)
+	erow2.Row.TextArea.SetDiffLines(inserted, nil)
+
+	// show the first change in both rows
+	if len(deleted) > 0 {
+		erow.Row.TextArea.MakeIndexVisible(deleted[0][0])
+	}
+	if len(inserted) > 0 {
+		erow2.Row.TextArea.MakeIndexVisible(inserted[0][0])
+	}
+
+	// scrolling one row scrolls the other to the corresponding line
+	linkDiffScroll(erow, erow2, ops, oa, ob)
+	return nil
+}
+
+// Start offsets of the lines, plus the total length.
+func lineOffsets(lines [][]byte) []int {
+	w := make([]int, len(lines)+1)
+	for i, l := range lines {
+		w[i+1] = w[i] + len(l)
+	}
+	return w
+}
+
+//----------
+
+// Links the scroll offsets of two rows (DiffRows -side) by the corresponding lines of the diff. Unlinked when the content of any of the rows changes, or a row is closed.
+type diffScrollLink struct {
+	erows   [2]*ERow
+	offsets [2][]int // line start offsets of each row
+	lines   [2][]int // line of a row -> corresponding line in the other row
+	regs    [2]*evreg.Regist
+	syncing bool
+}
+
+func linkDiffScroll(erowA, erowB *ERow, ops []*diffutil.Op, oa, ob []int) {
+	erowA.unlinkDiffScroll()
+	erowB.unlinkDiffScroll()
+
+	lk := &diffScrollLink{erows: [2]*ERow{erowA, erowB}, offsets: [2][]int{oa, ob}}
+	la, lb := make([]int, len(oa)), make([]int, len(ob))
+	for _, o := range ops {
+		for k := 0; k < o.N; k++ {
+			switch o.Type {
+			case diffutil.Equal:
+				la[o.A+k] = o.B + k
+				lb[o.B+k] = o.A + k
+			case diffutil.Delete:
+				la[o.A+k] = o.B
+			case diffutil.Insert:
+				lb[o.B+k] = o.A
+			}
+		}
+	}
+	la[len(la)-1] = len(lb) - 1
+	lb[len(lb)-1] = len(la) - 1
+	lk.lines = [2][]int{la, lb}
+
+	for i, erow := range lk.erows {
+		i := i
+		erow.diffLink = lk
+		lk.regs[i] = erow.Row.TextArea.EvReg.Add(ui.TextAreaSetOffsetEventId, func(ev0 interface{}) {
+			lk.sync(i)
+		})
+	}
+}
+
+// Scrolls the other row to the line corresponding to the top line of row i.
+func (lk *diffScrollLink) sync(i int) {
+	if lk.syncing {
+		return
+	}
+	lk.syncing = true
+	defer func() { lk.syncing = false }()
+
+	j := 1 - i
+	offs := lk.offsets[i]
+	index := lk.erows[i].Row.TextArea.OffsetIndex()
+	line := sort.SearchInts(offs, index+1) - 1
+	if line < 0 {
+		line = 0
+	}
+	if line >= len(lk.lines[i]) {
+		line = len(lk.lines[i]) - 1
+	}
+	line2 := lk.lines[i][line]
+	lk.erows[j].Row.TextArea.SetOffsetIndex(lk.offsets[j][line2])
+}
+
+func (lk *diffScrollLink) unlink() {
+	for i, erow := range lk.erows {
+		lk.regs[i].Unregister()
+		erow.diffLink = nil
+	}
+}
+
+func (erow *ERow) unlinkDiffScroll() {
+	if erow.diffLink != nil {
+		erow.diffLink.unlink()
+	}
+}
+
+//----------
+
+func showDiff(ed *Editor, nameA, nameB string, a, b []byte) error {
+	buf := &bytes.Buffer{}
+	if err := diffutil.WriteUnified(buf, nameA, nameB, a, b, 3); err != nil {
+		return err
+	}
+	if buf.Len() == 0 {
+		fmt.Fprintf(buf, "# no differences: %v, %v\n", nameA, nameB)
+	}
+	erow, _ := ed.ExistingOrNewERow("+Diff")
+	erow.Row.TextArea.SetBytesClearPos(buf.Bytes())
+	erow.Flash()
+	return nil
+}
@@ -2,12 +2,14 @@ package core
 
 import (
 	"fmt"
+	"image"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/jmigpin/editor/core/fswatcher"
+	"github.com/jmigpin/editor/core/lsproto"
 	"github.com/jmigpin/editor/ui"
 	"github.com/jmigpin/editor/util/drawutil"
 	"github.com/jmigpin/editor/util/drawutil/drawer3"
@@ -22,10 +24,15 @@ type Editor struct {
 	Watcher     fswatcher.Watcher
 	RowReopener *RowReopener
 	ERowInfos   map[string]*ERowInfo
+	LSProtoMan  *lsproto.Manager
 
 	events chan interface{}
 
-	dndh *DndHandler
+	dndh    *DndHandler
+	cpopup  *CompletionPopup
+	gdpopup *GoDocPopup
+
+	recovery *recoverySession
 
 	// because closing events chan would receive later events on a closed channel
 	close chan struct{}
@@ -36,6 +43,7 @@ func NewEditor(opt *Options) (*Editor, error) {
 		events:    make(chan interface{}, 64),
 		close:     make(chan struct{}),
 		ERowInfos: map[string]*ERowInfo{},
+		recovery:  &recoverySession{},
 	}
 	ed.HomeVars = NewHomeVars()
 	ed.RowReopener = NewRowReopener(ed)
@@ -66,6 +74,11 @@ func (ed *Editor) init(opt *Options) error {
 
 	ed.setupTheme(opt)
 
+	// language servers (started on first use)
+	if err := ed.setupLSProto(opt); err != nil {
+		return err
+	}
+
 	// user interface
 	ui0, err := ui.NewUI(ed.events, "Editor")
 	if err != nil {
@@ -76,21 +89,34 @@ func (ed *Editor) init(opt *Options) error {
 
 	ed.setupRootToolbar()
 	ed.setupRootMenuToolbar()
+	ed.cpopup = NewCompletionPopup(ed)
+	ed.gdpopup = NewGoDocPopup(ed)
 
 	// TODO: ensure it has the window measure
 	// enqueue setup initial rows to run after UI has window measure
 	ed.EnsureOneColumn()
 	ed.UI.RunOnUIGoRoutine(func() {
 		ed.setupInitialRows(opt)
+		ed.showRecoveryRow()
 	})
 
+	// periodic snapshots of edited rows for crash recovery
+	go ed.runRecoverySnapshots()
+
 	return nil
 }
 
 //----------
 
 func (ed *Editor) Close() {
+	ed.SaveHistories()
 	ed.Watcher.Close()
+	if ed.LSProtoMan != nil {
+		if err := ed.LSProtoMan.Close(); err != nil {
+			log.Print(err)
+		}
+	}
+	ed.recovery.close()
 	close(ed.close)
 }
 
@@ -218,6 +244,16 @@ func (ed *Editor) ERows() []*ERow {
 
 //----------
 
+// Row with the textarea under the point.
+func (ed *Editor) textAreaERowAt(p image.Point) (*ERow, bool) {
+	for _, erow := range ed.ERows() {
+		if p.In(erow.Row.TextArea.Bounds) {
+			return erow, true
+		}
+	}
+	return nil, false
+}
+
 func (ed *Editor) GoodRowPos() *ui.RowPos {
 	return ed.UI.Root.GoodRowPos()
 }
@@ -333,6 +369,25 @@ func (ed *Editor) setupInitialRows(opt *Options) {
 
 //----------
 
+func (ed *Editor) setupLSProto(opt *Options) error {
+	if len(opt.LSProtos) == 0 {
+		return nil
+	}
+	regs := []*lsproto.Registration{}
+	for _, s := range opt.LSProtos {
+		reg, err := lsproto.ParseRegistration(s)
+		if err != nil {
+			return err
+		}
+		regs = append(regs, reg)
+	}
+	ed.LSProtoMan = lsproto.NewManager(regs)
+	ed.LSProtoMan.OnError = ed.Error
+	return nil
+}
+
+//----------
+
 func (ed *Editor) setupTheme(opt *Options) {
 	drawer3.WrapLineRune = rune(opt.WrapLineRune)
 	drawutil.TabWidth = opt.TabWidth
@@ -419,6 +474,12 @@ func (ed *Editor) handleGlobalShortcuts(ev interface{}) event.Handle {
 
 	switch t := ev.(type) {
 	case *event.WindowInput:
+		if h := ed.cpopup.handleInput(t); h == event.Handled {
+			return h
+		}
+		if h := ed.gdpopup.handleInput(t); h == event.Handled {
+			return h
+		}
 		switch t2 := t.Event.(type) {
 		case *event.MouseDown:
 			switch t2.Button {
@@ -467,4 +528,6 @@ type Options struct {
 
 	SessionName string
 	Filenames   []string
+
+	LSProtos []string // language server registrations: "language,exts,cmd"
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/jmigpin/editor/core/toolbarparser"
 	"github.com/jmigpin/editor/ui"
 	"github.com/jmigpin/editor/util/drawutil/drawer3"
+	"github.com/jmigpin/editor/util/syntaxutil"
 	"github.com/jmigpin/editor/util/uiutil/event"
 )
 
@@ -25,6 +26,8 @@ type ERow struct {
 
 	highlightDuplicates           bool
 	disableTextAreaSetStrCallback bool
+
+	diffLink *diffScrollLink // scroll linked with another row (DiffRows -side)
 }
 
 //----------
@@ -42,7 +45,7 @@ func NewERow(ed *Editor, info *ERowInfo, rowPos *ui.RowPos) *ERow {
 
 	erow.initHandlers()
 	erow.parseToolbar() // after handlers are set
-	erow.setupTextAreaCommentString()
+	erow.setupTextAreaSyntax()
 
 	return erow
 }
@@ -85,11 +88,21 @@ func (erow *ERow) initHandlers() {
 			erow.Row.EnableTextAreaXBar(!d.WrapLine.On())
 		}
 
+		// diff highlights and scroll link (DiffRows) are outdated
+		if erow.Row.TextArea.HasDiffLines() {
+			erow.Row.TextArea.SetDiffLines(nil, nil)
+		}
+		erow.unlinkDiffScroll()
+
 		if erow.disableTextAreaSetStrCallback {
 			return
 		}
 
 		erow.Info.SetRowsStrFromMaster(erow)
+		erow.Info.UpdateGitMarkers()
+		erow.Info.UpdateGoSemantic()
+		erow.Info.clearGitBlame()
+		erow.Info.lsprotoDidChange()
 
 		// update godebug annotations if hash doesn't match
 		//cmdutil.DefaultGoDebugCmd.NakedUpdateERowAnnotations(erow)
@@ -101,6 +114,13 @@ func (erow *ERow) initHandlers() {
 	})
 	// textarea annotation clicks
 	row.TextArea.EvReg.Add(ui.TextAreaAnnotationClickEventId, func(ev0 interface{}) {
+		ev := ev0.(*ui.TextAreaAnnotationClickEvent)
+		if erow.Info.gitBlame != nil {
+			if err := gitBlameShow(erow, ev.AnnotationIndex); err != nil {
+				erow.Ed.Error(err)
+			}
+			return
+		}
 		// TODO: print full annotation string
 		erow.Ed.Messagef("TODO: %#v\n", ev0)
 	})
@@ -112,7 +132,7 @@ func (erow *ERow) initHandlers() {
 			mods := evt.Mods.ClearLocks()
 			switch {
 			case mods.Is(event.ModCtrl) && evt.LowerRune() == 's':
-				if err := erow.Info.SaveFile(); err != nil {
+				if err := erow.Info.SaveFile(false); err != nil {
 					erow.Ed.Error(err)
 				}
 			case mods.Is(event.ModCtrl) && evt.LowerRune() == 'f':
@@ -131,6 +151,19 @@ func (erow *ERow) initHandlers() {
 		// ensure execution (if any) is stopped
 		erow.Exec.Stop()
 
+		erow.unlinkDiffScroll()
+
+		// keep undo history for later sessions
+		if len(erow.Info.ERows) == 1 {
+			if err := erow.Info.SaveHistory(); err != nil {
+				erow.Ed.Error(err)
+			}
+			// closing discards the unsaved content
+			if err := erow.Info.removeRecoverySnapshot(); err != nil {
+				erow.Ed.Error(err)
+			}
+		}
+
 		// unregister from editor
 		erow.Info.RemoveERow(erow)
 		if len(erow.Info.ERows) == 0 {
@@ -144,6 +177,7 @@ func (erow *ERow) initHandlers() {
 		// unregister with watcher
 		if !erow.Info.IsSpecial() && len(erow.Info.ERows) == 0 {
 			erow.Ed.Watcher.Remove(erow.Info.Name())
+			erow.Info.lsprotoDidClose()
 		}
 
 		// add to reopener to allow to reopen later if needed
@@ -237,13 +271,10 @@ func (erow *ERow) TextAreaAppendAsync(str string) <-chan struct{} {
 	return comm
 }
 
+// Output is not truncated, the text buffer (rope) appends cheaply.
 func (erow *ERow) textAreaAppend(str string) {
-	// TODO: unlimited output? some xterms have more or less 64k limit. Bigger limits will slow down the ui since it will be calculating the new string content. This will be improved once the textarea drawer supports append/cutTop operations.
-
-	maxSize := 64 * 1024
-
 	ta := erow.Row.TextArea
-	if err := ta.AppendStrClearHistory(str, maxSize); err != nil {
+	if err := ta.AppendStrClearHistory(str, 0); err != nil {
 		erow.Ed.Error(err)
 	}
 }
@@ -278,6 +309,14 @@ func (erow *ERow) readLoopToTextArea(reader io.Reader) {
 
 //----------
 
+// Copy of the textarea content, safe to use in other goroutines while the row is edited.
+func (erow *ERow) TextAreaBytesCopy() ([]byte, error) {
+	rw := erow.Row.TextArea.TextCursor.RW()
+	return rw.ReadNAt(0, rw.Len())
+}
+
+//----------
+
 func (erow *ERow) Flash() {
 	p, ok := erow.TbData.PartAtIndex(0)
 	if ok {
@@ -308,9 +347,11 @@ func (erow *ERow) MakeRangeVisibleAndFlash(index int, len int) {
 
 //----------
 
-func (erow *ERow) setupTextAreaCommentString() {
+func (erow *ERow) setupTextAreaSyntax() {
 	ta := erow.Row.TextArea
-	switch filepath.Ext(erow.Info.Name()) {
+	ext := filepath.Ext(erow.Info.Name())
+	ta.SetTokenizer(syntaxutil.ExtTokenizer(ext))
+	switch ext {
 	default:
 		fallthrough
 	case "", ".sh", ".conf", ".list", ".txt":
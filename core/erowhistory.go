@@ -0,0 +1,99 @@
+package core
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+)
+
+// Files undo history is kept in this directory across editor sessions. A history is only restored if the file content on disk matches the content hash at the time the history was saved.
+func historyDir() string {
+	home := os.Getenv("HOME")
+	return filepath.Join(home, ".editor_history")
+}
+
+// History filename is the hash of the file path.
+func historyFilename(name string) string {
+	h := bytesHash([]byte(name))
+	return filepath.Join(historyDir(), hex.EncodeToString(h))
+}
+
+//----------
+
+type historyFile struct {
+	Name    string
+	Hash    []byte // content hash at the history position
+	History []byte
+}
+
+//----------
+
+// Saves the history of the file rows to disk. Only saves if the rows content matches the disk content, otherwise the previously saved history (if any) is kept since it could still match the disk content.
+func (info *ERowInfo) SaveHistory() error {
+	if !info.IsFileButNotDir() || len(info.ERows) == 0 {
+		return nil
+	}
+	ta := info.ERows[0].Row.TextArea
+	b, err := ta.Bytes()
+	if err != nil {
+		return err
+	}
+	h := bytesHash(b)
+	if !bytes.Equal(h, info.fsHash.hash) {
+		return nil
+	}
+
+	buf := &bytes.Buffer{}
+	if err := ta.TextHistory.Encode(buf); err != nil {
+		return err
+	}
+	hf := &historyFile{Name: info.Name(), Hash: h, History: buf.Bytes()}
+
+	if err := os.MkdirAll(historyDir(), 0700); err != nil {
+		return err
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	f, err := os.OpenFile(historyFilename(info.Name()), flags, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return gob.NewEncoder(f).Encode(hf)
+}
+
+// Restores the history from disk if the disk content hash matches. A history that doesn't match is discarded.
+func (info *ERowInfo) loadHistory(erow *ERow) error {
+	filename := historyFilename(info.Name())
+	f, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	hf := &historyFile{}
+	if err := gob.NewDecoder(f).Decode(hf); err != nil {
+		_ = os.Remove(filename)
+		return err
+	}
+	if hf.Name != info.Name() || !bytes.Equal(hf.Hash, info.fsHash.hash) {
+		return os.Remove(filename)
+	}
+
+	return erow.Row.TextArea.TextHistory.Decode(bytes.NewReader(hf.History))
+}
+
+//----------
+
+// Saves the history of all open files.
+func (ed *Editor) SaveHistories() {
+	for _, info := range ed.ERowInfos {
+		if err := info.SaveHistory(); err != nil {
+			ed.Error(err)
+		}
+	}
+}
@@ -12,6 +12,9 @@ import (
 	"time"
 
 	"github.com/jmigpin/editor/ui"
+	"github.com/jmigpin/editor/util/diffutil"
+	"github.com/jmigpin/editor/util/gitutil"
+	"github.com/jmigpin/editor/util/osutil"
 )
 
 // Editor Row file Info.
@@ -25,13 +28,35 @@ type ERowInfo struct {
 	fiErr error
 
 	savedHash struct { // saved (known) filesystem hash
-		size int
-		hash []byte
+		size    int
+		hash    []byte
+		content []byte // base for merging with external changes
 	}
 	fsHash struct { // filesystem hash
 		modTime time.Time
 		hash    []byte
 	}
+
+	recoveryHash []byte // content hash of the last recovery snapshot
+
+	gitHead struct { // file content at git HEAD (changed lines markers)
+		content []byte
+		ok      bool
+	}
+	gitMarkers struct { // changed lines markers update
+		seq   int // discards outdated results
+		timer *time.Timer
+	}
+	gitBlame []*gitutil.BlameLine // lines shown as annotations
+
+	goSemantic struct { // semantic highlighting
+		seq   int // discards outdated results
+		timer *time.Timer
+	}
+	lsprotoChange struct { // language server content sync
+		seq   int // discards outdated updates
+		timer *time.Timer
+	}
 }
 
 // Not to be created directly. Only the editor instance will check if another info already exists.
@@ -105,9 +130,10 @@ func (info *ERowInfo) Dir() string {
 
 //----------
 
-func (info *ERowInfo) setSavedHash(hash []byte, size int) {
-	info.savedHash.size = size
+func (info *ERowInfo) setSavedHash(hash []byte, b []byte) {
+	info.savedHash.size = len(b)
 	info.savedHash.hash = hash
+	info.savedHash.content = b
 	info.UpdateFsDifferRowState()
 }
 
@@ -233,6 +259,8 @@ func (info *ERowInfo) NewFileERow(rowPos *ui.RowPos) (*ERow, error) {
 
 		// update the new erow with content
 		info.SetRowsStrFromMaster(erow0)
+		info.UpdateGitMarkers()
+		info.UpdateGoSemantic()
 
 		return erow, nil
 	}
@@ -244,12 +272,21 @@ func (info *ERowInfo) NewFileERow(rowPos *ui.RowPos) (*ERow, error) {
 	}
 
 	// update data
-	info.setSavedHash(info.fsHash.hash, len(b))
+	info.setSavedHash(info.fsHash.hash, b)
 
 	// new erow (no other rows exist)
 	erow := NewERow(info.Ed, info, rowPos)
 	erow.Row.TextArea.SetBytesClearHistory(b)
 
+	// restore undo history from previous sessions
+	if err := info.loadHistory(erow); err != nil {
+		info.Ed.Error(err)
+	}
+
+	info.updateGitHead()
+	info.UpdateGoSemantic()
+	info.lsprotoDidChange()
+
 	return erow, nil
 }
 
@@ -260,22 +297,62 @@ func (info *ERowInfo) ReloadFile() error {
 	}
 
 	// update data
-	info.setSavedHash(info.fsHash.hash, len(b))
+	info.setSavedHash(info.fsHash.hash, b)
 
 	// update all erows
 	info.SetRowsBytes(b)
 
-	return nil
+	info.updateGitHead()
+
+	return info.SaveHistory()
+}
+
+// Merges the rows edits with the changes on disk since the last save/reload. Conflicts are marked in the content. The merge can be undone.
+func (info *ERowInfo) MergeReloadFile() (conflicts int, _ error) {
+	if !info.IsFileButNotDir() {
+		return 0, fmt.Errorf("not a file: %s", info.Name())
+	}
+	if len(info.ERows) == 0 {
+		return 0, nil
+	}
+	b, edited, err := info.rowsEdited()
+	if err != nil {
+		return 0, err
+	}
+	if !edited {
+		return 0, info.ReloadFile()
+	}
+
+	disk, err := info.readFsFile()
+	if err != nil {
+		return 0, err
+	}
+	base := info.savedHash.content
+	merged, conflicts := diffutil.Merge3(base, b, disk, "edited", "disk")
+
+	// the disk content is now the known saved content
+	info.setSavedHash(info.fsHash.hash, disk)
+
+	// update all erows (keeps undo history)
+	info.SetRowsBytes(merged)
+
+	return conflicts, nil
 }
 
 //----------
 
-// Save file and update rows.
-func (info *ERowInfo) SaveFile() error {
+// Save file and update rows. Fails if the file changed on disk since the last save/reload, unless forced.
+func (info *ERowInfo) SaveFile(force bool) error {
 	if len(info.ERows) == 0 {
 		return nil
 	}
 
+	if !force {
+		if err := info.checkFsChanged(); err != nil {
+			return err
+		}
+	}
+
 	// read from one of the erows
 	erow0 := info.ERows[0]
 	b, err := erow0.Row.TextArea.Bytes()
@@ -291,8 +368,37 @@ func (info *ERowInfo) SaveFile() error {
 
 	// update all erows
 	info.SetRowsBytes(b2)
+	info.lsprotoDidSave()
 
-	return nil
+	// content is on disk, remove recovery snapshot (even from previous sessions)
+	info.recoveryHash = nil
+	if err := info.Ed.recovery.remove(info.Name()); err != nil {
+		return err
+	}
+
+	return info.SaveHistory()
+}
+
+func (info *ERowInfo) checkFsChanged() error {
+	if !info.IsFileButNotDir() {
+		return nil
+	}
+	b, err := ioutil.ReadFile(info.Name())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // will be created
+		}
+		return err
+	}
+	h := bytesHash(b)
+	if bytes.Equal(h, info.savedHash.hash) {
+		return nil
+	}
+	// update data (row state)
+	info.readFileInfo()
+	info.setFsHash(h)
+
+	return fmt.Errorf("%v: changed on disk since last save/reload: use \"Save -force\" to overwrite, \"MergeReload\" to merge, or \"DiffDisk\" to compare", info.Name())
 }
 
 func (info *ERowInfo) saveFile(b []byte) (_ []byte, changes bool, _ error) {
@@ -334,15 +440,7 @@ func (info *ERowInfo) readFsFile() ([]byte, error) {
 }
 
 func (info *ERowInfo) saveFsFile(b []byte) error {
-	flags := os.O_WRONLY | os.O_TRUNC | os.O_CREATE
-	f, err := os.OpenFile(info.Name(), flags, 0644)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	defer f.Sync() // necessary? modtime needs fsync on dir?
-	_, err = f.Write(b)
-	if err != nil {
+	if err := osutil.WriteFileAtomic(info.Name(), b, 0644); err != nil {
 		return err
 	}
 
@@ -350,7 +448,7 @@ func (info *ERowInfo) saveFsFile(b []byte) error {
 	h := bytesHash(b)
 	info.readFileInfo() // get new modtime
 	info.setFsHash(h)
-	info.setSavedHash(h, len(b))
+	info.setSavedHash(h, b)
 
 	return nil
 }
@@ -371,23 +469,33 @@ func (info *ERowInfo) UpdateEditedRowState() {
 	if !info.IsFileButNotDir() {
 		return
 	}
-	edited := false
 	if len(info.ERows) == 0 {
 		return
 	}
+	// size is checked first to avoid reading the content (called on every edit)
+	edited := info.ERows[0].Row.TextArea.TextCursor.RW().Len() != info.savedHash.size
+	if !edited {
+		_, edited2, err := info.rowsEdited()
+		if err != nil {
+			info.Ed.Error(err)
+		}
+		edited = edited2
+	}
+	info.updateRowState(ui.RowStateEdited, edited)
+}
+
+// Rows content and if it differs from the saved content. Expects at least one row.
+func (info *ERowInfo) rowsEdited() ([]byte, bool, error) {
 	erow0 := info.ERows[0]
 	b, err := erow0.Row.TextArea.Bytes()
 	if err != nil {
-		info.Ed.Error(err)
 		b = []byte{}
 	}
 	if len(b) != info.savedHash.size {
-		edited = true
-	} else {
-		hash2 := bytesHash(b)
-		edited = !bytes.Equal(hash2, info.savedHash.hash)
+		return b, true, err
 	}
-	info.updateRowState(ui.RowStateEdited, edited)
+	hash2 := bytesHash(b)
+	return b, !bytes.Equal(hash2, info.savedHash.hash), err
 }
 
 func (info *ERowInfo) UpdateExistsRowState() {
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/jmigpin/editor/core/parseutil"
+	"github.com/jmigpin/editor/core/toolbarparser"
+	"github.com/jmigpin/editor/util/iout"
+)
+
+// Lists all matches in the "+FindAll" row as "file:line:col: text" lines to be opened by the filename content cmd. Same flags as the find cmd.
+func FindAllCmd(erow *ERow, part *toolbarparser.Part) error {
+	if !erow.Info.IsFileButNotDir() {
+		return fmt.Errorf("not a file")
+	}
+
+	fa, err := parseFindCmdArgs(part)
+	if err != nil {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	filename := parseutil.EscapeFilename(erow.Info.Name())
+	r := erow.Row.TextArea.TextCursor.RW()
+	n, err := writeFindAll(buf, r, filename, fa)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("string not found: %q", fa.str)
+	}
+
+	erow2, _ := erow.Ed.ExistingOrNewERow("+FindAll")
+	erow2.Row.TextArea.SetBytesClearPos(buf.Bytes())
+	erow2.Flash()
+
+	return nil
+}
+
+//----------
+
+func writeFindAll(w io.Writer, r iout.Reader, filename string, fa *findCmdArgs) (int, error) {
+	// matches start and end
+	var ms [][]int
+	if fa.re != nil {
+		// single pass over the content (same matches as regexp.FindAll)
+		ms2, err := iout.FindAllRegexp(r, 0, r.Len(), fa.re)
+		if err != nil {
+			return 0, err
+		}
+		ms = ms2
+	} else {
+		if fa.str == "" {
+			return 0, nil
+		}
+		sep := []byte(fa.str)
+		if fa.ignoreCase {
+			sep = bytes.ToLower(sep)
+		}
+		for i := 0; i < r.Len(); {
+			j, err := iout.Index(r, i, r.Len()-i, sep, fa.ignoreCase)
+			if err != nil {
+				return 0, err
+			}
+			if j < 0 {
+				break
+			}
+			ms = append(ms, []int{j, j + len(sep)})
+			i = j + len(sep)
+		}
+	}
+
+	lc := &lineCounter{r: r, line: 1}
+	n := 0
+	for _, m := range ms {
+		// empty matches are not listed
+		if m[1] == m[0] {
+			continue
+		}
+		line, col, text, err := lc.position(m[0])
+		if err != nil {
+			return n, err
+		}
+		fmt.Fprintf(w, "%v:%v:%v: %s\n", filename, line, col, text)
+		n++
+	}
+	return n, nil
+}
+
+//----------
+
+// Keeps the line count while advancing forward in the reader.
+type lineCounter struct {
+	r         iout.Reader
+	i         int
+	line      int
+	lineStart int
+}
+
+// Line and column start at 1. Index must not be lower then previous calls.
+func (lc *lineCounter) position(index int) (line, col int, text []byte, _ error) {
+	// count lines up to index
+	b, err := lc.r.ReadNSliceAt(lc.i, index-lc.i)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	lc.line += bytes.Count(b, []byte("\n"))
+	if j := bytes.LastIndexByte(b, '\n'); j >= 0 {
+		lc.lineStart = lc.i + j + 1
+	}
+	lc.i = index
+
+	// column in runes
+	b, err = lc.r.ReadNSliceAt(lc.lineStart, index-lc.lineStart)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	col = utf8.RuneCount(b) + 1
+
+	// line text
+	le, err := iout.Index(lc.r, index, lc.r.Len()-index, []byte("\n"), false)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if le < 0 {
+		le = lc.r.Len()
+	}
+	text, err = lc.r.ReadNAt(lc.lineStart, le-lc.lineStart)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	return lc.line, col, text, nil
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/jmigpin/editor/util/iout"
+)
+
+func TestWriteFindAll(t *testing.T) {
+	type test struct {
+		s   string
+		fa  *findCmdArgs
+		out string
+	}
+	re := func(s string) *findCmdArgs {
+		return &findCmdArgs{str: s, re: regexp.MustCompile(s)}
+	}
+	tests := []test{
+		{"abc\nabc", &findCmdArgs{str: "b"}, "f:1:2: abc\nf:2:2: abc\n"},
+		{"aBc\nabc", &findCmdArgs{str: "b", ignoreCase: true}, "f:1:2: aBc\nf:2:2: abc\n"},
+		{"aaa\nbab", re("^a"), "f:1:1: aaa\n"},
+		{"aaa\nbab", re("(?m)^a"), "f:1:1: aaa\n"},
+		{"aaa\nab", re("(?m)^a"), "f:1:1: aaa\nf:2:1: ab\n"},
+		{"foobar bar", re(`\bbar`), "f:1:8: foobar bar\n"},
+		{"baaac", re("a*"), "f:1:2: baaac\n"},
+		{"ção\nção", re("o"), "f:1:3: ção\nf:2:3: ção\n"},
+		{"abc", re("z"), ""},
+	}
+	for i, tt := range tests {
+		buf := &bytes.Buffer{}
+		r := iout.NewRW([]byte(tt.s))
+		if _, err := writeFindAll(buf, r, "f", tt.fa); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.out {
+			t.Fatalf("test %v: %q != %q", i, buf.String(), tt.out)
+		}
+	}
+}
+
+func TestLineCounter(t *testing.T) {
+	s := "ab\ncd\n\nçé"
+	lc := &lineCounter{r: iout.NewRW([]byte(s)), line: 1}
+	type pos struct {
+		index, line, col int
+		text             string
+	}
+	for _, p := range []pos{{0, 1, 1, "ab"}, {4, 2, 2, "cd"}, {6, 3, 1, ""}, {9, 4, 2, "çé"}} {
+		line, col, text, err := lc.position(p.index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line != p.line || col != p.col || string(text) != p.text {
+			t.Fatalf("index %v: %v:%v:%q", p.index, line, col, text)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"bytes"
+	"regexp"
 	"strconv"
 
 	"github.com/jmigpin/editor/core/toolbarparser"
@@ -33,10 +34,6 @@ func findShortcut2(erow *ERow) error {
 		// don't use if selection has more then one line
 		if !bytes.ContainsRune(s, '\n') {
 			searchStr = s
-			// quote if it has spaces
-			if bytes.ContainsRune(searchStr, ' ') {
-				searchStr = []byte(strconv.Quote(string(searchStr)))
-			}
 		}
 	}
 
@@ -51,6 +48,27 @@ func findShortcut2(erow *ERow) error {
 		}
 	}
 
+	// keep find cmd flags
+	flagsEnd := 0
+	if found {
+		flagsEnd = part.Args[0].End
+		for _, arg := range part.Args[1:] {
+			s := arg.Str()
+			if s != "-re" && s != "-case" {
+				break
+			}
+			flagsEnd = arg.End
+			if s == "-re" {
+				searchStr = []byte(regexp.QuoteMeta(string(searchStr)))
+			}
+		}
+	}
+
+	// quote if it has spaces
+	if bytes.ContainsRune(searchStr, ' ') {
+		searchStr = []byte(strconv.Quote(string(searchStr)))
+	}
+
 	tb := erow.Row.Toolbar
 	tc := erow.Row.Toolbar.TextCursor
 	tc.BeginEdit()
@@ -58,7 +76,7 @@ func findShortcut2(erow *ERow) error {
 
 	if found {
 		// select current find cmd string
-		a := part.Args[0].End
+		a := flagsEnd
 		b := part.End
 		if a == b {
 			if err := tc.RW().Insert(a, []byte(" ")); err != nil {
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/jmigpin/editor/util/iout"
+	"github.com/jmigpin/editor/util/syntaxutil"
+)
+
+// Folds the innermost region at the cursor line that is not folded yet.
+func FoldCmd(erow *ERow) error {
+	rs, err := foldRegions(erow)
+	if err != nil {
+		return err
+	}
+	ta := erow.Row.TextArea
+	ci := ta.TextCursor.Index()
+	var fr *syntaxutil.FoldRegion
+	for _, r := range rs {
+		// region starts at the end of the cursor line or in a previous line
+		ls, err := iout.LineStartIndex(ta.TextCursor.RW(), r.Pos)
+		if err != nil {
+			return err
+		}
+		if ls <= ci && ci <= r.End && !ta.IsFolded(r.Pos, r.End) {
+			fr = r // regions are ordered, last is the innermost
+		}
+	}
+	if fr == nil {
+		return fmt.Errorf("no region to fold at cursor")
+	}
+	ta.Fold(fr.Pos, fr.End)
+	return nil
+}
+
+// Folds all the outermost regions.
+func FoldAllCmd(erow *ERow) error {
+	rs, err := foldRegions(erow)
+	if err != nil {
+		return err
+	}
+	ta := erow.Row.TextArea
+	end := -1
+	for _, r := range rs {
+		if r.Pos < end {
+			continue // nested
+		}
+		ta.Fold(r.Pos, r.End)
+		end = r.End
+	}
+	return nil
+}
+
+// Unfolds the regions at the cursor (or selection) lines.
+func UnfoldCmd(erow *ERow) error {
+	ta := erow.Row.TextArea
+	a, b, _, err := ta.TextCursor.LinesIndexes()
+	if err != nil {
+		return err
+	}
+	if !ta.Unfold(a, b) {
+		return fmt.Errorf("no folded region at cursor")
+	}
+	return nil
+}
+
+func UnfoldAllCmd(erow *ERow) error {
+	erow.Row.TextArea.UnfoldAll()
+	return nil
+}
+
+//----------
+
+// Go files use the braces structure, other files use the indentation.
+func foldRegions(erow *ERow) ([]*syntaxutil.FoldRegion, error) {
+	b, err := erow.Row.TextArea.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Ext(erow.Info.Name()) == ".go" {
+		return syntaxutil.GoFoldRegions(b), nil
+	}
+	return syntaxutil.IndentFoldRegions(b), nil
+}
@@ -208,6 +208,10 @@ func (tw *TargetWatcher) reviewWatch(watch string, ev *Event) []*Event {
 func (tw *TargetWatcher) reviewWatchName(w *Watch, n *Name, ev *Event) *Event {
 	// emit event
 	if w.Str == n.Str {
+		// name was removed or replaced (ex: renamed over by an atomic save), the watch needs to be added again
+		if ev.Op.HasAny(Remove | Rename) {
+			tw.rewatchName(w, n)
+		}
 		return ev
 	}
 
@@ -218,16 +222,7 @@ func (tw *TargetWatcher) reviewWatchName(w *Watch, n *Name, ev *Event) *Event {
 		return nil
 	}
 
-	tw.link(p, n.Str)
-
-	// clear old watch
-	if w.Str != p {
-		delete(w.Names, n.Str)
-		if len(w.Names) == 0 {
-			delete(tw.m.watches, w.Str)
-			_ = tw.Watcher.Remove(w.Str)
-		}
-	}
+	tw.relink(w, n, p)
 
 	// NOTE: the ev name is not n.Str
 
@@ -266,6 +261,29 @@ func (tw *TargetWatcher) reviewWatchName(w *Watch, n *Name, ev *Event) *Event {
 	return ev2
 }
 
+func (tw *TargetWatcher) rewatchName(w *Watch, n *Name) {
+	p, err := tw.addCloseWatch(n.Str)
+	if err != nil {
+		err2 := errors.Wrap(err, "rewatch name")
+		log.Print(err2)
+		return
+	}
+	tw.relink(w, n, p)
+}
+
+func (tw *TargetWatcher) relink(w *Watch, n *Name, p string) {
+	tw.link(p, n.Str)
+
+	// clear old watch
+	if w.Str != p {
+		delete(w.Names, n.Str)
+		if len(w.Names) == 0 {
+			delete(tw.m.watches, w.Str)
+			_ = tw.Watcher.Remove(w.Str)
+		}
+	}
+}
+
 //----------
 
 func (tw *TargetWatcher) eventLoop() {
@@ -148,3 +148,35 @@ func TestTargetWatcher4(t *testing.T) {
 	//	return ev.Name == file1 && ev.Op.HasAny(Modify)
 	//})
 }
+
+func TestTargetWatcher5(t *testing.T) {
+	tmpDir := tmpDir()
+	defer os.RemoveAll(tmpDir)
+
+	fw := mustNew(t)
+	*fw.OpMask() = Remove | Modify
+	w := NewTargetWatcher(fw)
+	defer w.Close()
+
+	dir := tmpDir
+	file1 := path.Join(dir, "file1.txt")
+	file2 := path.Join(dir, "file2.txt")
+
+	mustCreateFile(t, file1)
+	mustCreateFile(t, file2)
+	mustAdd(t, w, file1)
+
+	// file replaced (atomic save)
+	mustRenameFile(t, file2, file1)
+
+	readEvent(t, w, true, func(ev *Event) bool {
+		return ev.Name == file1 && ev.Op.HasAny(Remove)
+	})
+
+	// still watching the new file
+	mustWriteFile(t, file1)
+
+	readEvent(t, w, true, func(ev *Event) bool {
+		return ev.Name == file1 && ev.Op.HasAny(Modify)
+	})
+}
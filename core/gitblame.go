@@ -0,0 +1,107 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/jmigpin/editor/util/diffutil"
+	"github.com/jmigpin/editor/util/drawutil/drawer3"
+	"github.com/jmigpin/editor/util/gitutil"
+)
+
+// Shows the git blame of the row content as annotations at the end of each line. Clicking an annotation shows the commit. Runs in the background (cancellable with "Stop").
+func GitBlameCmd(erow *ERow) error {
+	if !erow.Info.IsFileButNotDir() {
+		return fmt.Errorf("not a file")
+	}
+	b, err := erow.Row.TextArea.Bytes()
+	if err != nil {
+		return err
+	}
+
+	info := erow.Info
+	name := info.Name()
+	ctx := erow.Exec.Start()
+	go func() {
+		lines, err := gitutil.Blame(ctx, name, b)
+		erow.Exec.Clear(ctx, nil)
+		erow.Ed.UI.RunOnUIGoRoutine(func() {
+			if err != nil {
+				erow.Ed.Error(err)
+				return
+			}
+			info.setGitBlame(b, lines)
+		})
+	}()
+	return nil
+}
+
+//----------
+
+func (info *ERowInfo) setGitBlame(b []byte, lines []*gitutil.BlameLine) {
+	if len(info.ERows) == 0 {
+		return
+	}
+	// content changed while running
+	b2, err := info.ERows[0].Row.TextArea.Bytes()
+	if err != nil || !bytes.Equal(b, b2) {
+		return
+	}
+
+	offsets := lineOffsets(diffutil.SplitLines(b))
+	entries := make([]*drawer3.Annotation, len(lines))
+	for i, l := range lines {
+		if l == nil || i >= len(offsets) {
+			continue
+		}
+		s := "not committed"
+		if l.Committed() {
+			s = fmt.Sprintf("%.8s %v %v", l.Hash, l.Time.Format("2006-01-02"), l.Author)
+		}
+		entries[i] = &drawer3.Annotation{Offset: offsets[i], Bytes: []byte(s)}
+	}
+
+	info.gitBlame = lines
+	info.setAnnotations(entries)
+}
+
+// Content changed, the blame lines are outdated.
+func (info *ERowInfo) clearGitBlame() {
+	if info.gitBlame == nil {
+		return
+	}
+	info.gitBlame = nil
+	info.setAnnotations(nil)
+}
+
+func (info *ERowInfo) setAnnotations(entries []*drawer3.Annotation) {
+	info.UpdateAnnotationsRowState(entries != nil)
+	for _, erow := range info.ERows {
+		ta := erow.Row.TextArea
+		if d, ok := ta.Drawer.(*drawer3.PosDrawer); ok {
+			d.Annotations.SetOn(entries != nil)
+			d.Annotations.Opt.Select.Line = -1
+			d.Annotations.Opt.Entries = entries
+			ta.MarkNeedsLayoutAndPaint()
+		}
+	}
+}
+
+//----------
+
+// Shows the commit of the blame annotation in the "+Git" row.
+func gitBlameShow(erow *ERow, index int) error {
+	lines := erow.Info.gitBlame
+	if index < 0 || index >= len(lines) || lines[index] == nil {
+		return fmt.Errorf("bad blame line index: %v", index)
+	}
+	l := lines[index]
+	if !l.Committed() {
+		return fmt.Errorf("line not committed yet")
+	}
+	return gitERowRun(erow, func(ctx context.Context, w io.Writer, dir string) error {
+		return gitExec(ctx, w, dir, "show", l.Hash)
+	})
+}
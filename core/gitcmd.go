@@ -0,0 +1,146 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/jmigpin/editor/core/parseutil"
+	"github.com/jmigpin/editor/core/toolbarparser"
+	"github.com/jmigpin/editor/util/gitutil"
+)
+
+// Shows the status of the row git working tree in the "+Git" row.
+func GitCmd(erow *ERow) error {
+	return gitERowRun(erow, func(ctx context.Context, w io.Writer, dir string) error {
+		return gitStatus(ctx, w, dir)
+	})
+}
+
+// Usage: GitDiff [-staged] [filename]
+func GitDiffCmd(erow *ERow, part *toolbarparser.Part) error {
+	args := []string{"diff"}
+	u := part.ArgsUnquoted()[1:]
+	if len(u) > 0 && u[0] == "-staged" {
+		args = append(args, "--staged")
+		u = u[1:]
+	}
+	names, err := gitFilenames(erow, u, false)
+	if err != nil {
+		return err
+	}
+	args = append(append(args, "--"), names...)
+	return gitERowRun(erow, func(ctx context.Context, w io.Writer, dir string) error {
+		return gitExec(ctx, w, dir, args...)
+	})
+}
+
+// Usage: GitAdd [filenames...]
+func GitAddCmd(erow *ERow, part *toolbarparser.Part) error {
+	names, err := gitFilenames(erow, part.ArgsUnquoted()[1:], true)
+	if err != nil {
+		return err
+	}
+	args := append([]string{"add", "--"}, names...)
+	return gitERowRun(erow, func(ctx context.Context, w io.Writer, dir string) error {
+		if err := gitExec(ctx, w, dir, args...); err != nil {
+			return err
+		}
+		return gitStatus(ctx, w, dir)
+	})
+}
+
+// Usage: GitCommit <message>
+func GitCommitCmd(erow *ERow, part *toolbarparser.Part) error {
+	msg := strings.Join(part.ArgsUnquoted()[1:], " ")
+	if strings.TrimSpace(msg) == "" {
+		return fmt.Errorf("expecting message")
+	}
+	return gitERowRun(erow, func(ctx context.Context, w io.Writer, dir string) error {
+		if err := gitExec(ctx, w, dir, "commit", "-m", msg); err != nil {
+			return err
+		}
+		erow.Ed.UI.RunOnUIGoRoutine(erow.Ed.updateGitHeads)
+		return gitStatus(ctx, w, dir)
+	})
+}
+
+//----------
+
+// Runs fn with the "+Git" row exec (cancellable with "Stop"). The dir is the erow directory.
+func gitERowRun(erow *ERow, fn func(ctx context.Context, w io.Writer, dir string) error) error {
+	dir := erow.Info.Dir()
+	if dir == "" {
+		return fmt.Errorf("not a file or directory row: %v", erow.Info.Name())
+	}
+
+	erow2, _ := erow.Ed.ExistingOrNewERow("+Git")
+	erow2.Row.TextArea.SetStrClearHistory("")
+	erow2.Row.TextArea.ClearPos()
+	erow2.Flash()
+
+	erow2.Exec.Run(func(ctx context.Context, w io.Writer) error {
+		return fn(ctx, w, dir)
+	})
+	return nil
+}
+
+func gitExec(ctx context.Context, w io.Writer, dir string, args ...string) error {
+	fmt.Fprintf(w, "# git %v\n", strings.Join(args, " "))
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = w
+	cmd.Stderr = w
+	return cmd.Run()
+}
+
+// Writes the status entries as clickable filenames.
+func gitStatus(ctx context.Context, w io.Writer, dir string) error {
+	entries, err := gitutil.Status(ctx, dir, false)
+	if err != nil {
+		return err
+	}
+	top, err := gitutil.TopLevel(ctx, dir)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "# git status: %v\n", top)
+	if len(entries) == 0 {
+		fmt.Fprintf(w, "# nothing to commit\n")
+	}
+	for _, e := range entries {
+		name := parseutil.EscapeFilename(e.Path)
+		if e.Dir {
+			name += "/"
+		}
+		if e.OrigPath != "" {
+			name = parseutil.EscapeFilename(e.OrigPath) + " -> " + name
+		}
+		fmt.Fprintf(w, "%2s %v\n", e.Code(), name)
+	}
+	return nil
+}
+
+// Filenames relative to the erow directory. Defaults to the erow file if there are no names (required if the erow is not a file).
+func gitFilenames(erow *ERow, names []string, required bool) ([]string, error) {
+	if len(names) == 0 {
+		if erow.Info.IsFileButNotDir() {
+			return []string{erow.Info.Name()}, nil
+		}
+		if required {
+			return nil, fmt.Errorf("expecting filename")
+		}
+		return nil, nil
+	}
+	w := []string{}
+	for _, n := range names {
+		if !filepath.IsAbs(n) {
+			n = filepath.Join(erow.Info.Dir(), n)
+		}
+		w = append(w, n)
+	}
+	return w, nil
+}
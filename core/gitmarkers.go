@@ -0,0 +1,102 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmigpin/editor/util/diffutil"
+	"github.com/jmigpin/editor/util/drawutil/drawer3"
+	"github.com/jmigpin/editor/util/gitutil"
+)
+
+// Reads the file content at git HEAD in the background, and updates the changed lines markers.
+func (info *ERowInfo) updateGitHead() {
+	if !info.IsFileButNotDir() {
+		return
+	}
+	name := info.Name()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		b, err := gitutil.ShowHead(ctx, name)
+		info.Ed.UI.RunOnUIGoRoutine(func() {
+			info.gitHead.content = b
+			info.gitHead.ok = err == nil // not in a git working tree, or not committed
+			info.UpdateGitMarkers()
+		})
+	}()
+}
+
+// Markers on the left border of the lines that differ from git HEAD. The content is read and diffed (in the background) after it stops changing (the current markers are kept meanwhile).
+func (info *ERowInfo) UpdateGitMarkers() {
+	if len(info.ERows) == 0 {
+		return
+	}
+
+	// results of previous updates are outdated
+	info.gitMarkers.seq++
+	seq := info.gitMarkers.seq
+	if info.gitMarkers.timer != nil {
+		info.gitMarkers.timer.Stop()
+	}
+
+	if !info.gitHead.ok {
+		info.setGitMarkers(nil)
+		return
+	}
+	info.gitMarkers.timer = time.AfterFunc(250*time.Millisecond, func() {
+		info.Ed.UI.RunOnUIGoRoutine(func() {
+			if info.gitMarkers.seq != seq || len(info.ERows) == 0 {
+				return
+			}
+			b, err := info.ERows[0].TextAreaBytesCopy()
+			if err != nil {
+				return
+			}
+			head := info.gitHead.content
+			go func() {
+				markers := gitMarkers(head, b)
+				info.Ed.UI.RunOnUIGoRoutine(func() {
+					// content or head might have changed meanwhile
+					if info.gitMarkers.seq == seq {
+						info.setGitMarkers(markers)
+					}
+				})
+			}()
+		})
+	})
+}
+
+func (info *ERowInfo) setGitMarkers(markers []*drawer3.GutterMarker) {
+	for _, e := range info.ERows {
+		e.Row.TextArea.SetGutterMarkers(markers)
+	}
+}
+
+func gitMarkers(head, b []byte) []*drawer3.GutterMarker {
+	var markers []*drawer3.GutterMarker
+	for _, c := range diffutil.LineChanges(head, b) {
+		m := &drawer3.GutterMarker{Pos: c.Pos, End: c.End}
+		switch {
+		case c.Inserted && c.Deleted:
+			m.Type = drawer3.GMModified
+		case c.Inserted:
+			m.Type = drawer3.GMInserted
+		default:
+			m.Type = drawer3.GMDeleted
+		}
+		markers = append(markers, m)
+	}
+	return markers
+}
+
+//----------
+
+// Git HEAD might have changed (ex: commit).
+func (ed *Editor) updateGitHeads() {
+	for _, info := range ed.ERowInfos {
+		if len(info.ERows) > 0 {
+			info.updateGitHead()
+		}
+	}
+}
@@ -284,6 +284,7 @@ func (gdi *GoDebugInstance) updateInfoUI(info *ERowInfo) {
 		}
 
 		info.UpdateAnnotationsRowState(true)
+		info.gitBlame = nil // annotations replaced
 
 		di := gdi.data.dataIndex
 		fmsgs := &di.FileMsgs[findex]
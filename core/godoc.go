@@ -0,0 +1,288 @@
+package core
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jmigpin/editor/core/gosource"
+	"github.com/jmigpin/editor/core/parseutil"
+	"github.com/jmigpin/editor/util/uiutil/event"
+)
+
+// Time the pointer rests over an identifier before showing its documentation.
+const goDocHoverDelay = 700 * time.Millisecond
+
+// Time without typing before updating the signature help.
+const goDocSigHelpDelay = 150 * time.Millisecond
+
+type goDocMode int
+
+const (
+	goDocModeCmd     goDocMode = iota // doc at the cursor, or signature help
+	goDocModeHover                    // doc under the pointer
+	goDocModeSigHelp                  // signature help while typing arguments
+)
+
+// Documentation of .go files shown in the context float box: type signature and doc comment of an identifier, or the parameters of a call with the current argument highlighted.
+type GoDocPopup struct {
+	ed   *Editor
+	seq  int // ignore results of older requests
+	mode goDocMode
+
+	shown bool
+	erow  *ERow
+	start int // hovered identifier
+	end   int
+
+	timer    *time.Timer
+	hoverSeq int
+
+	sigTimer *time.Timer
+	sigSeq   int
+
+	running bool          // a check is running in the background
+	pending func() func() // check waiting for the running one
+}
+
+func NewGoDocPopup(ed *Editor) *GoDocPopup {
+	return &GoDocPopup{ed: ed}
+}
+
+//----------
+
+// The completion popup shares the float box.
+func (gp *GoDocPopup) visible() bool {
+	return gp.shown && !gp.ed.cpopup.visible() && gp.ed.UI.Root.ContextFloatBox.Visible()
+}
+
+func (gp *GoDocPopup) Hide() {
+	gp.seq++
+	gp.sigSeq++ // cancel the signature help after typing
+	gp.pending = nil
+	if gp.visible() {
+		gp.ed.UI.Root.ContextFloatBox.Hide()
+	}
+	gp.shown = false
+	gp.erow = nil
+}
+
+//----------
+
+// Runs in the background (type checking can be slow). Errors are only reported in the cmd mode.
+func (gp *GoDocPopup) request(erow *ERow, index int, mode goDocMode) error {
+	if !erow.Info.IsFileButNotDir() || filepath.Ext(erow.Info.Name()) != ".go" {
+		return fmt.Errorf("not a .go file")
+	}
+	b, err := erow.TextAreaBytesCopy()
+	if err != nil {
+		return err
+	}
+	filename := erow.Info.Name()
+
+	gp.seq++
+	seq := gp.seq
+	gp.runCheck(func() func() {
+		var dres *gosource.DocResult
+		var sres *gosource.SignatureResult
+		var err error
+		if mode != goDocModeSigHelp {
+			dres, err = gosource.Doc(filename, b, index)
+		}
+		if mode == goDocModeSigHelp || (mode == goDocModeCmd && err != nil) {
+			sres, err = gosource.SignatureHelp(filename, b, index)
+		}
+		return func() {
+			if seq != gp.seq {
+				return
+			}
+			if err != nil {
+				if mode == goDocModeCmd {
+					gp.ed.Errorf("godoc: %v", err)
+				} else {
+					gp.Hide()
+				}
+				return
+			}
+			if gp.ed.cpopup.visible() || !erowIsOpen(erow) {
+				return
+			}
+			gp.mode = mode
+			if dres != nil {
+				gp.show(erow, dres.StartIndex, dres.Str, [2]int{}, dres.Doc)
+				gp.start, gp.end = dres.StartIndex, dres.EndIndex
+			} else {
+				gp.show(erow, sres.StartIndex, sres.Str, sres.Segment, sres.Doc)
+			}
+		}
+	})
+	return nil
+}
+
+// Runs the check in the background, and the returned func in the UI goroutine. Only one check runs at a time (type checking is cpu heavy), a check started meanwhile waits for it and replaces the older waiting one (never run).
+func (gp *GoDocPopup) runCheck(check func() func()) {
+	if gp.running {
+		gp.pending = check
+		return
+	}
+	gp.running = true
+	go func() {
+		fn := check()
+		gp.ed.UI.RunOnUIGoRoutine(func() {
+			gp.running = false
+			fn()
+			if check2 := gp.pending; check2 != nil {
+				gp.pending = nil
+				gp.runCheck(check2)
+			}
+		})
+	}()
+}
+
+// Shows the box below the line of index.
+func (gp *GoDocPopup) show(erow *ERow, index int, str string, seg [2]int, doc string) {
+	cfb := gp.ed.UI.Root.ContextFloatBox
+	cfb.SetText(str, seg)
+	cfb.SetDoc(doc)
+
+	ta := erow.Row.TextArea
+	p := ta.GetPoint(index)
+	p.Y += ta.LineHeight()
+	cfb.Show(p)
+	gp.erow = erow
+	gp.shown = true
+}
+
+//----------
+
+// Restarts the hover delay. Hides the hover documentation if the pointer left the identifier.
+func (gp *GoDocPopup) pointerMove(p image.Point, buttons event.MouseButtons) {
+	gp.hoverSeq++
+	if gp.timer != nil {
+		gp.timer.Stop()
+	}
+
+	erow, ok := gp.ed.textAreaERowAt(p)
+
+	if gp.visible() && gp.mode == goDocModeHover {
+		in := ok && erow == gp.erow
+		if in {
+			i := erow.Row.TextArea.GetIndex(p)
+			in = i >= gp.start && i < gp.end
+		}
+		if !in {
+			gp.Hide()
+		}
+	}
+
+	if !ok || buttons != 0 || filepath.Ext(erow.Info.Name()) != ".go" {
+		return
+	}
+	seq := gp.hoverSeq
+	gp.timer = time.AfterFunc(goDocHoverDelay, func() {
+		gp.ed.UI.RunOnUIGoRoutine(func() {
+			if seq != gp.hoverSeq || !erowIsOpen(erow) {
+				return
+			}
+			// don't replace other popups
+			if gp.ed.cpopup.visible() || (gp.visible() && gp.mode != goDocModeHover) {
+				return
+			}
+			index := erow.Row.TextArea.GetIndex(p)
+			if gp.visible() && index >= gp.start && index < gp.end {
+				return // already shown
+			}
+			if !goIdentAtIndex(erow, index) {
+				return
+			}
+			_ = gp.request(erow, index, goDocModeHover)
+		})
+	})
+}
+
+//----------
+
+// Keys: "(" and "," show the signature help, which is updated while typing. Esc closes.
+func (gp *GoDocPopup) handleInput(wi *event.WindowInput) event.Handle {
+	cfb := gp.ed.UI.Root.ContextFloatBox
+	switch t := wi.Event.(type) {
+	case *event.MouseMove:
+		if gp.visible() && wi.Point.In(cfb.Bounds) {
+			break
+		}
+		gp.pointerMove(wi.Point, t.Buttons)
+	case *event.KeyDown:
+		if gp.ed.cpopup.visible() {
+			break
+		}
+		m := t.Mods.ClearLocks()
+		if gp.visible() && m.Is(event.ModNone) && t.KeySym == event.KSymEscape {
+			return event.Handled // closed on keyup
+		}
+		sigHelp := gp.visible() && gp.mode == goDocModeSigHelp
+		if gp.visible() && !sigHelp {
+			gp.Hide()
+		}
+		if sigHelp || t.Rune == '(' || t.Rune == ',' {
+			gp.sigHelpAfterTyping()
+		}
+	case *event.KeyUp:
+		if gp.visible() && t.KeySym == event.KSymEscape {
+			gp.Hide()
+			return event.Handled
+		}
+	case *event.MouseDown:
+		if gp.visible() && !wi.Point.In(cfb.Bounds) {
+			gp.Hide()
+		}
+	}
+	return event.NotHandled
+}
+
+// Updates the signature help after the typing stops (the textarea handles the key before the delay ends). Uses the active row, which got the typed keys, and not the row under the pointer.
+func (gp *GoDocPopup) sigHelpAfterTyping() {
+	gp.sigSeq++
+	if gp.sigTimer != nil {
+		gp.sigTimer.Stop()
+	}
+	seq := gp.sigSeq
+	gp.sigTimer = time.AfterFunc(goDocSigHelpDelay, func() {
+		gp.ed.UI.RunOnUIGoRoutine(func() {
+			if seq != gp.sigSeq || gp.ed.cpopup.visible() {
+				return
+			}
+			erow, ok := gp.ed.ActiveERow()
+			if !ok || filepath.Ext(erow.Info.Name()) != ".go" {
+				return
+			}
+			index := erow.Row.TextArea.TextCursor.Index()
+			_ = gp.request(erow, index, goDocModeSigHelp)
+		})
+	})
+}
+
+//----------
+
+// Avoids type checking when the pointer is not over an identifier (ex: spaces, operators, numbers).
+func goIdentAtIndex(erow *ERow, index int) bool {
+	rw := erow.Row.TextArea.TextCursor.RW()
+	w, _, err := parseutil.WordAtIndex(rw, index, 100)
+	if err != nil {
+		return false
+	}
+	ru, _ := utf8.DecodeRune(w)
+	return !unicode.IsDigit(ru)
+}
+
+//----------
+
+// Shows the documentation of the identifier at the cursor, or the signature help if inside a call.
+func GoDocCmd(erow *ERow) error {
+	ed := erow.Ed
+	ed.cpopup.Hide()
+	index := erow.Row.TextArea.TextCursor.Index()
+	return ed.gdpopup.request(erow, index, goDocModeCmd)
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/jmigpin/editor/core/gosource"
+	"github.com/jmigpin/editor/core/parseutil"
+	"github.com/jmigpin/editor/ui"
+)
+
+// Lists the declaration and uses of the Go identifier at the cursor (in the package and its importers) in the "+References" row as clickable "file:line:col" lines.
+func GoReferencesCmd(erow *ERow) error {
+	if !erow.Info.IsFileButNotDir() || filepath.Ext(erow.Info.Name()) != ".go" {
+		return fmt.Errorf("not a .go file")
+	}
+	ta := erow.Row.TextArea
+	b, err := erow.TextAreaBytesCopy()
+	if err != nil {
+		return err
+	}
+	index := ta.TextCursor.Index()
+	filename := erow.Info.Name()
+	overlay := goOverlay(erow.Ed)
+	overlay[filename] = b
+
+	erow2, _ := erow.Ed.ExistingOrNewERow("+References")
+	erow2.Row.TextArea.SetStrClearHistory("")
+	erow2.Row.TextArea.ClearPos()
+	erow2.Flash()
+
+	erow2.Exec.Run(func(ctx context.Context, w io.Writer) error {
+		poss, err := gosource.References(ctx, filename, b, index, overlay)
+		if err != nil {
+			return err
+		}
+		if len(poss) == 0 {
+			return fmt.Errorf("no references found")
+		}
+		files := map[string][]byte{}
+		for _, pos := range poss {
+			fb, ok := files[pos.Filename]
+			if !ok {
+				fb, ok = overlay[pos.Filename]
+				if !ok {
+					fb, err = ioutil.ReadFile(pos.Filename)
+					if err != nil {
+						return err
+					}
+				}
+				files[pos.Filename] = fb
+			}
+			if pos.Offset > len(fb) {
+				continue
+			}
+			line, col := offsetLineColumn(fb, pos.Offset)
+			name := parseutil.EscapeFilename(pos.Filename)
+			fmt.Fprintf(w, "%v:%v:%v: %s\n", name, line, col, lineAt(fb, pos.Offset))
+		}
+		return nil
+	})
+	return nil
+}
+
+// Content of the edited (unsaved) .go rows, to be used instead of the files on disk.
+func goOverlay(ed *Editor) map[string][]byte {
+	m := map[string][]byte{}
+	for fn, info := range ed.ERowInfos {
+		if len(info.ERows) == 0 ||
+			!info.IsFileButNotDir() ||
+			filepath.Ext(fn) != ".go" ||
+			!info.ERows[0].Row.HasState(ui.RowStateEdited) {
+			continue
+		}
+		b, err := info.ERows[0].TextAreaBytesCopy()
+		if err != nil {
+			continue
+		}
+		m[fn] = b
+	}
+	return m
+}
@@ -1,51 +1,75 @@
 package core
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"go/token"
+	"path/filepath"
 
+	"github.com/jmigpin/editor/core/gosource"
 	"github.com/jmigpin/editor/core/toolbarparser"
-	"github.com/jmigpin/editor/ui"
 )
 
+// Renames the Go identifier at the cursor using type information. Open rows get the edits in their content (one undo step per row), files that are not open are changed on disk. Unsaved rows content is used, and conflicts are reported without changes.
 func GoRenameCmd(erow *ERow, part *toolbarparser.Part) error {
-	if !erow.Info.IsFileButNotDir() {
-		return fmt.Errorf("not a file")
-	}
-
-	if erow.Row.HasState(ui.RowStateEdited) {
-		return fmt.Errorf("row has edits, save first")
+	if !erow.Info.IsFileButNotDir() || filepath.Ext(erow.Info.Name()) != ".go" {
+		return fmt.Errorf("not a .go file")
 	}
 
 	// new name argument "to"
 	args := part.Args[1:]
-	if len(args) < 1 {
-		return fmt.Errorf("expecting at least 1 argument")
+	if len(args) != 1 {
+		return fmt.Errorf("expecting 1 argument")
 	}
-	to := args[len(args)-1].UnquotedStr()
+	to := args[0].UnquotedStr()
 
-	// allow other args
-	otherArgs := []string{}
-	for i := 0; i < len(args)-1; i++ {
-		otherArgs = append(otherArgs, args[i].UnquotedStr())
+	ta := erow.Row.TextArea
+	b, err := erow.TextAreaBytesCopy()
+	if err != nil {
+		return err
 	}
+	index := ta.TextCursor.Index()
+	filename := erow.Info.Name()
+	overlay := goOverlay(erow.Ed)
+	overlay[filename] = b
 
-	// id offset to rename "from"
-	offset := erow.Row.TextArea.TextCursor.Index()
-
-	// command
-	offsetStr := fmt.Sprintf("%v:#%v", erow.Info.Name(), offset)
-	cargs := []string{"gorename", "-offset", offsetStr, "-to", to}
-	cargs = append(cargs, otherArgs...)
+	ed := erow.Ed
+	go func() {
+		poss, from, err := gosource.Rename(context.Background(), filename, b, index, to, overlay)
+		ed.UI.RunOnUIGoRoutine(func() {
+			if err == nil {
+				err = goRenameApply(ed, poss, from, to)
+			}
+			if err != nil {
+				ed.Errorf("gorename: %v", err)
+				return
+			}
+			ed.Messagef("gorename: %v -> %v: %v references", from, to, len(poss))
+		})
+	}()
+	return nil
+}
 
-	reloadOnNoErr := func(err error) {
-		if err == nil {
-			erow.Ed.UI.RunOnUIGoRoutine(func() {
-				ReloadCmd(erow)
-			})
+func goRenameApply(ed *Editor, poss []token.Position, from, to string) error {
+	// the content could have changed while type checking
+	files := map[string][]byte{}
+	fedits := map[string][]*fileEdit{}
+	for _, pos := range poss {
+		b, ok := files[pos.Filename]
+		if !ok {
+			var err error
+			b, err = editorFileBytes(ed, pos.Filename)
+			if err != nil {
+				return err
+			}
+			files[pos.Filename] = b
 		}
+		end := pos.Offset + len(from)
+		if end > len(b) || !bytes.Equal(b[pos.Offset:end], []byte(from)) {
+			return fmt.Errorf("content changed: %v", pos)
+		}
+		fedits[pos.Filename] = append(fedits[pos.Filename], &fileEdit{pos.Offset, end, []byte(to)})
 	}
-
-	ExternalCmdFromArgs(erow, cargs, reloadOnNoErr)
-
-	return nil
+	return applyFileEdits(ed, fedits)
 }
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jmigpin/editor/core/gosource"
+	"github.com/jmigpin/editor/util/drawutil/drawer3"
+	"github.com/jmigpin/editor/util/syntaxutil"
+)
+
+// Semantic highlighting of go files. Computed in the background after the content stops changing (the content is only read then), and cached by content hash.
+func (info *ERowInfo) UpdateGoSemantic() {
+	if len(info.ERows) == 0 || filepath.Ext(info.Name()) != ".go" {
+		return
+	}
+
+	// results of previous updates are outdated
+	info.goSemantic.seq++
+	seq := info.goSemantic.seq
+	if info.goSemantic.timer != nil {
+		info.goSemantic.timer.Stop()
+	}
+
+	// current segments positions are kept updated by the textarea until the new segments are set
+
+	info.goSemantic.timer = time.AfterFunc(500*time.Millisecond, func() {
+		info.Ed.UI.RunOnUIGoRoutine(func() {
+			if info.goSemantic.seq != seq || len(info.ERows) == 0 {
+				return
+			}
+			b, err := info.ERows[0].TextAreaBytesCopy()
+			if err != nil {
+				return
+			}
+			name := info.Name()
+			hash := bytesHash(b)
+			if segs, ok := goSemanticCache.get(name, hash); ok {
+				info.setSyntaxSegments(segs)
+				return
+			}
+			go func() {
+				segs := goSemanticSegments(name, b)
+				goSemanticCache.add(name, hash, segs)
+				info.Ed.UI.RunOnUIGoRoutine(func() {
+					// content might have changed meanwhile
+					if info.goSemantic.seq == seq {
+						info.setSyntaxSegments(segs)
+					}
+				})
+			}()
+		})
+	})
+}
+
+func (info *ERowInfo) setSyntaxSegments(segs map[syntaxutil.Class][]*drawer3.Segment) {
+	for _, e := range info.ERows {
+		e.Row.TextArea.SetSyntaxSegments(segs)
+	}
+}
+
+func goSemanticSegments(filename string, b []byte) map[syntaxutil.Class][]*drawer3.Segment {
+	idents, err := gosource.SemanticIdents(filename, b)
+	if err != nil {
+		// parse errors: keep only the tokenizer colors
+		return nil
+	}
+	m := map[syntaxutil.Class][]*drawer3.Segment{}
+	for _, id := range idents {
+		seg := &drawer3.Segment{Pos: id.Offset, End: id.End}
+		m[id.Class] = append(m[id.Class], seg)
+	}
+	return m
+}
+
+//----------
+
+var goSemanticCache = newSemanticCache(32)
+
+// Segments by filename and content hash. Safe to use concurrently.
+type semanticCache struct {
+	sync.Mutex
+	max int
+	m   map[string]map[syntaxutil.Class][]*drawer3.Segment
+}
+
+func newSemanticCache(max int) *semanticCache {
+	return &semanticCache{max: max, m: map[string]map[syntaxutil.Class][]*drawer3.Segment{}}
+}
+
+func (sc *semanticCache) get(name string, hash []byte) (map[syntaxutil.Class][]*drawer3.Segment, bool) {
+	sc.Lock()
+	defer sc.Unlock()
+	segs, ok := sc.m[name+string(hash)]
+	return segs, ok
+}
+
+func (sc *semanticCache) add(name string, hash []byte, segs map[syntaxutil.Class][]*drawer3.Segment) {
+	sc.Lock()
+	defer sc.Unlock()
+	if len(sc.m) >= sc.max {
+		sc.m = map[string]map[syntaxutil.Class][]*drawer3.Segment{}
+	}
+	sc.m[name+string(hash)] = segs
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"go/ast"
+	"go/parser"
 	"go/token"
 	"go/types"
 	"log"
@@ -19,6 +20,8 @@ type CCResult struct {
 	Str        string
 	Segments   [][2]int
 	Objs       []types.Object
+
+	cc *CC2
 }
 
 func CodeCompletion(filename string, src interface{}, index int) (*CCResult, error) {
@@ -27,28 +30,28 @@ func CodeCompletion(filename string, src interface{}, index int) (*CCResult, err
 	if err != nil {
 		return nil, err
 	}
+	res.cc = cc
+	res.format()
+	return res, err
+}
 
-	//// show comment if the first result is matching
-	//comment := ""
-	//if len(res.Objs) > 0 {
-	//	obj := res.Objs[0]
-	//	seg := res.Segments[0]
-	//	if len(res.Objs) == 1 || (seg[0] == 0 && seg[1] == len(obj.Name())) {
-	//		s, ok := cc.objComment(obj)
-	//		if ok {
-	//			comment = "\n\n" + s
-	//		}
-	//	}
-	//}
+// Filters the candidates again with str (the text typed since StartIndex). Updates Objs, Segments and Str.
+func (res *CCResult) Filter(str string) {
+	res.cc.filter.str = str
+	res.cc.filterCandidates()
+	res.format()
+}
+
+// Doc comment of the object declaration.
+func (res *CCResult) Comment(obj types.Object) (string, bool) {
+	return res.cc.objComment(obj)
+}
 
+func (res *CCResult) format() {
 	// TODO: check args types
 	showArgs := len(res.Objs) < 3
 
 	FormatResult2(res, showArgs) // alters res.Str and res.Segments
-
-	//res.Str += comment
-
-	return res, err
 }
 
 //------------
@@ -59,22 +62,47 @@ type CC2 struct {
 	res     *DeclResolver
 	result  *CCResult
 
+	candidates []types.Object // before filtering
+
 	filter struct {
 		str     string
 		typeStr string
 		typeObj types.Object
 	}
+
+	// call argument (signature help)
+	arg struct {
+		sig   *types.Signature
+		index int
+	}
 }
 
 func (cc *CC2) run(filename string, src interface{}, index int) (*CCResult, error) {
+	ipos, src2, err := cc.setup(filename, src, index)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cc.candidatesInPos(ipos, src2); err != nil {
+		return nil, err
+	}
+
+	cc.candidates = cc.result.Objs
+	cc.filterCandidates()
+
+	return cc.result, nil
+}
+
+// Parses and type checks the file package. Returns the index position and the source.
+func (cc *CC2) setup(filename string, src interface{}, index int) (token.Pos, string, error) {
 	cc.result = &CCResult{StartIndex: index}
 	cc.conf = NewConfig()
-	//cc.conf.ParserMode = parser.ParseComments
+	cc.conf.ParserMode = parser.ParseComments // doc comments
 
 	// insert semicolon to improve code completion
 	b, err := ReadSource(filename, src)
 	if err != nil {
-		return nil, err
+		return 0, "", err
 	}
 	src2 := string(b)
 	//src2 := InsertSemicolon(string(b), index)
@@ -85,7 +113,7 @@ func (cc *CC2) run(filename string, src interface{}, index int) (*CCResult, erro
 	// parse and keep astfile
 	astFile, err, ok := cc.conf.ParseFile(filename, src2, 0)
 	if !ok {
-		return nil, err
+		return 0, "", err
 	}
 	cc.astFile = astFile
 
@@ -99,22 +127,16 @@ func (cc *CC2) run(filename string, src interface{}, index int) (*CCResult, erro
 	// index fset position
 	tf, err := cc.conf.PosTokenFile(cc.astFile.Package)
 	if err != nil {
-		return nil, err
+		return 0, "", err
 	}
 	if index >= tf.Size() {
-		return nil, fmt.Errorf("index bigger than filesize")
+		return 0, "", fmt.Errorf("index bigger than filesize")
 	}
 	ipos := token.Pos(tf.Base() + index)
 
 	cc.res = NewDeclResolver(cc.conf)
 
-	if err := cc.candidatesInPos(ipos, src2); err != nil {
-		return nil, err
-	}
-
-	cc.filterCandidates()
-
-	return cc.result, nil
+	return ipos, src2, nil
 }
 
 //------------
@@ -259,32 +281,33 @@ func (cc *CC2) candidatesInImportSpec(imp *ast.ImportSpec, reqNode ast.Node) err
 
 //------------
 
+// Signature of the called function and the argument index. The called object (if named) is the candidate.
 func (cc *CC2) candidatesInArg(fun ast.Expr, index int) error {
-	nt, err := cc.res.ResolveType(fun)
-	if err != nil {
-		return err
+	// help the checker
+	_, _ = cc.res.ResolveType(fun)
+
+	tv, ok := cc.conf.Info.Types[fun]
+	if !ok || tv.Type == nil {
+		return fmt.Errorf("unable to resolve call type")
+	}
+	sig, ok := tv.Type.Underlying().(*types.Signature)
+	if !ok {
+		return fmt.Errorf("not a function call: %v", tv.Type)
 	}
-	switch t := nt.(type) {
-	case *ast.Ident:
-		path := cc.conf.SurePosAstPath(t.Pos())
-		for _, p := range path {
-			switch p.(type) {
-			}
-		}
 
-		//case *ast.FuncType:
-		//	// index argument
-		//	j := 0
-		//	for _, f := range t.Params.List {
-		//		for range f.Names {
-		//			if j == index {
-		//				// argument type
-		//				return cc.candidatesOfType(f.Type, fun)
-		//			}
-		//			j++
-		//		}
-		//	}
+	var obj types.Object
+	switch t := fun.(type) {
+	case *ast.Ident:
+		obj = cc.conf.Info.Uses[t]
+	case *ast.SelectorExpr:
+		obj = cc.conf.Info.Uses[t.Sel]
+	}
+	if obj != nil {
+		cc.result.Objs = append(cc.result.Objs, obj)
 	}
+
+	cc.arg.sig = sig
+	cc.arg.index = index
 	return nil
 }
 
@@ -581,11 +604,23 @@ func (cc *CC2) objComment(obj types.Object) (string, bool) {
 		s = t.Doc.Text()
 	case *ast.ValueSpec:
 		s = t.Doc.Text()
+	case *ast.Field:
+		s = t.Doc.Text()
+		if s == "" {
+			s = t.Comment.Text()
+		}
 	default:
 		log.Printf("todo: %T", t)
 		return "", false
 	}
 
+	// non-grouped declaration: the doc is in the gendecl
+	if s == "" && len(path) > 2 {
+		if gd, ok := path[2].(*ast.GenDecl); ok && !gd.Lparen.IsValid() {
+			s = gd.Doc.Text()
+		}
+	}
+
 	s = strings.TrimRight(s, "\n")
 	if len(s) == 0 {
 		return "", false
@@ -604,7 +639,7 @@ func (cc *CC2) filterCandidates() {
 
 	var entries []entry
 	strLow := strings.ToLower(cc.filter.str)
-	for _, obj := range cc.result.Objs {
+	for _, obj := range cc.candidates {
 
 		// filter objects of this type
 		if cc.filter.typeObj != nil {
@@ -143,3 +143,63 @@ func TestCC5(t *testing.T) {
 	testCCSrc(t, src, 1, 2)
 	testCCSrc(t, src, 2, 1)
 }
+
+func TestCCFilter1(t *testing.T) {
+	src := `
+		package pack1
+		// Abc doc.
+		type Abc struct{
+			// Field doc.
+			abcField int
+			abcField2 int
+			xyz int
+		}
+		func f1(){
+			var u Abc
+			u.ab●
+			A●
+		}
+	`
+	src2, index, err := SourceCursor("●", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := CodeCompletion("t000/src.go", src2, index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Objs) != 2 {
+		t.Fatalf("expecting 2 results: %v", res.Objs)
+	}
+	res.Filter("")
+	if len(res.Objs) != 3 {
+		t.Fatalf("expecting 3 results: %v", res.Objs)
+	}
+	res.Filter("xy")
+	if len(res.Objs) != 1 || res.Str != "xyz" {
+		t.Fatalf("expecting xyz: %v", res.Str)
+	}
+	res.Filter("abcfield")
+	s, ok := res.Comment(res.Objs[0])
+	if !ok || s != "Field doc." {
+		t.Fatalf("comment: %q", s)
+	}
+
+	// doc in the non-grouped type declaration
+	src2, index, err = SourceCursor("●", src, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err = CodeCompletion("t000/src.go", src2, index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Filter("Abc")
+	if len(res.Objs) != 1 {
+		t.Fatalf("expecting 1 result: %v", res.Objs)
+	}
+	s, ok = res.Comment(res.Objs[0])
+	if !ok || s != "Abc doc." {
+		t.Fatalf("comment: %q", s)
+	}
+}
@@ -23,8 +23,9 @@ type Config struct {
 	astFiles   map[string]*ast.File
 	astFilesMu sync.RWMutex
 
-	importable    map[string]struct{}
-	dirExtraFiles map[string]map[string]struct{}
+	importable      map[string]struct{}
+	xtestImportable map[string]struct{}
+	dirExtraFiles   map[string]map[string]struct{}
 }
 
 func NewConfig() *Config {
@@ -34,6 +35,7 @@ func NewConfig() *Config {
 	conf.Pkgs = make(map[string]*types.Package)
 
 	conf.importable = make(map[string]struct{})
+	conf.xtestImportable = make(map[string]struct{})
 	conf.dirExtraFiles = make(map[string]map[string]struct{})
 
 	conf.Conf.Error = func(error) {} // non-nil to avoid stopping on first error
@@ -174,13 +176,47 @@ func (conf *Config) importPath2(path string) (*types.Package, error) {
 	return conf.check(path, astFiles)
 }
 
+// Type checks the external test package of the path, cached in Pkgs with the "_test" suffix. Nil if there are no files.
+func (conf *Config) importXTestPath(path string) (*types.Package, error) {
+	xpath := path + "_test"
+	if pkg, ok := conf.Pkgs[xpath]; ok {
+		return pkg, nil
+	}
+	conf.Pkgs[xpath] = nil
+	filenames, err := conf.XTestPkgFilenames(path)
+	if err != nil || len(filenames) == 0 {
+		return nil, err
+	}
+	astFiles, errors := conf.parseFiles(filenames, parser.Mode(0))
+
+	// ignore to do conf check with possible partial ast files
+	_ = errors
+
+	pkg, err := conf.check(xpath, astFiles)
+	conf.Pkgs[xpath] = pkg
+	return pkg, err
+}
+
 func (conf *Config) PkgFilenames(path string) ([]string, error) {
 	dir, _, names, err := PkgFilenames(path, true)
 	if err != nil {
 		// don't handle the error, the files might be in "dir extra files" (ex: provided src)
 		//return nil, err
 	}
+	return conf.pkgFilenames2(dir, names, false), nil
+}
 
+// External test package filenames (package name with the "_test" suffix).
+func (conf *Config) XTestPkgFilenames(path string) ([]string, error) {
+	dir, names, err := XTestPkgFilenames(path)
+	if err != nil {
+		// don't handle the error, the files might be in "dir extra files" (ex: provided src)
+		//return nil, err
+	}
+	return conf.pkgFilenames2(dir, names, true), nil
+}
+
+func (conf *Config) pkgFilenames2(dir string, names []string, xtest bool) []string {
 	// add dir extra files
 	for dir2, m := range conf.dirExtraFiles {
 		if dir2 == dir {
@@ -191,9 +227,9 @@ func (conf *Config) PkgFilenames(path string) ([]string, error) {
 					seen[name] = true
 				}
 			}
-			// add extra names if not added yet
+			// add extra names if not added yet (and of the same package)
 			for name := range m {
-				if !seen[name] {
+				if !seen[name] && conf.isXTestFilename(filepath.Join(dir, name)) == xtest {
 					names = append(names, name)
 				}
 			}
@@ -205,7 +241,15 @@ func (conf *Config) PkgFilenames(path string) ([]string, error) {
 	for _, n := range names {
 		u = append(u, filepath.Join(dir, n))
 	}
-	return u, nil
+	return u
+}
+
+// Parsed file that belongs to the external test package.
+func (conf *Config) isXTestFilename(filename string) bool {
+	conf.astFilesMu.RLock()
+	astFile, ok := conf.astFiles[filename]
+	conf.astFilesMu.RUnlock()
+	return ok && isXTestFile(astFile)
 }
 
 func (conf *Config) ReImportImportables() []error {
@@ -223,6 +267,12 @@ func (conf *Config) ReImportImportables() []error {
 			errors = append(errors, err)
 		}
 	}
+	for p := range conf.xtestImportable {
+		_, err := conf.importXTestPath(p)
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
 	return errors
 }
 
@@ -231,11 +281,19 @@ func (conf *Config) MakeFilePkgImportable(filename string) {
 	_, pkgFilename := ExtractSrcDir(fullFilename)
 	pkgPath := filepath.Dir(pkgFilename)
 	conf.MakeImportable(pkgPath)
+	if conf.isXTestFilename(fullFilename) {
+		conf.MakeXTestImportable(pkgPath)
+	}
 }
 func (conf *Config) MakeImportable(path string) {
 	conf.importable[path] = struct{}{}
 }
 
+// The external test package is type checked by ReImportImportables.
+func (conf *Config) MakeXTestImportable(path string) {
+	conf.xtestImportable[path] = struct{}{}
+}
+
 func (conf *Config) IsImportable(path string) bool {
 	_, ok := conf.importable[path]
 	return ok
@@ -323,6 +381,9 @@ func (conf *Config) PosPkg(pos token.Pos) (*types.Package, error) {
 	if err != nil {
 		return nil, err
 	}
+	if astFile, err := conf.PosAstFile(pos); err == nil && isXTestFile(astFile) {
+		dir += "_test"
+	}
 	if pkg, ok := conf.Pkgs[dir]; ok {
 		return pkg, nil
 	}
@@ -0,0 +1,170 @@
+package gosource
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// Type signature and doc comment of the object of an identifier.
+type DocResult struct {
+	StartIndex, EndIndex int // identifier
+	Obj                  types.Object
+	Str                  string
+	Doc                  string
+}
+
+func Doc(filename string, src interface{}, index int) (*DocResult, error) {
+	cc := &CC2{}
+	ipos, _, err := cc.setup(filename, src, index)
+	if err != nil {
+		return nil, err
+	}
+
+	path, _ := astutil.PathEnclosingInterval(cc.astFile, ipos, ipos)
+	if len(path) == 0 {
+		return nil, fmt.Errorf("index has no node")
+	}
+	id, ok := path[0].(*ast.Ident)
+	if !ok {
+		return nil, fmt.Errorf("node is not an ident")
+	}
+	obj := cc.conf.Info.ObjectOf(id)
+	if obj == nil {
+		// help the checker (ex: make imports importable) and try again
+		_, _ = cc.res.ResolveDecl(id)
+		obj = cc.conf.Info.ObjectOf(id)
+	}
+	if obj == nil {
+		return nil, fmt.Errorf("object not found: %v", id.Name)
+	}
+
+	res := &DocResult{Obj: obj}
+	res.StartIndex = cc.conf.FSet.Position(id.Pos()).Offset
+	res.EndIndex = res.StartIndex + len(id.Name)
+	res.Str = types.ObjectString(obj, cc.qualifier())
+	res.Doc, _ = cc.objComment(obj)
+	return res, nil
+}
+
+//----------
+
+// Parameters of a call with the current argument.
+type SignatureResult struct {
+	StartIndex int    // call
+	Str        string // ex: "f1(a int, b ...string) error"
+	Segment    [2]int // current argument in Str, empty if none
+	Obj        types.Object
+	Doc        string
+}
+
+func SignatureHelp(filename string, src interface{}, index int) (*SignatureResult, error) {
+	cc := &CC2{}
+	ipos, src2, err := cc.setup(filename, src, index)
+	if err != nil {
+		return nil, err
+	}
+
+	// innermost call with the index inside the parenthesis
+	path, _ := astutil.PathEnclosingInterval(cc.astFile, ipos, ipos)
+	var call *ast.CallExpr
+loop:
+	for _, n := range path {
+		switch t := n.(type) {
+		case *ast.CallExpr:
+			if ipos > t.Lparen && ipos <= t.Rparen {
+				call = t
+				break loop
+			}
+		case *ast.FuncLit, *ast.BlockStmt:
+			break loop
+		}
+	}
+	if call == nil {
+		return nil, fmt.Errorf("not inside a call")
+	}
+
+	// argument index: commas before the index
+	offset := func(n ast.Node) int {
+		return cc.conf.FSet.Position(n.End()).Offset
+	}
+	argi := 0
+	for i, a := range call.Args {
+		if a.End() <= ipos && strings.Contains(src2[offset(a):index], ",") {
+			argi = i + 1
+		}
+	}
+
+	if err := cc.candidatesInArg(call.Fun, argi); err != nil {
+		return nil, err
+	}
+
+	res := &SignatureResult{}
+	res.StartIndex = cc.conf.FSet.Position(call.Pos()).Offset
+	name := types.ExprString(call.Fun)
+	if len(cc.result.Objs) > 0 {
+		res.Obj = cc.result.Objs[0]
+		name = res.Obj.Name()
+		res.Doc, _ = cc.objComment(res.Obj)
+	}
+	res.Str, res.Segment = formatSignature(name, cc.arg.sig, cc.arg.index, cc.qualifier())
+	return res, nil
+}
+
+// Returns the signature string, and the segment of the parameter at index (variadic parameter includes the following indexes).
+func formatSignature(name string, sig *types.Signature, index int, q types.Qualifier) (string, [2]int) {
+	var seg [2]int
+	params := sig.Params()
+	n := params.Len()
+	if sig.Variadic() && index >= n {
+		index = n - 1
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString(name + "(")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		p := params.At(i)
+		start := sb.Len()
+		if p.Name() != "" {
+			sb.WriteString(p.Name() + " ")
+		}
+		t := p.Type()
+		if sig.Variadic() && i == n-1 {
+			if s, ok := t.(*types.Slice); ok {
+				sb.WriteString("...")
+				t = s.Elem()
+			}
+		}
+		sb.WriteString(types.TypeString(t, q))
+		if i == index {
+			seg = [2]int{start, sb.Len()}
+		}
+	}
+	sb.WriteString(")")
+
+	results := sig.Results()
+	switch {
+	case results.Len() == 1 && results.At(0).Name() == "":
+		sb.WriteString(" " + types.TypeString(results.At(0).Type(), q))
+	case results.Len() > 0:
+		sb.WriteString(" " + types.TypeString(results, q))
+	}
+	return sb.String(), seg
+}
+
+//----------
+
+// Names of the file package objects are not qualified.
+func (cc *CC2) qualifier() types.Qualifier {
+	pkg, err := cc.conf.PosPkg(cc.astFile.Package)
+	if err != nil {
+		return nil
+	}
+	return types.RelativeTo(pkg)
+}
@@ -0,0 +1,73 @@
+package gosource
+
+import (
+	"testing"
+)
+
+func TestDoc1(t *testing.T) {
+	src := `
+		package pack1
+		// Sums the values.
+		func f1(a, b int) int { return a + b }
+		func f2() int { return ●f1(1, 2) }
+	`
+	src2, index, err := SourceCursor("●", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := Doc("t000/src.go", src2, index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Str != "func f1(a int, b int) int" {
+		t.Fatalf("%q", res.Str)
+	}
+	if res.Doc != "Sums the values." {
+		t.Fatalf("%q", res.Doc)
+	}
+}
+
+func TestSignatureHelp1(t *testing.T) {
+	src := `
+		package pack1
+		func f1(a int, b ...string) error { return nil }
+		func f2() { f1(1, "a", ●"b") }
+	`
+	src2, index, err := SourceCursor("●", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := SignatureHelp("t000/src.go", src2, index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Str != "f1(a int, b ...string) error" {
+		t.Fatalf("%q", res.Str)
+	}
+	if s := res.Str[res.Segment[0]:res.Segment[1]]; s != "b ...string" {
+		t.Fatalf("%q", s)
+	}
+}
+
+func TestSignatureHelp2(t *testing.T) {
+	src := `
+		package pack1
+		type T1 struct{}
+		func (t *T1) m1(a, b int) (int, error) { return 0, nil }
+		func f2(t *T1) { t.m1(●) }
+	`
+	src2, index, err := SourceCursor("●", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := SignatureHelp("t000/src.go", src2, index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Str != "m1(a int, b int) (int, error)" {
+		t.Fatalf("%q", res.Str)
+	}
+	if s := res.Str[res.Segment[0]:res.Segment[1]]; s != "a int" {
+		t.Fatalf("%q", s)
+	}
+}
@@ -0,0 +1,203 @@
+package gosource
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jmigpin/editor/util/osutil"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// Positions of the declaration and uses of the object of the identifier at index. Exported objects are also searched in the packages that import the declaring package, found under the project root directory of the file (version control or go.mod directory). Overlay has the content of unsaved files.
+func References(ctx context.Context, filename string, src interface{}, index int, overlay map[string][]byte) ([]token.Position, error) {
+	conf, _, obj, err := referencesObject(ctx, filename, src, index, overlay)
+	if err != nil {
+		return nil, err
+	}
+	return identsPositions(conf, objIdents(conf, obj)), nil
+}
+
+// Type checks the file package, and the importers if the object of the identifier at index is exported.
+func referencesObject(ctx context.Context, filename string, src interface{}, index int, overlay map[string][]byte) (*Config, *ast.Ident, types.Object, error) {
+	conf := NewConfig()
+	id, obj, err := identObjectAtIndex(conf, filename, src, index, overlay)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// search importers
+	if obj.Exported() && !isLocalObj(obj) {
+		declPath := obj.Pkg().Path()
+		root := projectRoot(filepath.Dir(FullFilename(filename)))
+		paths, err := importerPaths(ctx, root, declPath)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		// external test packages are checked separately
+		conf.MakeImportable(declPath)
+		conf.MakeXTestImportable(declPath)
+		for _, p := range paths {
+			conf.MakeImportable(p)
+			conf.MakeXTestImportable(p)
+		}
+		_ = conf.ReImportImportables()
+
+		// objects were recreated
+		obj = conf.Info.ObjectOf(id)
+		if obj == nil {
+			return nil, nil, nil, fmt.Errorf("object not found after importing importers: %v", id.Name)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, nil, err
+	}
+	return conf, id, obj, nil
+}
+
+//----------
+
+// Parses and type checks the file package (with the overlay files), and returns the object of the identifier at index.
+func identObjectAtIndex(conf *Config, filename string, src interface{}, index int, overlay map[string][]byte) (*ast.Ident, types.Object, error) {
+	// unsaved files are used instead of the files on disk
+	for fn, b := range overlay {
+		_, _, _ = conf.ParseFile(fn, b, 0)
+	}
+
+	astFile, err, ok := conf.ParseFile(filename, src, 0)
+	if !ok {
+		return nil, nil, err
+	}
+
+	// make package path importable and re-import (type check added astfile)
+	conf.MakeFilePkgImportable(filename)
+	_ = conf.ReImportImportables()
+
+	tf, err := conf.PosTokenFile(astFile.Package)
+	if err != nil {
+		return nil, nil, err
+	}
+	if index > tf.Size() {
+		return nil, nil, fmt.Errorf("index bigger than filesize")
+	}
+	pos := token.Pos(tf.Base() + index)
+	path, _ := astutil.PathEnclosingInterval(astFile, pos, pos)
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("index has no node")
+	}
+	id, ok := path[0].(*ast.Ident)
+	if !ok {
+		return nil, nil, fmt.Errorf("node is not an ident")
+	}
+	obj := conf.Info.ObjectOf(id)
+	if obj == nil {
+		// help the checker (ex: make imports importable) and try again
+		res := NewDeclResolver(conf)
+		_, _ = res.ResolveDecl(id)
+		obj = conf.Info.ObjectOf(id)
+	}
+	if obj == nil {
+		return nil, nil, fmt.Errorf("object not found: %v", id.Name)
+	}
+	if obj.Pkg() == nil {
+		return nil, nil, fmt.Errorf("builtin object: %v", id.Name)
+	}
+	return id, obj, nil
+}
+
+// Declaration and uses identifiers, sorted by position.
+func objIdents(conf *Config, obj types.Object) []*ast.Ident {
+	seen := map[*ast.Ident]bool{}
+	w := []*ast.Ident{}
+	add := func(id *ast.Ident, o types.Object) {
+		if o != obj || seen[id] {
+			return
+		}
+		seen[id] = true
+		w = append(w, id)
+	}
+	for id, o := range conf.Info.Defs {
+		add(id, o)
+	}
+	for id, o := range conf.Info.Uses {
+		add(id, o)
+	}
+	sort.Slice(w, func(a, b int) bool {
+		return w[a].Pos() < w[b].Pos()
+	})
+	return w
+}
+
+// Sorted by filename and offset.
+func identsPositions(conf *Config, ids []*ast.Ident) []token.Position {
+	w := []token.Position{}
+	for _, id := range ids {
+		w = append(w, conf.FSet.Position(id.Pos()))
+	}
+	sort.Slice(w, func(a, b int) bool {
+		pa, pb := w[a], w[b]
+		if pa.Filename == pb.Filename {
+			return pa.Offset < pb.Offset
+		}
+		return pa.Filename < pb.Filename
+	})
+	return w
+}
+
+// Declared inside a function, or in the file scope (ex: import name).
+func isLocalObj(obj types.Object) bool {
+	p := obj.Parent()
+	return p != nil && p != obj.Pkg().Scope()
+}
+
+//----------
+
+// Paths of the packages under root that import path (including test files and external test packages).
+func importerPaths(ctx context.Context, root, path string) ([]string, error) {
+	w := []string{}
+	err := filepath.Walk(root, func(p string, fi os.FileInfo, err error) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err != nil || !fi.IsDir() {
+			return nil // continue
+		}
+		name := fi.Name()
+		if p != root && (strings.HasPrefix(name, ".") ||
+			strings.HasPrefix(name, "_") ||
+			name == "testdata" ||
+			name == "vendor") {
+			return filepath.SkipDir
+		}
+		bpkg, err := build.ImportDir(p, 0)
+		if err != nil {
+			return nil // no go files
+		}
+		imports := append(bpkg.Imports, bpkg.TestImports...)
+		imports = append(imports, bpkg.XTestImports...)
+		for _, imp := range imports {
+			if imp == path {
+				_, pkgPath := ExtractSrcDir(p)
+				w = append(w, pkgPath)
+				break
+			}
+		}
+		return nil
+	})
+	return w, err
+}
+
+// Project root directory, not going above the GOPATH src directory.
+func projectRoot(dir string) string {
+	srcDir, _ := ExtractSrcDir(dir)
+	srcDir = strings.TrimSuffix(srcDir, "/")
+	return osutil.ProjectRoot(dir, srcDir)
+}
@@ -0,0 +1,200 @@
+package gosource
+
+import (
+	"context"
+	"fmt"
+	"go/build"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func testReferencesSrc(t *testing.T, src string, n int, expOffsets []int) {
+	t.Helper()
+	src2, index, err := SourceCursor("●", src, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := "t000/src.go"
+	poss, err := References(context.Background(), filename, src2, index, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(poss) != len(expOffsets) {
+		t.Fatalf("expecting %v positions, got %v", len(expOffsets), poss)
+	}
+	for i, pos := range poss {
+		if pos.Offset != expOffsets[i] {
+			t.Fatalf("position %v: expecting offset %v, got %v", i, expOffsets[i], pos.Offset)
+		}
+	}
+}
+
+func TestReferences1(t *testing.T) {
+	src := `
+		package pack1
+		func f1() int {
+			a := 1
+			b := a + 2
+			return ●a + b
+		}
+	`
+	testReferencesSrc(t, src, 0, []int{38, 53, 69})
+}
+
+func TestReferences2(t *testing.T) {
+	src := `
+		package pack1
+		func f1() int { return 1 }
+		func f2() int { return f1() + ●f1() }
+	`
+	testReferencesSrc(t, src, 0, []int{24, 71, 78})
+}
+
+//----------
+
+func TestImporterPaths1(t *testing.T) {
+	dir := tmpGopath(t, map[string]string{
+		"p1/.git/HEAD":       "",
+		"p1/a/a.go":          "package a\nfunc F() {}\n",
+		"p1/b/b.go":          "package b\nimport \"p1/a\"\nvar _ = a.F\n",
+		"p1/c/c_test.go":     "package c\nimport \"p1/a\"\nvar _ = a.F\n",
+		"p1/d/d.go":          "package d\n",
+		"p1/testdata/e/e.go": "package e\nimport \"p1/a\"\nvar _ = a.F\n",
+		"p1/vendor/f/f.go":   "package f\nimport \"p1/a\"\nvar _ = a.F\n",
+		"p1/.hidden/g/g.go":  "package g\nimport \"p1/a\"\nvar _ = a.F\n",
+		"p1/b/h/h.go":        "package h\nimport \"p1/a\"\nvar _ = a.F\n",
+		"p2/i/i.go":          "package i\nimport \"p1/a\"\nvar _ = a.F\n",
+	})
+	root := projectRoot(filepath.Join(dir, "src/p1/a"))
+	if root != filepath.Join(dir, "src/p1") {
+		t.Fatalf("root: %v", root)
+	}
+	paths, err := importerPaths(context.Background(), root, "p1/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(paths)
+	r := strings.Join(paths, " ")
+	if r != "p1/b p1/b/h p1/c" {
+		t.Fatalf("paths: %v", r)
+	}
+}
+
+func TestImporterPaths2(t *testing.T) {
+	dir := tmpGopath(t, map[string]string{
+		"p1/a/a.go": "package a\n",
+	})
+	// no version control or go.mod file, not going above the src dir
+	d := filepath.Join(dir, "src/p1/a")
+	if root := projectRoot(d); root != d {
+		t.Fatalf("root: %v", root)
+	}
+}
+
+func TestReferencesImporters1(t *testing.T) {
+	dir := tmpGopath(t, map[string]string{
+		"p1/go.mod": "module p1\n",
+		"p1/a/a.go": "package a\nfunc F() {}\n",
+		"p1/b/b.go": "package b\nimport \"p1/a\"\nfunc f() { a.F() }\n",
+	})
+	filename := filepath.Join(dir, "src/p1/a/a.go")
+	poss, err := References(context.Background(), filename, nil, 15, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := positionsStr(dir, poss)
+	if r != "src/p1/a/a.go:15 src/p1/b/b.go:37" {
+		t.Fatalf("positions: %v", r)
+	}
+}
+
+func TestReferencesOverlay1(t *testing.T) {
+	dir := tmpGopath(t, map[string]string{
+		"p1/go.mod": "module p1\n",
+		"p1/a/a.go": "package a\nfunc F() {}\n",
+		"p1/b/b.go": "package b\nimport \"p1/a\"\nfunc f() { a.F() }\n",
+	})
+	// unsaved content of both files: the declaration moved, and a new use
+	filenameA := filepath.Join(dir, "src/p1/a/a.go")
+	filenameB := filepath.Join(dir, "src/p1/b/b.go")
+	srcA := "package a\n\nfunc F() {}\n"
+	overlay := map[string][]byte{
+		filenameA: []byte(srcA),
+		filenameB: []byte("package b\nimport \"p1/a\"\nfunc f() { a.F(); a.F() }\n"),
+	}
+	poss, err := References(context.Background(), filenameA, srcA, 16, overlay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := positionsStr(dir, poss)
+	if r != "src/p1/a/a.go:16 src/p1/b/b.go:37 src/p1/b/b.go:44" {
+		t.Fatalf("positions: %v", r)
+	}
+}
+
+//----------
+
+// Temporary GOPATH with the files (relative to the src dir). Sets the build context (not in module mode) for the duration of the test.
+func tmpGopath(t *testing.T, files map[string]string) string {
+	t.Helper()
+	// paths are compared without symlinks
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, s := range files {
+		fn := filepath.Join(dir, "src", name)
+		if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fn, []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GO111MODULE", "off")
+	gopath := build.Default.GOPATH
+	build.Default.GOPATH = dir
+	t.Cleanup(func() { build.Default.GOPATH = gopath })
+	return dir
+}
+
+func positionsStr(dir string, poss []token.Position) string {
+	u := []string{}
+	for _, p := range poss {
+		fn := strings.TrimPrefix(p.Filename, dir+"/")
+		u = append(u, fmt.Sprintf("%v:%v", fn, p.Offset))
+	}
+	return strings.Join(u, " ")
+}
+
+func TestReferencesXTest1(t *testing.T) {
+	dir := tmpGopath(t, map[string]string{
+		"p1/go.mod":        "module p1\n",
+		"p1/a/a.go":        "package a\nfunc F() {}\n",
+		"p1/a/a_test.go":   "package a_test\nimport \"p1/a\"\nfunc f() { a.F() }\n",
+		"p1/b/b.go":        "package b\n",
+		"p1/b/b_test.go":   "package b_test\nimport \"p1/a\"\nfunc f() { a.F() }\n",
+		"p1/c/c.go":        "package c\n",
+		"p1/c/c_x_test.go": "package c\nimport \"p1/a\"\nfunc f() { a.F() }\n",
+	})
+	filename := filepath.Join(dir, "src/p1/a/a.go")
+	poss, err := References(context.Background(), filename, nil, 15, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := positionsStr(dir, poss)
+	if r != "src/p1/a/a.go:15 src/p1/a/a_test.go:42 src/p1/b/b_test.go:42 src/p1/c/c_x_test.go:37" {
+		t.Fatalf("positions: %v", r)
+	}
+
+	// uses in the external test packages are in other packages
+	_, _, err = Rename(context.Background(), filename, nil, 15, "f2", nil)
+	if err == nil || !strings.Contains(err.Error(), "a_test.go") || !strings.Contains(err.Error(), "b_test.go") {
+		t.Fatalf("expecting conflict: %v", err)
+	}
+}
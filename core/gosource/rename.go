@@ -0,0 +1,396 @@
+package gosource
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Positions of the identifiers to rename (declaration and uses, sorted by filename and offset) and the old name. Fails with the list of conflicts if the new name would change the meaning of the program. Overlay has the content of unsaved files.
+func Rename(ctx context.Context, filename string, src interface{}, index int, newName string, overlay map[string][]byte) ([]token.Position, string, error) {
+	if !isIdentifier(newName) || newName == "_" {
+		return nil, "", fmt.Errorf("invalid identifier: %q", newName)
+	}
+	conf, _, obj, err := referencesObject(ctx, filename, src, index, overlay)
+	if err != nil {
+		return nil, "", err
+	}
+	if obj.Name() == newName {
+		return nil, "", fmt.Errorf("same name: %v", newName)
+	}
+	ids := objIdents(conf, obj)
+	rc := &renameChecker{conf: conf, obj: obj, ids: ids, newName: newName}
+	if err := rc.check(); err != nil {
+		return nil, "", err
+	}
+	return identsPositions(conf, ids), obj.Name(), nil
+}
+
+func isIdentifier(s string) bool {
+	if s == "" || token.Lookup(s).IsKeyword() {
+		return false
+	}
+	for i, ru := range s {
+		if !(ru == '_' || unicode.IsLetter(ru) || (i > 0 && unicode.IsDigit(ru))) {
+			return false
+		}
+	}
+	return true
+}
+
+//----------
+
+type renameChecker struct {
+	conf      *Config
+	obj       types.Object
+	ids       []*ast.Ident
+	newName   string
+	conflicts []string
+}
+
+func (rc *renameChecker) check() error {
+	rc.checkDecl()
+	rc.checkExported()
+	if rc.obj.Parent() != nil {
+		rc.checkScopes()
+	} else {
+		rc.checkFieldsAndMethods()
+	}
+	if len(rc.conflicts) > 0 {
+		return fmt.Errorf("rename conflicts:\n%v", strings.Join(rc.conflicts, "\n"))
+	}
+	return nil
+}
+
+func (rc *renameChecker) conflict(pos token.Pos, f string, args ...interface{}) {
+	s := fmt.Sprintf(f, args...)
+	if pos.IsValid() {
+		s = fmt.Sprintf("%v: %v", rc.conf.FSet.Position(pos), s)
+	}
+	rc.conflicts = append(rc.conflicts, s)
+}
+
+//----------
+
+func (rc *renameChecker) checkDecl() {
+	var decl *ast.Ident
+	for _, id := range rc.ids {
+		if rc.conf.Info.Defs[id] == rc.obj {
+			decl = id
+			break
+		}
+	}
+	if decl == nil {
+		if _, ok := rc.obj.(*types.PkgName); ok {
+			rc.conflict(rc.obj.Pos(), "implicit package name, add an import name first")
+			return
+		}
+		rc.conflict(rc.obj.Pos(), "declaration not found")
+		return
+	}
+
+	// standard library
+	goroot := filepath.Join(build.Default.GOROOT, "src") + string(filepath.Separator)
+	if fn := rc.conf.FSet.Position(decl.Pos()).Filename; strings.HasPrefix(fn, goroot) {
+		rc.conflict(decl.Pos(), "declared in goroot")
+	}
+
+	// the field uses would need to be renamed with the type
+	for _, id := range rc.ids {
+		if v, ok := rc.conf.Info.Defs[id].(*types.Var); ok && v.Embedded() {
+			rc.conflict(id.Pos(), "embedded field")
+		}
+	}
+}
+
+// Uses from other packages need the name to stay exported.
+func (rc *renameChecker) checkExported() {
+	if !rc.obj.Exported() || ast.IsExported(rc.newName) {
+		return
+	}
+	for _, id := range rc.ids {
+		if !rc.samePkg(id.Pos()) {
+			rc.conflict(id.Pos(), "use in other package, %v would not be exported", rc.newName)
+		}
+	}
+}
+
+//----------
+
+func (rc *renameChecker) checkScopes() {
+	scope := rc.obj.Parent()
+
+	// declared in the same scope
+	if o2 := scope.Lookup(rc.newName); o2 != nil {
+		rc.conflict(o2.Pos(), "%v already declared", rc.newName)
+		return
+	}
+
+	// uses of the object would refer to an inner declaration with the new name
+	for _, id := range rc.ids {
+		if !rc.samePkg(id.Pos()) {
+			continue // qualified use
+		}
+		s, err := rc.conf.PosInnermostScope(id.Pos())
+		if err != nil {
+			continue
+		}
+		if s2, o2 := s.LookupParent(rc.newName, id.Pos()); o2 != nil && scopeDepth(s2, scope) > 0 {
+			rc.conflict(id.Pos(), "would refer to %v declared at %v", o2.Name(), rc.conf.FSet.Position(o2.Pos()))
+		}
+	}
+
+	// uses of the new name would refer to the object
+	for id, o2 := range rc.conf.Info.Uses {
+		if id.Name != rc.newName || o2 == rc.obj || o2.Parent() == nil {
+			continue
+		}
+		s, err := rc.conf.PosInnermostScope(id.Pos())
+		if err != nil {
+			continue
+		}
+		d1 := scopeDepth(s, scope)
+		d2 := scopeDepth(s, o2.Parent())
+		if d1 < 0 || d2 < 0 || d1 >= d2 {
+			continue
+		}
+		// local objects are only visible after the declaration
+		if isLocalObj(rc.obj) && id.Pos() < rc.obj.Pos() {
+			continue
+		}
+		rc.conflict(id.Pos(), "%v would refer to the renamed object", id.Name)
+	}
+}
+
+// Number of parents from s to the target scope, or -1 if not found.
+func scopeDepth(s, target *types.Scope) int {
+	for d := 0; s != nil; d++ {
+		if s == target {
+			return d
+		}
+		s = s.Parent()
+	}
+	return -1
+}
+
+//----------
+
+func (rc *renameChecker) checkFieldsAndMethods() {
+	pkg := rc.obj.Pkg()
+	lookup := func(t types.Type) {
+		o2, _, _ := types.LookupFieldOrMethod(t, true, pkg, rc.newName)
+		if o2 != nil {
+			rc.conflict(o2.Pos(), "%v already declared in %v", rc.newName, t)
+		}
+	}
+
+	// method receiver type
+	if sig, ok := rc.obj.Type().(*types.Signature); ok && sig.Recv() != nil {
+		lookup(sig.Recv().Type())
+		rc.checkSatisfy()
+		return
+	}
+
+	// named struct types that declare the field
+	for _, o := range rc.conf.Info.Defs {
+		tn, ok := o.(*types.TypeName)
+		if !ok || tn.Pkg() != pkg {
+			continue
+		}
+		st, ok := tn.Type().Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+		for i := 0; i < st.NumFields(); i++ {
+			if st.Field(i) == rc.obj {
+				lookup(tn.Type())
+			}
+		}
+	}
+
+	// selections of the field (ex: anonymous structs, embedding types)
+	seen := map[string]bool{}
+	for _, sel := range rc.conf.Info.Selections {
+		if sel.Obj() != rc.obj {
+			continue
+		}
+		t := sel.Recv()
+		if s := t.String(); !seen[s] {
+			seen[s] = true
+			lookup(t)
+		}
+	}
+}
+
+// Types assigned to interfaces (ex: "var _ I = T{}") that have the method would no longer implement the interface. Only the method of one side would be renamed (concrete or interface method).
+func (rc *renameChecker) checkSatisfy() {
+	pkg := rc.obj.Pkg()
+	qual := types.RelativeTo(pkg)
+	seen := map[string]bool{}
+	for _, sa := range satisfyAssigns(rc.conf) {
+		m1, _, _ := types.LookupFieldOrMethod(sa.iface, false, pkg, rc.obj.Name())
+		m2, _, _ := types.LookupFieldOrMethod(sa.typ, false, pkg, rc.obj.Name())
+		if m1 == nil || m2 == nil || m1 == m2 || (m1 != rc.obj && m2 != rc.obj) {
+			continue
+		}
+		t1 := types.TypeString(sa.iface, qual)
+		t2 := types.TypeString(sa.typ, qual)
+		if k := t1 + " " + t2; !seen[k] {
+			seen[k] = true
+			rc.conflict(sa.pos, "%v would no longer implement %v (method of only one of them would be renamed)", t2, t1)
+		}
+	}
+}
+
+//----------
+
+type satisfyAssign struct {
+	iface, typ types.Type
+	pos        token.Pos
+}
+
+// Assignments of types to interface types in the type checked files (declarations, assignments, call arguments, returns, composite literals, sends, conversions and type assertions). Sorted by position.
+func satisfyAssigns(conf *Config) []*satisfyAssign {
+	info := &conf.Info
+	w := []*satisfyAssign{}
+	add := func(iface types.Type, e ast.Expr) {
+		t := info.TypeOf(e)
+		if iface == nil || t == nil || !types.IsInterface(iface) {
+			return
+		}
+		if _, ok := t.(*types.Basic); ok {
+			return // untyped nil, invalid
+		}
+		w = append(w, &satisfyAssign{iface, t, e.Pos()})
+	}
+	call := func(ce *ast.CallExpr) {
+		if tv, ok := info.Types[ce.Fun]; ok && tv.IsType() {
+			if len(ce.Args) == 1 {
+				add(tv.Type, ce.Args[0]) // conversion
+			}
+			return
+		}
+		sig, ok := info.TypeOf(ce.Fun).(*types.Signature)
+		if !ok {
+			return
+		}
+		params := sig.Params()
+		for i, a := range ce.Args {
+			switch {
+			case sig.Variadic() && i >= params.Len()-1:
+				if st, ok := params.At(params.Len() - 1).Type().(*types.Slice); ok && !ce.Ellipsis.IsValid() {
+					add(st.Elem(), a)
+				}
+			case i < params.Len():
+				add(params.At(i).Type(), a)
+			}
+		}
+	}
+	compositeLit := func(cl *ast.CompositeLit) {
+		t := info.TypeOf(cl)
+		if t == nil {
+			return
+		}
+		for i, e := range cl.Elts {
+			kv, _ := e.(*ast.KeyValueExpr)
+			value := e
+			if kv != nil {
+				value = kv.Value
+			}
+			switch u := t.Underlying().(type) {
+			case *types.Struct:
+				if kv != nil {
+					if id, ok := kv.Key.(*ast.Ident); ok {
+						if o := info.Uses[id]; o != nil {
+							add(o.Type(), value)
+						}
+					}
+				} else if i < u.NumFields() {
+					add(u.Field(i).Type(), value)
+				}
+			case *types.Slice:
+				add(u.Elem(), value)
+			case *types.Array:
+				add(u.Elem(), value)
+			case *types.Map:
+				if kv != nil {
+					add(u.Key(), kv.Key)
+				}
+				add(u.Elem(), value)
+			}
+		}
+	}
+
+	var visit func(node ast.Node, sig *types.Signature)
+	visit = func(node ast.Node, sig *types.Signature) {
+		ast.Inspect(node, func(n ast.Node) bool {
+			switch t := n.(type) {
+			case *ast.FuncDecl:
+				if o := info.Defs[t.Name]; o != nil && t.Body != nil {
+					sig2, _ := o.Type().(*types.Signature)
+					visit(t.Body, sig2)
+				}
+				return false
+			case *ast.FuncLit:
+				sig2, _ := info.TypeOf(t).(*types.Signature)
+				visit(t.Body, sig2)
+				return false
+			case *ast.ReturnStmt:
+				if sig != nil && sig.Results().Len() == len(t.Results) {
+					for i, r := range t.Results {
+						add(sig.Results().At(i).Type(), r)
+					}
+				}
+			case *ast.ValueSpec:
+				if t.Type != nil {
+					for _, v := range t.Values {
+						add(info.TypeOf(t.Type), v)
+					}
+				}
+			case *ast.AssignStmt:
+				if t.Tok == token.ASSIGN && len(t.Lhs) == len(t.Rhs) {
+					for i, r := range t.Rhs {
+						add(info.TypeOf(t.Lhs[i]), r)
+					}
+				}
+			case *ast.SendStmt:
+				if ct, ok := info.TypeOf(t.Chan).(*types.Chan); ok {
+					add(ct.Elem(), t.Value)
+				}
+			case *ast.TypeAssertExpr:
+				if t.Type != nil {
+					add(info.TypeOf(t.X), t.Type)
+				}
+			case *ast.CallExpr:
+				call(t)
+			case *ast.CompositeLit:
+				compositeLit(t)
+			}
+			return true
+		})
+	}
+	for node := range info.Scopes {
+		if f, ok := node.(*ast.File); ok {
+			visit(f, nil)
+		}
+	}
+
+	sort.Slice(w, func(a, b int) bool {
+		return w[a].pos < w[b].pos
+	})
+	return w
+}
+
+//----------
+
+func (rc *renameChecker) samePkg(pos token.Pos) bool {
+	pkg, err := rc.conf.PosPkg(pos)
+	return err == nil && pkg != nil && pkg.Path() == rc.obj.Pkg().Path()
+}
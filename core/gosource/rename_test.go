@@ -0,0 +1,178 @@
+package gosource
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func testRenameSrc(t *testing.T, src string, newName string) (string, error) {
+	t.Helper()
+	src2, index, err := SourceCursor("●", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := "t000/src.go"
+	poss, oldName, err := Rename(context.Background(), filename, src2, index, newName, nil)
+	if err != nil {
+		return "", err
+	}
+	// apply from the end to keep offsets valid
+	s := src2
+	for i := len(poss) - 1; i >= 0; i-- {
+		o := poss[i].Offset
+		s = s[:o] + newName + s[o+len(oldName):]
+	}
+	return s, nil
+}
+
+func TestRename1(t *testing.T) {
+	src := `
+		package pack1
+		func f1() int {
+			a := 1
+			b := ●a + 2
+			return a + b
+		}
+	`
+	s, err := testRenameSrc(t, src, "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(s, "c := 1") || !strings.Contains(s, "b := c + 2") || !strings.Contains(s, "return c + b") {
+		t.Fatal(s)
+	}
+}
+
+func TestRename2(t *testing.T) {
+	src := `
+		package pack1
+		type T1 struct{ a, b int }
+		func f1(t T1) int { return t.●a }
+	`
+	s, err := testRenameSrc(t, src, "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(s, "struct{ c, b int }") || !strings.Contains(s, "return t.c") {
+		t.Fatal(s)
+	}
+}
+
+func TestRenameConflict1(t *testing.T) {
+	// same scope
+	src := `
+		package pack1
+		func f1() int {
+			●a := 1
+			b := 2
+			return a + b
+		}
+	`
+	if _, err := testRenameSrc(t, src, "b"); err == nil {
+		t.Fatal("expecting conflict")
+	}
+}
+
+func TestRenameConflict2(t *testing.T) {
+	// use would refer to the inner declaration
+	src := `
+		package pack1
+		var ●v1 = 1
+		func f1() int {
+			v2 := 2
+			return v1 + v2
+		}
+	`
+	if _, err := testRenameSrc(t, src, "v2"); err == nil {
+		t.Fatal("expecting conflict")
+	}
+}
+
+func TestRenameConflict3(t *testing.T) {
+	// use of the new name would refer to the renamed object
+	src := `
+		package pack1
+		var v2 = 1
+		func f1() int {
+			●v1 := 2
+			return v1 + v2
+		}
+	`
+	if _, err := testRenameSrc(t, src, "v2"); err == nil {
+		t.Fatal("expecting conflict")
+	}
+}
+
+func TestRenameConflict4(t *testing.T) {
+	// field
+	src := `
+		package pack1
+		type T1 struct{ a, b int }
+		func f1(t T1) int { return t.●a }
+	`
+	if _, err := testRenameSrc(t, src, "b"); err == nil {
+		t.Fatal("expecting conflict")
+	}
+}
+
+func TestRenameConflict5(t *testing.T) {
+	// method
+	src := `
+		package pack1
+		type T1 int
+		func (t T1) ●m1() {}
+		func (t T1) m2() {}
+	`
+	if _, err := testRenameSrc(t, src, "m2"); err == nil {
+		t.Fatal("expecting conflict")
+	}
+}
+
+func TestRenameConflict6(t *testing.T) {
+	// concrete method, type assigned to an interface
+	src := `
+		package pack1
+		type I1 interface{ m1() }
+		type T1 int
+		func (t T1) ●m1() {}
+		var _ I1 = T1(0)
+	`
+	_, err := testRenameSrc(t, src, "m2")
+	if err == nil || !strings.Contains(err.Error(), "would no longer implement") {
+		t.Fatalf("expecting conflict: %v", err)
+	}
+}
+
+func TestRenameConflict7(t *testing.T) {
+	// interface method, type assigned to the interface in a return
+	src := `
+		package pack1
+		type I1 interface{ ●m1() }
+		type T1 struct{}
+		func (t *T1) m1() {}
+		func f1() I1 { return &T1{} }
+	`
+	_, err := testRenameSrc(t, src, "m2")
+	if err == nil || !strings.Contains(err.Error(), "would no longer implement") {
+		t.Fatalf("expecting conflict: %v", err)
+	}
+}
+
+func TestRename3(t *testing.T) {
+	// interface method, type not assigned to the interface
+	src := `
+		package pack1
+		type I1 interface{ ●m1() }
+		type T1 struct{}
+		func (t *T1) m1() {}
+		func f1(i I1) { i.m1() }
+	`
+	s, err := testRenameSrc(t, src, "m2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(s, "interface{ m2() }") || !strings.Contains(s, "i.m2()") || !strings.Contains(s, "(t *T1) m1()") {
+		t.Fatal(s)
+	}
+}
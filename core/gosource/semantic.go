@@ -0,0 +1,173 @@
+package gosource
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jmigpin/editor/util/syntaxutil"
+)
+
+type SemanticIdent struct {
+	Offset, End int
+	Class       syntaxutil.Class
+}
+
+// Classifies the file identifiers using type information (locals, params, funcs, types, ...). Identifiers reported as undefined by the type checker get the unresolved class. The file package and its direct imports are type checked.
+func SemanticIdents(filename string, src interface{}) ([]*SemanticIdent, error) {
+	conf := NewConfig()
+	var errs []types.Error
+	conf.Conf.Error = func(err error) {
+		if te, ok := err.(types.Error); ok {
+			errs = append(errs, te)
+		}
+	}
+
+	astFile, err, ok := conf.ParseFile(filename, src, 0)
+	if !ok {
+		return nil, err
+	}
+
+	// make package path and imports importable and re-import (type check added astfile)
+	conf.MakeFilePkgImportable(filename)
+	for _, imp := range astFile.Imports {
+		if path, err := strconv.Unquote(imp.Path.Value); err == nil {
+			conf.MakeImportable(path)
+		}
+	}
+	_ = conf.ReImportImportables()
+
+	tf, err := conf.PosTokenFile(astFile.Package)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := &semClassifier{conf: conf, params: map[types.Object]bool{}}
+	idents := map[token.Pos]*ast.Ident{}
+	sels := map[*ast.Ident]*ast.SelectorExpr{}
+	ast.Inspect(astFile, func(node ast.Node) bool {
+		switch t := node.(type) {
+		case *ast.FuncDecl:
+			sc.addParams(t.Recv)
+		case *ast.FuncType:
+			sc.addParams(t.Params)
+			sc.addParams(t.Results)
+		case *ast.SelectorExpr:
+			sels[t.Sel] = t
+		case *ast.Ident:
+			idents[t.Pos()] = t
+		}
+		return true
+	})
+
+	w := []*SemanticIdent{}
+	add := func(id *ast.Ident, c syntaxutil.Class) {
+		offset := tf.Offset(id.Pos())
+		w = append(w, &SemanticIdent{offset, offset + len(id.Name), c})
+	}
+
+	unresolved := map[*ast.Ident]bool{}
+	for _, e := range errs {
+		if !(strings.Contains(e.Msg, "undefined") || strings.Contains(e.Msg, "undeclared")) {
+			continue
+		}
+		id, ok := idents[e.Pos]
+		if !ok || sc.unloadedPkgSelector(sels[id]) {
+			continue
+		}
+		unresolved[id] = true
+		add(id, syntaxutil.Unresolved)
+	}
+
+	for _, id := range idents {
+		if unresolved[id] {
+			continue
+		}
+		if c := sc.class(id); c != syntaxutil.None {
+			add(id, c)
+		}
+	}
+
+	sort.Slice(w, func(a, b int) bool {
+		return w[a].Offset < w[b].Offset
+	})
+	return w, nil
+}
+
+//----------
+
+type semClassifier struct {
+	conf   *Config
+	params map[types.Object]bool
+}
+
+func (sc *semClassifier) addParams(fl *ast.FieldList) {
+	if fl == nil {
+		return
+	}
+	for _, f := range fl.List {
+		for _, id := range f.Names {
+			if obj := sc.conf.Info.Defs[id]; obj != nil {
+				sc.params[obj] = true
+			}
+		}
+	}
+}
+
+func (sc *semClassifier) object(id *ast.Ident) types.Object {
+	if obj := sc.conf.Info.Defs[id]; obj != nil {
+		return obj
+	}
+	return sc.conf.Info.Uses[id]
+}
+
+func (sc *semClassifier) class(id *ast.Ident) syntaxutil.Class {
+	obj := sc.object(id)
+	if obj == nil {
+		return syntaxutil.None
+	}
+	switch t := obj.(type) {
+	case *types.Var:
+		switch {
+		case t.IsField():
+			return syntaxutil.Field
+		case sc.params[obj]:
+			return syntaxutil.Param
+		case t.Pkg() != nil && t.Parent() == t.Pkg().Scope():
+			return syntaxutil.Var
+		default:
+			return syntaxutil.Local
+		}
+	case *types.Func:
+		return syntaxutil.Func
+	case *types.TypeName:
+		// universe types are colored by the tokenizer
+		if t.Pkg() != nil {
+			return syntaxutil.Type
+		}
+	case *types.Const:
+		if t.Pkg() != nil {
+			return syntaxutil.Const
+		}
+	}
+	return syntaxutil.None
+}
+
+// Selector of a package that failed to load (ex: not found). All names would be undefined.
+func (sc *semClassifier) unloadedPkgSelector(sel *ast.SelectorExpr) bool {
+	if sel == nil {
+		return false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	pn, ok := sc.object(id).(*types.PkgName)
+	if !ok {
+		return false
+	}
+	return pn.Imported().Scope().Len() == 0
+}
@@ -0,0 +1,67 @@
+package gosource
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jmigpin/editor/util/syntaxutil"
+)
+
+func testSemanticSrc(t *testing.T, src string, exp string) {
+	t.Helper()
+	filename := "t000/src.go"
+	idents, err := SemanticIdents(filename, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[syntaxutil.Class]string{
+		syntaxutil.Type:       "type",
+		syntaxutil.Local:      "local",
+		syntaxutil.Param:      "param",
+		syntaxutil.Var:        "var",
+		syntaxutil.Func:       "func",
+		syntaxutil.Const:      "const",
+		syntaxutil.Field:      "field",
+		syntaxutil.Unresolved: "unresolved",
+	}
+	u := []string{}
+	for _, id := range idents {
+		u = append(u, fmt.Sprintf("%v:%v", src[id.Offset:id.End], names[id.Class]))
+	}
+	res := strings.Join(u, " ")
+	if res != exp {
+		t.Fatalf("\n%v\n---\n%v", res, exp)
+	}
+}
+
+//------------
+
+func TestSemantic1(t *testing.T) {
+	src := `package pack1
+type T1 struct{ f1 int }
+const c1 = 1
+var v1 T1
+func (t *T1) m1(a int) (b int) {
+	v1 := a + c1 + t.f1
+	return v1 + b + v2
+}`
+	exp := "T1:type f1:field c1:const v1:var T1:type " +
+		"t:param T1:type m1:func a:param b:param " +
+		"v1:local a:param c1:const t:param f1:field " +
+		"v1:local b:param v2:unresolved"
+	testSemanticSrc(t, src, exp)
+}
+
+func TestSemantic2(t *testing.T) {
+	src := `package pack1
+func f1() {
+	x := T1{f2: 1}
+	f1()
+	_ = x.f3
+}
+type T1 struct{ f2 int }`
+	exp := "f1:func x:local T1:type f2:field f1:func " +
+		"x:local f3:unresolved T1:type f2:field"
+	testSemanticSrc(t, src, exp)
+}
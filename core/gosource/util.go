@@ -59,6 +59,26 @@ func PkgFilenames(dir string, testFiles bool) (string, string, []string, error)
 	return bpkg.Dir, pkgDir, a, nil
 }
 
+// External test files (package name with the "_test" suffix) of the package.
+func XTestPkgFilenames(dir string) (string, []string, error) {
+	// transform into pkg dir
+	pkgDir := dir
+	srcDir := "."
+	if filepath.IsAbs(dir) {
+		srcDir, pkgDir = ExtractSrcDir(dir)
+	}
+	// pkg dir
+	bpkg, err := build.Import(pkgDir, srcDir, 0)
+	if err != nil {
+		return dir, nil, err
+	}
+	return bpkg.Dir, bpkg.XTestGoFiles, nil
+}
+
+func isXTestFile(astFile *ast.File) bool {
+	return strings.HasSuffix(astFile.Name.Name, "_test")
+}
+
 func PkgName(path string) (string, error) {
 	// transform into pkg dir
 	pkgDir := path
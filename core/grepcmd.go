@@ -0,0 +1,217 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/jmigpin/editor/core/parseutil"
+	"github.com/jmigpin/editor/core/toolbarparser"
+)
+
+// Directories names not visited by the grep cmd.
+var GrepSkipDirs = map[string]bool{
+	".git":   true,
+	".hg":    true,
+	".svn":   true,
+	"vendor": true,
+}
+
+//----------
+
+// Usage: Grep [-re] [-i] <pattern> [dirs...]
+func GrepCmd(erow *ERow, part *toolbarparser.Part) error {
+	args := part.Args[1:]
+
+	reOpt, icaseOpt := false, false
+	for ; len(args) > 0; args = args[1:] {
+		s := args[0].Str()
+		if s == "-re" {
+			reOpt = true
+		} else if s == "-i" {
+			icaseOpt = true
+		} else {
+			break
+		}
+	}
+
+	if len(args) < 1 {
+		return fmt.Errorf("expecting pattern argument")
+	}
+	pattern := args[0].UnquotedStr()
+	if pattern == "" {
+		return fmt.Errorf("empty pattern")
+	}
+	dirs := []string{}
+	for _, a := range args[1:] {
+		dirs = append(dirs, a.UnquotedStr())
+	}
+	if len(dirs) == 0 {
+		dirs = append(dirs, ".")
+	}
+
+	match, err := grepMatcher(pattern, reOpt, icaseOpt)
+	if err != nil {
+		return err
+	}
+
+	// output to a directory row
+	erow2 := erow
+	if !erow.Info.IsDir() {
+		if erow.Info.IsSpecial() {
+			return fmt.Errorf("can't run on special row")
+		}
+		info := erow.Ed.ReadERowInfo(erow.Info.Dir())
+		erow2 = NewERow(erow.Ed, info, erow.Row.PosBelow())
+	}
+
+	// cleanup row content
+	erow2.Row.TextArea.SetStrClearHistory("")
+	erow2.Row.TextArea.ClearPos()
+
+	dir := erow2.Info.Name()
+	erow2.Exec.Run(func(ctx context.Context, w io.Writer) error {
+		return GrepContext(ctx, w, dir, dirs, match)
+	})
+
+	return nil
+}
+
+//----------
+
+// Returns the index of the match in the line, or -1 if not found.
+type GrepMatchFn func(line []byte) int
+
+func grepMatcher(pattern string, reOpt, icaseOpt bool) (GrepMatchFn, error) {
+	// simple case
+	if !reOpt && !icaseOpt {
+		p := []byte(pattern)
+		fn := func(line []byte) int {
+			return bytes.Index(line, p)
+		}
+		return fn, nil
+	}
+
+	if !reOpt {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if icaseOpt {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	fn := func(line []byte) int {
+		m := re.FindIndex(line)
+		if m == nil || m[0] == m[1] {
+			return -1
+		}
+		return m[0]
+	}
+	return fn, nil
+}
+
+//----------
+
+// Walks the dirs (relative to dir if not absolute) and outputs "filename:line:col: text" for every line matched.
+func GrepContext(ctx context.Context, w io.Writer, dir string, dirs []string, match GrepMatchFn) error {
+	for _, d := range dirs {
+		d2 := d
+		if !filepath.IsAbs(d2) {
+			d2 = filepath.Join(dir, d2)
+		}
+		err := filepath.Walk(d2, func(p string, fi os.FileInfo, err error) error {
+			// stop on context
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil {
+				// report and continue
+				_, err2 := fmt.Fprintf(w, "# error: %v\n", err)
+				return err2
+			}
+			if fi.IsDir() {
+				if p != d2 && GrepSkipDirs[fi.Name()] {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !fi.Mode().IsRegular() {
+				return nil
+			}
+
+			// output name relative to the row dir if possible
+			name := p
+			if !filepath.IsAbs(d) {
+				if u, err := filepath.Rel(dir, p); err == nil {
+					name = u
+				}
+			}
+			name = parseutil.EscapeFilename(name)
+
+			return grepFile(ctx, w, p, name, match)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func grepFile(ctx context.Context, w io.Writer, filename, name string, match GrepMatchFn) error {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		_, err2 := fmt.Fprintf(w, "# error: %v\n", err)
+		return err2
+	}
+
+	if isBinary(b) {
+		return nil
+	}
+
+	for line := 1; len(b) > 0; line++ {
+		// stop on context (big files)
+		if line%1024 == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// line content
+		k := bytes.IndexByte(b, '\n')
+		l := b
+		if k >= 0 {
+			l = b[:k]
+			b = b[k+1:]
+		} else {
+			b = nil
+		}
+
+		i := match(l)
+		if i < 0 {
+			continue
+		}
+		col := utf8.RuneCount(l[:i]) + 1
+		_, err := fmt.Fprintf(w, "%v:%v:%v: %s\n", name, line, col, l)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//----------
+
+// Content is considered binary if it has a zero byte at the beginning of the data (same heuristic as git/grep).
+func isBinary(b []byte) bool {
+	max := 8000
+	if len(b) > max {
+		b = b[:max]
+	}
+	return bytes.IndexByte(b, 0) >= 0
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func grepTestTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.txt":        "abc\nzzz Abc\n",
+		"sub/b.txt":    "xabc\n",
+		"sub/c.bin":    "abc\x00",
+		".git/d.txt":   "abc\n",
+		"vendor/e.txt": "abc\n",
+	}
+	for name, s := range files {
+		fn := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fn, []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func testGrep(t *testing.T, dir, pattern string, reOpt, icaseOpt bool) []string {
+	t.Helper()
+	match, err := grepMatcher(pattern, reOpt, icaseOpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := GrepContext(context.Background(), buf, dir, []string{"."}, match); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	sort.Strings(lines)
+	return lines
+}
+
+func TestGrep1(t *testing.T) {
+	dir := grepTestTree(t)
+	lines := testGrep(t, dir, "abc", false, false)
+	exp := []string{"a.txt:1:1: abc", "sub/b.txt:1:2: xabc"}
+	if strings.Join(lines, "|") != strings.Join(exp, "|") {
+		t.Fatal(lines)
+	}
+}
+
+func TestGrep2(t *testing.T) {
+	dir := grepTestTree(t)
+	lines := testGrep(t, dir, "^abc", true, true)
+	exp := []string{"a.txt:1:1: abc"}
+	if strings.Join(lines, "|") != strings.Join(exp, "|") {
+		t.Fatal(lines)
+	}
+	lines = testGrep(t, dir, "a.c", true, true)
+	exp = []string{"a.txt:1:1: abc", "a.txt:2:5: zzz Abc", "sub/b.txt:1:2: xabc"}
+	if strings.Join(lines, "|") != strings.Join(exp, "|") {
+		t.Fatal(lines)
+	}
+}
+
+func TestGrepCancel(t *testing.T) {
+	dir := t.TempDir()
+	b := bytes.Repeat([]byte("abc\n"), 10000)
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.txt"), b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	match, _ := grepMatcher("abc", false, false)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	buf := &bytes.Buffer{}
+	err := grepFile(ctx, buf, filepath.Join(dir, "a.txt"), "a.txt", match)
+	if err != context.Canceled {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(buf.Bytes(), []byte("\n")); n >= 10000 {
+		t.Fatalf("not cancelled: %v lines", n)
+	}
+}
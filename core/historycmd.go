@@ -0,0 +1,104 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmigpin/editor/core/toolbarparser"
+	"github.com/jmigpin/editor/util/uiutil/widget/history"
+)
+
+const historyTimeFormat = "2006-01-02 15:04:05"
+
+// Lists the row undo tree states in the "+History" row. Branches are indented, the current state is marked with "*".
+func HistoryCmd(erow *ERow) error {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "# %v: use \"HistoryGoto <id|time>\" in the row toolbar\n", erow.Info.Name())
+	for _, s := range erow.Row.TextArea.TextHistory.States() {
+		mark := " "
+		if s.Current {
+			mark = "*"
+		}
+		indent := strings.Repeat("  ", s.Depth)
+		if s.Edit == nil {
+			fmt.Fprintf(buf, "%s%s%d: initial state\n", indent, mark, s.Id)
+			continue
+		}
+		t := s.Edit.Time.Format(historyTimeFormat)
+		fmt.Fprintf(buf, "%s%s%d: %v: %v\n", indent, mark, s.Id, t, editSummary(s.Edit))
+	}
+
+	erow2, _ := erow.Ed.ExistingOrNewERow("+History")
+	erow2.Row.TextArea.SetBytesClearPos(buf.Bytes())
+	erow2.Flash()
+	return nil
+}
+
+func editSummary(edit *history.Edit) string {
+	max := 3
+	u := []string{}
+	entries := edit.Entries()
+	for i, ur := range entries {
+		if i == max {
+			u = append(u, fmt.Sprintf("(+%d)", len(entries)-max))
+			break
+		}
+		// the entry is the undo: an insert undo is a delete
+		op := "+"
+		if ur.Insert {
+			op = "-"
+		}
+		s := ur.S
+		if len(s) > 20 {
+			s = append(s[:20:20], "..."...)
+		}
+		u = append(u, fmt.Sprintf("%s%q", op, s))
+	}
+	return strings.Join(u, " ")
+}
+
+//----------
+
+// Usage: HistoryGoto <id|[date] time>
+func HistoryGotoCmd(erow *ERow, part *toolbarparser.Part) error {
+	args := part.Args[1:]
+	if len(args) < 1 {
+		return fmt.Errorf("expecting state id or time")
+	}
+	th := erow.Row.TextArea.TextHistory
+
+	// state id
+	if len(args) == 1 {
+		if id, err := strconv.Atoi(args[0].UnquotedStr()); err == nil {
+			return th.Goto(id)
+		}
+	}
+
+	// time
+	strs := []string{}
+	for _, a := range args {
+		strs = append(strs, a.UnquotedStr())
+	}
+	t, err := parseHistoryTime(strings.Join(strs, " "), time.Now())
+	if err != nil {
+		return err
+	}
+	return th.GotoTime(t)
+}
+
+// Time only (ex: "15:04:05") is relative to today.
+func parseHistoryTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation(historyTimeFormat, s, now.Location()); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("15:04:05", s, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("bad time format: %q", s)
+	}
+	y, m, d := now.Date()
+	t2 := time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, now.Location())
+	return t2, nil
+}
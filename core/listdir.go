@@ -6,11 +6,13 @@ import (
 	"io"
 	"os"
 	"path"
+	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/jmigpin/editor/core/parseutil"
 	"github.com/jmigpin/editor/core/toolbarparser"
+	"github.com/jmigpin/editor/util/gitutil"
 )
 
 func ListDirCmd(erow *ERow, part *toolbarparser.Part) error {
@@ -56,10 +58,12 @@ func ListDirContext(ctx context.Context, w io.Writer, filepath string, tree, hid
 		return err
 	}
 
-	return listDirContext(ctx, w, filepath, "", tree, hidden)
+	marks := gitStatusMarks(ctx, filepath)
+
+	return listDirContext(ctx, w, filepath, "", tree, hidden, marks)
 }
 
-func listDirContext(ctx context.Context, w io.Writer, filepath, addedFilepath string, tree, hidden bool) error {
+func listDirContext(ctx context.Context, w io.Writer, filepath, addedFilepath string, tree, hidden bool, marks map[string]string) error {
 	fp2 := path.Join(filepath, addedFilepath)
 
 	out := func(s string) bool {
@@ -103,14 +107,18 @@ func listDirContext(ctx context.Context, w io.Writer, filepath, addedFilepath st
 		if fi.IsDir() {
 			name2 += "/"
 		}
-		s := parseutil.EscapeFilename(name2) + "\n"
+		s := parseutil.EscapeFilename(name2)
+		if m, ok := marks[name2]; ok {
+			s += " " + m
+		}
+		s += "\n"
 		if !out(s) {
 			return nil
 		}
 
 		if fi.IsDir() && tree {
 			afp := path.Join(addedFilepath, name)
-			err := listDirContext(ctx, w, filepath, afp, tree, hidden)
+			err := listDirContext(ctx, w, filepath, afp, tree, hidden, marks)
 			if err != nil {
 				return err
 			}
@@ -121,6 +129,47 @@ func listDirContext(ctx context.Context, w io.Writer, filepath, addedFilepath st
 
 //----------
 
+// Git status codes of the entries inside dir, keyed by the relative path (directories end with "/"). Directories that contain changes (not only ignored entries) are marked with "*". Nil if not inside a git working tree.
+func gitStatusMarks(ctx context.Context, dir string) map[string]string {
+	// only the entries inside dir (ignored entries of the whole tree can be many)
+	entries, err := gitutil.Status(ctx, dir, true, ".")
+	if err != nil {
+		return nil
+	}
+	// git reports paths without symlinks
+	dir2, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil
+	}
+	m := map[string]string{}
+	for _, e := range entries {
+		rel, err := filepath.Rel(dir2, e.Path)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if e.Dir {
+			m[rel+"/"] = e.Code()
+		} else {
+			m[rel] = e.Code()
+		}
+
+		// parent directories
+		if e.Code() == "!" {
+			continue
+		}
+		for d := path.Dir(rel); d != "."; d = path.Dir(d) {
+			if _, ok := m[d+"/"]; ok {
+				break // already marked (with its parents)
+			}
+			m[d+"/"] = "*"
+		}
+	}
+	return m
+}
+
+//----------
+
 type ByListOrder []os.FileInfo
 
 func (a ByListOrder) Len() int {
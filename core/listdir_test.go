@@ -0,0 +1,63 @@
+package core
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestGitStatusMarks(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	write := func(name, s string) {
+		t.Helper()
+		fn := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fn, []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git := func(args ...string) {
+		t.Helper()
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@test"}, args...)
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if b, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v: %s", err, b)
+		}
+	}
+
+	git("init", "-q")
+	write("a.txt", "a")
+	write("d/e/b.txt", "b")
+	write("d/c.txt", "c")
+	write(".gitignore", "*.log\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "first")
+
+	write("a.txt", "a2")     // outside the listed dir
+	write("d/e/b.txt", "b2") // modified, marks the parent dir
+	write("d/f/g.txt", "g")  // untracked dir
+	write("d/x.log", "x")    // ignored
+
+	marks := gitStatusMarks(context.Background(), filepath.Join(dir, "d"))
+	u := []string{}
+	for k, v := range marks {
+		u = append(u, k+" "+v)
+	}
+	sort.Strings(u)
+	r := strings.Join(u, "\n")
+	e := "e/ *\ne/b.txt M\nf/ ?\nx.log !"
+	if r != e {
+		t.Fatalf("\n%v", r)
+	}
+}
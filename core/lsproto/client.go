@@ -0,0 +1,355 @@
+package lsproto
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Language server instance (process) communicating over stdio. Requests and notifications are sent in order from a queue, so the callers never block on the server while sending.
+type Client struct {
+	reg     *Registration
+	rootDir string
+	man     *Manager
+
+	q    fnQueue
+	cmd  *exec.Cmd
+	conn *jsonConn
+
+	mu       sync.Mutex
+	startErr error
+	closing  bool
+	docs     map[string]*clientDoc // open documents by filename
+}
+
+type clientDoc struct {
+	version int
+	hash    [sha1.Size]byte
+}
+
+func newClient(man *Manager, reg *Registration, rootDir string) *Client {
+	c := &Client{man: man, reg: reg, rootDir: rootDir, docs: map[string]*clientDoc{}}
+	c.q.add(c.start)
+	return c
+}
+
+//----------
+
+func (c *Client) start() {
+	err := c.start2()
+	c.mu.Lock()
+	c.startErr = err
+	c.mu.Unlock()
+	if err != nil {
+		c.man.error(fmt.Errorf("lsproto: %v: %v", c.reg.Language, err))
+	}
+}
+
+func (c *Client) start2() error {
+	cmd := exec.Command(c.reg.Cmd[0], c.reg.Cmd[1:]...)
+	cmd.Dir = c.rootDir
+	cmd.Stderr = os.Stderr
+	w, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	r, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	c.cmd = cmd
+
+	c.conn = newJSONConn(r, w)
+	c.conn.onNotification = c.onNotification
+	c.conn.onRequest = c.onRequest
+	go func() {
+		err := c.conn.readLoop()
+		c.ended(err)
+	}()
+
+	// initialize
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	params := map[string]interface{}{
+		"processId": os.Getpid(),
+		"rootUri":   FilenameToURI(c.rootDir),
+		"workspaceFolders": []map[string]string{
+			{"uri": FilenameToURI(c.rootDir), "name": c.rootDir},
+		},
+		"capabilities": map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"synchronization":    map[string]interface{}{"didSave": true},
+				"hover":              map[string]interface{}{"contentFormat": []string{"plaintext"}},
+				"completion":         map[string]interface{}{"completionItem": map[string]interface{}{"documentationFormat": []string{"plaintext"}}},
+				"publishDiagnostics": map[string]interface{}{},
+			},
+			"workspace": map[string]interface{}{
+				"workspaceFolders": true,
+				"workspaceEdit":    map[string]interface{}{"documentChanges": true},
+			},
+		},
+	}
+	if err := c.conn.call(ctx, "initialize", params, nil); err != nil {
+		_ = cmd.Process.Kill()
+		return fmt.Errorf("initialize: %v", err)
+	}
+	return c.conn.notify("initialized", struct{}{})
+}
+
+// The server process ended (or the connection failed). The client is removed from the manager so that the next use starts a new server. Reported once, unless closing.
+func (c *Client) ended(err error) {
+	c.mu.Lock()
+	closing := c.closing
+	c.mu.Unlock()
+	if closing {
+		return
+	}
+	c.man.removeClient(c)
+	_ = c.cmd.Wait()
+	c.man.error(fmt.Errorf("lsproto: %v: server ended: %v", c.reg.Language, err))
+}
+
+// Sends shutdown/exit, and waits for the process to end (killed after the timeout).
+func (c *Client) close(timeout time.Duration) error {
+	c.mu.Lock()
+	c.closing = true
+	c.mu.Unlock()
+
+	done := make(chan error, 1)
+	c.q.add(func() {
+		if c.startErr != nil {
+			done <- nil
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		_ = c.conn.call(ctx, "shutdown", nil, nil)
+		_ = c.conn.notify("exit", nil)
+		if wc, ok := c.conn.w.(io.Closer); ok {
+			_ = wc.Close()
+		}
+
+		wait := make(chan error, 1)
+		go func() { wait <- c.cmd.Wait() }()
+		select {
+		case err := <-wait:
+			done <- err
+		case <-time.After(timeout):
+			_ = c.cmd.Process.Kill()
+			done <- fmt.Errorf("lsproto: %v: killed after timeout", c.reg.Language)
+		}
+	})
+	return <-done
+}
+
+//----------
+
+func (c *Client) onNotification(method string, params json.RawMessage) {
+	switch method {
+	case "textDocument/publishDiagnostics":
+		p := &PublishDiagnosticsParams{}
+		if err := json.Unmarshal(params, p); err != nil {
+			return
+		}
+		filename, err := URIToFilename(p.URI)
+		if err != nil {
+			return
+		}
+		c.man.setDiagnostics(filename, p.Diagnostics)
+	case "window/showMessage":
+		p := struct {
+			Type    int    `json:"type"`
+			Message string `json:"message"`
+		}{}
+		if err := json.Unmarshal(params, &p); err == nil && p.Type == 1 {
+			c.man.error(fmt.Errorf("lsproto: %v: %v", c.reg.Language, p.Message))
+		}
+	}
+}
+
+func (c *Client) onRequest(method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case "workspace/configuration":
+		// no configuration: one null per item
+		p := struct {
+			Items []json.RawMessage `json:"items"`
+		}{}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		return make([]interface{}, len(p.Items)), nil
+	case "window/workDoneProgress/create",
+		"client/registerCapability",
+		"client/unregisterCapability":
+		return nil, nil
+	}
+	return nil, &ResponseError{Code: -32601, Message: "method not found: " + method}
+}
+
+//----------
+
+// Sends didOpen, or didChange (full content) if the content changed since the last sync.
+func (c *Client) syncDoc(filename string, b []byte) {
+	hash := sha1.Sum(b)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	doc, ok := c.docs[filename]
+	if ok && doc.hash == hash {
+		return
+	}
+	uri := FilenameToURI(filename)
+	text := string(b)
+	if !ok {
+		doc = &clientDoc{version: 1, hash: hash}
+		c.docs[filename] = doc
+		item := &TextDocumentItem{URI: uri, LanguageID: c.reg.Language, Version: doc.version, Text: text}
+		c.notify("textDocument/didOpen", map[string]interface{}{"textDocument": item})
+		return
+	}
+	doc.version++
+	doc.hash = hash
+	params := map[string]interface{}{
+		"textDocument":   &VersionedTextDocumentIdentifier{URI: uri, Version: doc.version},
+		"contentChanges": []map[string]string{{"text": text}},
+	}
+	c.notify("textDocument/didChange", params)
+}
+
+func (c *Client) didSave(filename string, b []byte) {
+	c.syncDoc(filename, b)
+	params := map[string]interface{}{"textDocument": &TextDocumentIdentifier{FilenameToURI(filename)}}
+	c.notify("textDocument/didSave", params)
+}
+
+func (c *Client) didClose(filename string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.docs[filename]; !ok {
+		return
+	}
+	delete(c.docs, filename)
+	params := map[string]interface{}{"textDocument": &TextDocumentIdentifier{FilenameToURI(filename)}}
+	c.notify("textDocument/didClose", params)
+}
+
+//----------
+
+// Write errors are not reported: the server ended, which is reported once by the read loop.
+func (c *Client) notify(method string, params interface{}) {
+	c.q.add(func() {
+		if c.startErr != nil {
+			return
+		}
+		_ = c.conn.notify(method, params)
+	})
+}
+
+// The request is sent in order with the notifications. Waits for the response.
+func (c *Client) call(ctx context.Context, method string, params, result interface{}) error {
+	type sent struct {
+		id  int
+		ch  chan *jsonMsg
+		err error
+	}
+	sentc := make(chan *sent, 1)
+	c.q.add(func() {
+		if c.startErr != nil {
+			sentc <- &sent{err: c.startErr}
+			return
+		}
+		id, ch, err := c.conn.request(method, params)
+		sentc <- &sent{id, ch, err}
+	})
+	var s *sent
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case s = <-sentc:
+	}
+	if s.err != nil {
+		return s.err
+	}
+	return c.conn.waitResponse(ctx, s.id, s.ch, result)
+}
+
+func (c *Client) callPos(ctx context.Context, method string, filename string, b []byte, offset int, extra map[string]interface{}, result interface{}) error {
+	c.syncDoc(filename, b)
+	params := map[string]interface{}{
+		"textDocument": &TextDocumentIdentifier{FilenameToURI(filename)},
+		"position":     OffsetPosition(b, offset),
+	}
+	for k, v := range extra {
+		params[k] = v
+	}
+	return c.call(ctx, method, params, result)
+}
+
+//----------
+
+// Runs functions in order in one goroutine. Adding never blocks.
+type fnQueue struct {
+	mu      sync.Mutex
+	q       []func()
+	running bool
+}
+
+func (fq *fnQueue) add(fn func()) {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	fq.q = append(fq.q, fn)
+	if !fq.running {
+		fq.running = true
+		go fq.run()
+	}
+}
+
+func (fq *fnQueue) run() {
+	for {
+		fq.mu.Lock()
+		if len(fq.q) == 0 {
+			fq.running = false
+			fq.mu.Unlock()
+			return
+		}
+		fn := fq.q[0]
+		fq.q = fq.q[1:]
+		fq.mu.Unlock()
+		fn()
+	}
+}
+
+//----------
+
+// Hover contents as plain text.
+func hoverString(h *Hover) string {
+	if h == nil || len(h.Contents) == 0 {
+		return ""
+	}
+	mc := &MarkupContent{}
+	if err := json.Unmarshal(h.Contents, mc); err == nil && mc.Kind != "" {
+		return mc.Value
+	}
+	var u []*MarkedString
+	if err := json.Unmarshal(h.Contents, &u); err == nil {
+		w := []string{}
+		for _, ms := range u {
+			w = append(w, ms.Value)
+		}
+		return strings.Join(w, "\n")
+	}
+	ms := &MarkedString{}
+	if err := json.Unmarshal(h.Contents, ms); err == nil {
+		return ms.Value
+	}
+	return ""
+}
@@ -0,0 +1,234 @@
+package lsproto
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+// JSON-RPC 2.0 connection using the language server protocol framing ("Content-Length" headers).
+type jsonConn struct {
+	r *bufio.Reader
+	w io.Writer
+
+	wmu sync.Mutex // writes
+
+	mu      sync.Mutex
+	id      int
+	pending map[int]chan *jsonMsg
+	err     error // read loop ended
+
+	// handlers for messages sent by the server, run in the read loop
+	onNotification func(method string, params json.RawMessage)
+	onRequest      func(method string, params json.RawMessage) (interface{}, error)
+}
+
+func newJSONConn(r io.Reader, w io.Writer) *jsonConn {
+	return &jsonConn{
+		r:       bufio.NewReader(r),
+		w:       w,
+		pending: map[int]chan *jsonMsg{},
+	}
+}
+
+//----------
+
+// Sends a request, and returns a channel to wait for the response with waitResponse.
+func (c *jsonConn) request(method string, params interface{}) (int, chan *jsonMsg, error) {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return 0, nil, c.err
+	}
+	c.id++
+	id := c.id
+	ch := make(chan *jsonMsg, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	msg := &jsonOutMsg{JSONRPC: "2.0", ID: &id, Method: method, Params: params}
+	if err := c.write(msg); err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return 0, nil, err
+	}
+	return id, ch, nil
+}
+
+// Result can be nil to ignore the response result.
+func (c *jsonConn) waitResponse(ctx context.Context, id int, ch chan *jsonMsg, result interface{}) error {
+	select {
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		_ = c.notify("$/cancelRequest", map[string]int{"id": id})
+		return ctx.Err()
+	case msg := <-ch:
+		if msg == nil {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return c.err
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result == nil || len(msg.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(msg.Result, result)
+	}
+}
+
+func (c *jsonConn) call(ctx context.Context, method string, params, result interface{}) error {
+	id, ch, err := c.request(method, params)
+	if err != nil {
+		return err
+	}
+	return c.waitResponse(ctx, id, ch, result)
+}
+
+func (c *jsonConn) notify(method string, params interface{}) error {
+	return c.write(&jsonOutMsg{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+//----------
+
+func (c *jsonConn) write(msg interface{}) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n", len(b)); err != nil {
+		return err
+	}
+	_, err = c.w.Write(b)
+	return err
+}
+
+func (c *jsonConn) read() (*jsonMsg, error) {
+	tp := textproto.NewReader(c.r)
+	h, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(h.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("bad content length: %v", err)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(c.r, b); err != nil {
+		return nil, err
+	}
+	msg := &jsonMsg{}
+	if err := json.Unmarshal(b, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+//----------
+
+// Reads messages until an error occurs (ex: connection closed). Pending requests fail with that error.
+func (c *jsonConn) readLoop() error {
+	var err error
+	for {
+		var msg *jsonMsg
+		msg, err = c.read()
+		if err != nil {
+			break
+		}
+		c.handle(msg)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = fmt.Errorf("connection closed: %v", err)
+	for id, ch := range c.pending {
+		ch <- nil
+		delete(c.pending, id)
+	}
+	return err
+}
+
+func (c *jsonConn) handle(msg *jsonMsg) {
+	switch {
+	case msg.Method == "": // response
+		var id int
+		if msg.ID == nil || json.Unmarshal(*msg.ID, &id) != nil {
+			return
+		}
+		c.mu.Lock()
+		ch, ok := c.pending[id]
+		delete(c.pending, id)
+		c.mu.Unlock()
+		if ok {
+			ch <- msg
+		}
+	case msg.ID == nil: // notification
+		if c.onNotification != nil {
+			c.onNotification(msg.Method, msg.Params)
+		}
+	default: // request from the server
+		var result interface{}
+		err := &ResponseError{Code: -32601, Message: "method not found: " + msg.Method}
+		if c.onRequest != nil {
+			var err2 error
+			result, err2 = c.onRequest(msg.Method, msg.Params)
+			if err2 == nil {
+				err = nil
+			} else if err3, ok := err2.(*ResponseError); ok {
+				err = err3
+			}
+		}
+		res := &jsonOutResponse{JSONRPC: "2.0", ID: msg.ID, Result: result}
+		if err != nil {
+			res.Error = err
+		}
+		_ = c.write(res)
+	}
+}
+
+//----------
+
+type jsonMsg struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *ResponseError   `json:"error,omitempty"`
+}
+
+type jsonOutMsg struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      *int        `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type jsonOutResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  interface{}      `json:"result"`
+	Error   *ResponseError   `json:"error,omitempty"`
+}
+
+//----------
+
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("lsp error %v: %v", e.Code, e.Message)
+}
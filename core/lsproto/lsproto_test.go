@@ -0,0 +1,235 @@
+package lsproto
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Returns the binary, and the directory to be removed at the end.
+func buildFakeServer(t *testing.T) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not found")
+	}
+	dir, err := ioutil.TempDir("", "lsproto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := filepath.Join(dir, "fakeserver")
+	cmd := exec.Command("go", "build", "-o", bin, "./testdata/fakeserver")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("%v: %s", err, out)
+	}
+	return bin, dir
+}
+
+func newTestManager(t *testing.T, bin string) *Manager {
+	reg, err := ParseRegistration("fake,.fk," + bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	man := NewManager([]*Registration{reg})
+	man.OnError = func(err error) { t.Error(err) }
+	return man
+}
+
+//----------
+
+func TestManager1(t *testing.T) {
+	bin, dir := buildFakeServer(t)
+	defer os.RemoveAll(dir)
+
+	man := newTestManager(t, bin)
+	diagc := make(chan string, 10)
+	man.OnDiagnostics = func(filename string) { diagc <- filename }
+
+	filename := filepath.Join(dir, "a.fk")
+	src := []byte("alpha beta\nbeta bad alpha")
+	man.DidChange(filename, src)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// definition of the 2nd "alpha"
+	locs, err := man.Definition(ctx, filename, src, 22)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locs) != 1 {
+		t.Fatalf("locs: %v", locs)
+	}
+	a, b := RangeOffsets(src, locs[0].Range)
+	if string(src[a:b]) != "alpha" || a != 0 {
+		t.Fatalf("definition: %v %v", a, b)
+	}
+	fn, err := URIToFilename(locs[0].URI)
+	if err != nil || fn != filename {
+		t.Fatalf("uri: %v %v", fn, err)
+	}
+
+	// references
+	locs, err = man.References(ctx, filename, src, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locs) != 2 {
+		t.Fatalf("references: %v", locs)
+	}
+
+	// hover
+	s, err := man.Hover(ctx, filename, src, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != "word beta" {
+		t.Fatalf("hover: %q", s)
+	}
+
+	// completion
+	items, err := man.Completion(ctx, filename, src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[1].Label != "beta" {
+		t.Fatalf("completion: %v", items)
+	}
+
+	// diagnostics
+	select {
+	case <-diagc:
+	case <-ctx.Done():
+		t.Fatal("no diagnostics")
+	}
+	diags := man.Diagnostics(filename)
+	if len(diags) != 1 || diags[0].Range.Start.Line != 1 || diags[0].Message != "bad word" {
+		t.Fatalf("diagnostics: %v", diags)
+	}
+
+	// changed content is synced before the request
+	src2 := []byte("gamma alpha")
+	edits, err := man.Rename(ctx, filename, src2, 8, "delta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits[filename]) != 1 {
+		t.Fatalf("rename: %v", edits)
+	}
+	a, b = RangeOffsets(src2, edits[filename][0].Range)
+	if a != 6 || b != 11 || edits[filename][0].NewText != "delta" {
+		t.Fatalf("rename: %v %v", a, b)
+	}
+
+	if err := man.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManagerStartErr(t *testing.T) {
+	reg, _ := ParseRegistration("fake,.fk,/nonexistent/server")
+	man := NewManager([]*Registration{reg})
+	errc := make(chan error, 1)
+	man.OnError = func(err error) { errc <- err }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := man.Hover(ctx, "/tmp/a.fk", nil, 0)
+	if err == nil {
+		t.Fatal("expecting error")
+	}
+	<-errc
+	if err := man.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManagerServerEnded(t *testing.T) {
+	bin, dir := buildFakeServer(t)
+	defer os.RemoveAll(dir)
+
+	man := newTestManager(t, bin)
+	errc := make(chan error, 10)
+	man.OnError = func(err error) { errc <- err }
+
+	filename := filepath.Join(dir, "a.fk")
+	src := []byte("alpha beta")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := man.Hover(ctx, filename, src, 7); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := man.client(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.cmd.Process.Kill(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-errc:
+	case <-ctx.Done():
+		t.Fatal("server end not reported")
+	}
+
+	// dropped, the next use starts a new server
+	man.DidChange(filename, []byte("alpha gamma"))
+	if _, err := man.Hover(ctx, filename, src, 7); err != nil {
+		t.Fatal(err)
+	}
+	if c2, _ := man.client(filename); c2 == c {
+		t.Fatal("same client")
+	}
+	select {
+	case err := <-errc:
+		t.Fatalf("reported again: %v", err)
+	default:
+	}
+	if err := man.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//----------
+
+func TestPosition1(t *testing.T) {
+	b := []byte("a\nb€c\n𝄞d")
+	tests := []struct {
+		offset int
+		pos    Position
+	}{
+		{0, Position{0, 0}},
+		{2, Position{1, 0}},
+		{3, Position{1, 1}},
+		{6, Position{1, 2}},
+		{8, Position{2, 0}},
+		{12, Position{2, 2}}, // surrogate pair
+	}
+	for _, u := range tests {
+		p := OffsetPosition(b, u.offset)
+		if p != u.pos {
+			t.Fatalf("offset %v: %v != %v", u.offset, p, u.pos)
+		}
+		if o := PositionOffset(b, p); o != u.offset {
+			t.Fatalf("pos %v: %v != %v", p, o, u.offset)
+		}
+	}
+}
+
+func TestParseRegistration1(t *testing.T) {
+	reg, err := ParseRegistration("c,.c .h,clangd --log=error")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reg.Language != "c" || len(reg.Exts) != 2 || len(reg.Cmd) != 2 {
+		t.Fatalf("%+v", reg)
+	}
+	if _, err := ParseRegistration("c,.c"); err == nil {
+		t.Fatal("expecting error")
+	}
+}
@@ -0,0 +1,337 @@
+package lsproto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmigpin/editor/util/osutil"
+)
+
+// Language servers by file extension. Servers are started on the first use, one instance per language and root directory. Safe to use concurrently.
+type Manager struct {
+	OnError       func(error)
+	OnDiagnostics func(filename string) // diagnostics were updated
+
+	regs []*Registration
+
+	mu      sync.Mutex
+	clients map[string]*Client // key: language+root
+	roots   map[string]string  // root dir by file dir
+	diags   map[string][]*Diagnostic
+}
+
+func NewManager(regs []*Registration) *Manager {
+	return &Manager{
+		regs:    regs,
+		clients: map[string]*Client{},
+		roots:   map[string]string{},
+		diags:   map[string][]*Diagnostic{},
+	}
+}
+
+//----------
+
+func (man *Manager) registration(filename string) (*Registration, bool) {
+	ext := filepath.Ext(filename)
+	for _, reg := range man.regs {
+		for _, e := range reg.Exts {
+			if e == ext {
+				return reg, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Filename has a registered language server.
+func (man *Manager) Has(filename string) bool {
+	_, ok := man.registration(filename)
+	return ok
+}
+
+func (man *Manager) client(filename string) (*Client, error) {
+	return man.client2(filename, true)
+}
+
+func (man *Manager) client2(filename string, create bool) (*Client, error) {
+	reg, ok := man.registration(filename)
+	if !ok {
+		return nil, fmt.Errorf("lsproto: no registration for %q", filepath.Ext(filename))
+	}
+	man.mu.Lock()
+	defer man.mu.Unlock()
+
+	dir := filepath.Dir(filename)
+	root, ok := man.roots[dir]
+	if !ok {
+		root = osutil.ProjectRoot(dir, "")
+		man.roots[dir] = root
+	}
+	key := reg.Language + "\x00" + root
+
+	c, ok := man.clients[key]
+	if !ok {
+		if !create {
+			return nil, fmt.Errorf("lsproto: not running: %v", reg.Language)
+		}
+		c = newClient(man, reg, root)
+		man.clients[key] = c
+	}
+	return c, nil
+}
+
+// Removes the client (the server ended) if it is still the one in use.
+func (man *Manager) removeClient(c *Client) {
+	man.mu.Lock()
+	defer man.mu.Unlock()
+	for k, c2 := range man.clients {
+		if c2 == c {
+			delete(man.clients, k)
+		}
+	}
+}
+
+// Shuts down all servers.
+func (man *Manager) Close() error {
+	man.mu.Lock()
+	clients := man.clients
+	man.clients = map[string]*Client{}
+	man.mu.Unlock()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(clients))
+	for _, c := range clients {
+		wg.Add(1)
+		go func(c *Client) {
+			defer wg.Done()
+			errs <- c.close(3 * time.Second)
+		}(c)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//----------
+
+// Content of the file opened or changed (full content, ignored if unchanged). Files without a registration are ignored.
+func (man *Manager) DidChange(filename string, b []byte) {
+	if !man.Has(filename) {
+		return
+	}
+	if c, err := man.client(filename); err == nil {
+		c.syncDoc(filename, b)
+	}
+}
+
+func (man *Manager) DidSave(filename string, b []byte) {
+	if !man.Has(filename) {
+		return
+	}
+	if c, err := man.client(filename); err == nil {
+		c.didSave(filename, b)
+	}
+}
+
+func (man *Manager) DidClose(filename string) {
+	if !man.Has(filename) {
+		return
+	}
+	if c, err := man.client2(filename, false); err == nil {
+		c.didClose(filename)
+	}
+}
+
+//----------
+
+// Definition locations of the identifier at offset.
+func (man *Manager) Definition(ctx context.Context, filename string, b []byte, offset int) ([]*Location, error) {
+	c, err := man.client(filename)
+	if err != nil {
+		return nil, err
+	}
+	var raw json.RawMessage
+	if err := c.callPos(ctx, "textDocument/definition", filename, b, offset, nil, &raw); err != nil {
+		return nil, err
+	}
+	return parseLocations(raw)
+}
+
+func (man *Manager) References(ctx context.Context, filename string, b []byte, offset int) ([]*Location, error) {
+	c, err := man.client(filename)
+	if err != nil {
+		return nil, err
+	}
+	extra := map[string]interface{}{
+		"context": map[string]bool{"includeDeclaration": true},
+	}
+	var locs []*Location
+	if err := c.callPos(ctx, "textDocument/references", filename, b, offset, extra, &locs); err != nil {
+		return nil, err
+	}
+	return locs, nil
+}
+
+// Hover information as plain text.
+func (man *Manager) Hover(ctx context.Context, filename string, b []byte, offset int) (string, error) {
+	c, err := man.client(filename)
+	if err != nil {
+		return "", err
+	}
+	var h *Hover
+	if err := c.callPos(ctx, "textDocument/hover", filename, b, offset, nil, &h); err != nil {
+		return "", err
+	}
+	return hoverString(h), nil
+}
+
+func (man *Manager) Completion(ctx context.Context, filename string, b []byte, offset int) ([]*CompletionItem, error) {
+	c, err := man.client(filename)
+	if err != nil {
+		return nil, err
+	}
+	var raw json.RawMessage
+	if err := c.callPos(ctx, "textDocument/completion", filename, b, offset, nil, &raw); err != nil {
+		return nil, err
+	}
+	// list or array of items
+	cl := &CompletionList{}
+	if err := json.Unmarshal(raw, cl); err == nil && cl.Items != nil {
+		return cl.Items, nil
+	}
+	var items []*CompletionItem
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, nil // null
+	}
+	return items, nil
+}
+
+// Edits by filename needed to rename the identifier at offset.
+func (man *Manager) Rename(ctx context.Context, filename string, b []byte, offset int, newName string) (map[string][]*TextEdit, error) {
+	c, err := man.client(filename)
+	if err != nil {
+		return nil, err
+	}
+	extra := map[string]interface{}{"newName": newName}
+	we := &WorkspaceEdit{}
+	if err := c.callPos(ctx, "textDocument/rename", filename, b, offset, extra, we); err != nil {
+		return nil, err
+	}
+	m := map[string][]*TextEdit{}
+	add := func(uri string, edits []*TextEdit) error {
+		fn, err := URIToFilename(uri)
+		if err != nil {
+			return err
+		}
+		m[fn] = append(m[fn], edits...)
+		return nil
+	}
+	for uri, edits := range we.Changes {
+		if err := add(uri, edits); err != nil {
+			return nil, err
+		}
+	}
+	for _, tde := range we.DocumentChanges {
+		if err := add(tde.TextDocument.URI, tde.Edits); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+//----------
+
+// Last diagnostics published for the file.
+func (man *Manager) Diagnostics(filename string) []*Diagnostic {
+	man.mu.Lock()
+	defer man.mu.Unlock()
+	return man.diags[filename]
+}
+
+func (man *Manager) setDiagnostics(filename string, diags []*Diagnostic) {
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i].Range.Start, diags[j].Range.Start
+		return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+	})
+	man.mu.Lock()
+	if len(diags) == 0 {
+		delete(man.diags, filename)
+	} else {
+		man.diags[filename] = diags
+	}
+	man.mu.Unlock()
+	if man.OnDiagnostics != nil {
+		man.OnDiagnostics(filename)
+	}
+}
+
+func (man *Manager) error(err error) {
+	if man.OnError != nil {
+		man.OnError(err)
+	}
+}
+
+//----------
+
+// Location, []Location or []LocationLink.
+func parseLocations(raw json.RawMessage) ([]*Location, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	if raw[0] != '[' {
+		loc := &Location{}
+		if err := json.Unmarshal(raw, loc); err != nil {
+			return nil, err
+		}
+		return []*Location{loc}, nil
+	}
+	var links []*LocationLink
+	if err := json.Unmarshal(raw, &links); err == nil && len(links) > 0 && links[0].TargetURI != "" {
+		w := []*Location{}
+		for _, l := range links {
+			w = append(w, &Location{URI: l.TargetURI, Range: l.TargetSelectionRange})
+		}
+		return w, nil
+	}
+	var locs []*Location
+	if err := json.Unmarshal(raw, &locs); err != nil {
+		return nil, err
+	}
+	return locs, nil
+}
+
+//----------
+
+type Registration struct {
+	Language string   // language id (ex: "go")
+	Exts     []string // file extensions (ex: ".go")
+	Cmd      []string // server command and args
+}
+
+// Parses "language,exts,cmd" (ex: "go,.go,gopls" or "c,.c .h,clangd --log=error"). Extensions and command args are separated by spaces.
+func ParseRegistration(s string) (*Registration, error) {
+	u := strings.SplitN(s, ",", 3)
+	if len(u) != 3 {
+		return nil, fmt.Errorf("expecting \"language,exts,cmd\": %q", s)
+	}
+	reg := &Registration{
+		Language: strings.TrimSpace(u[0]),
+		Exts:     strings.Fields(u[1]),
+		Cmd:      strings.Fields(u[2]),
+	}
+	if reg.Language == "" || len(reg.Exts) == 0 || len(reg.Cmd) == 0 {
+		return nil, fmt.Errorf("empty field: %q", s)
+	}
+	return reg, nil
+}
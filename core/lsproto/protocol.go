@@ -0,0 +1,128 @@
+package lsproto
+
+import (
+	"encoding/json"
+)
+
+// Subset of the language server protocol types.
+
+type Position struct {
+	Line      int `json:"line"`      // zero based
+	Character int `json:"character"` // zero based, utf16 units
+}
+
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+type LocationLink struct {
+	TargetURI            string `json:"targetUri"`
+	TargetSelectionRange Range  `json:"targetSelectionRange"`
+}
+
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type TextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+type VersionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+}
+
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+type TextDocumentEdit struct {
+	TextDocument VersionedTextDocumentIdentifier `json:"textDocument"`
+	Edits        []*TextEdit                     `json:"edits"`
+}
+
+type WorkspaceEdit struct {
+	Changes         map[string][]*TextEdit `json:"changes,omitempty"`
+	DocumentChanges []*TextDocumentEdit    `json:"documentChanges,omitempty"`
+}
+
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity,omitempty"` // 1=error, 2=warning, 3=info, 4=hint
+	Source   string `json:"source,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (d *Diagnostic) SeverityStr() string {
+	switch d.Severity {
+	case 1:
+		return "error"
+	case 2:
+		return "warning"
+	case 3:
+		return "info"
+	case 4:
+		return "hint"
+	}
+	return "diagnostic"
+}
+
+type PublishDiagnosticsParams struct {
+	URI         string        `json:"uri"`
+	Diagnostics []*Diagnostic `json:"diagnostics"`
+}
+
+type CompletionItem struct {
+	Label         string          `json:"label"`
+	Kind          int             `json:"kind,omitempty"`
+	Detail        string          `json:"detail,omitempty"`
+	Documentation json.RawMessage `json:"documentation,omitempty"` // string or MarkupContent
+	InsertText    string          `json:"insertText,omitempty"`
+}
+
+type CompletionList struct {
+	IsIncomplete bool              `json:"isIncomplete"`
+	Items        []*CompletionItem `json:"items"`
+}
+
+type Hover struct {
+	Contents json.RawMessage `json:"contents"` // MarkupContent, MarkedString or []MarkedString
+	Range    *Range          `json:"range,omitempty"`
+}
+
+type MarkupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// String or {language, value}.
+type MarkedString struct {
+	Language string `json:"language"`
+	Value    string `json:"value"`
+}
+
+func (ms *MarkedString) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		ms.Value = s
+		return nil
+	}
+	type ms2 MarkedString // avoid recursion
+	return json.Unmarshal(b, (*ms2)(ms))
+}
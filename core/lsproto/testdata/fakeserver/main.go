@@ -0,0 +1,211 @@
+// Fake language server used by the lsproto tests. Identifiers are words of letters. The definition of a word is its first occurrence.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type msg struct {
+	ID     *json.RawMessage `json:"id,omitempty"`
+	Method string           `json:"method"`
+	Params json.RawMessage  `json:"params"`
+}
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type rang struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type posParams struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position position `json:"position"`
+	NewName  string   `json:"newName"`
+}
+
+var docs = map[string]string{}
+
+func main() {
+	r := bufio.NewReader(os.Stdin)
+	for {
+		m, err := read(r)
+		if err != nil {
+			os.Exit(1)
+		}
+		handle(m)
+	}
+}
+
+func handle(m *msg) {
+	switch m.Method {
+	case "initialize":
+		reply(m, map[string]interface{}{"capabilities": map[string]interface{}{}})
+	case "shutdown":
+		reply(m, nil)
+	case "exit":
+		os.Exit(0)
+	case "textDocument/didOpen":
+		p := struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}{}
+		_ = json.Unmarshal(m.Params, &p)
+		docs[p.TextDocument.URI] = p.TextDocument.Text
+		publishDiagnostics(p.TextDocument.URI)
+	case "textDocument/didChange":
+		p := struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}{}
+		_ = json.Unmarshal(m.Params, &p)
+		for _, c := range p.ContentChanges {
+			docs[p.TextDocument.URI] = c.Text
+		}
+		publishDiagnostics(p.TextDocument.URI)
+	case "textDocument/definition":
+		p, word, _ := wordParams(m)
+		occ := occurrences(docs[p.TextDocument.URI], word)
+		if len(occ) == 0 {
+			reply(m, nil)
+			return
+		}
+		reply(m, map[string]interface{}{"uri": p.TextDocument.URI, "range": occ[0]})
+	case "textDocument/references":
+		p, word, _ := wordParams(m)
+		locs := []interface{}{}
+		for _, r := range occurrences(docs[p.TextDocument.URI], word) {
+			locs = append(locs, map[string]interface{}{"uri": p.TextDocument.URI, "range": r})
+		}
+		reply(m, locs)
+	case "textDocument/hover":
+		_, word, _ := wordParams(m)
+		reply(m, map[string]interface{}{
+			"contents": map[string]string{"kind": "plaintext", "value": "word " + word},
+		})
+	case "textDocument/completion":
+		reply(m, map[string]interface{}{
+			"isIncomplete": false,
+			"items":        []map[string]string{{"label": "alpha"}, {"label": "beta"}},
+		})
+	case "textDocument/rename":
+		p, word, _ := wordParams(m)
+		edits := []interface{}{}
+		for _, r := range occurrences(docs[p.TextDocument.URI], word) {
+			edits = append(edits, map[string]interface{}{"range": r, "newText": p.NewName})
+		}
+		reply(m, map[string]interface{}{
+			"changes": map[string]interface{}{p.TextDocument.URI: edits},
+		})
+	default:
+		if m.ID != nil {
+			reply(m, nil)
+		}
+	}
+}
+
+// Diagnostic at each "bad" word.
+func publishDiagnostics(uri string) {
+	diags := []interface{}{}
+	for _, r := range occurrences(docs[uri], "bad") {
+		diags = append(diags, map[string]interface{}{"range": r, "severity": 1, "message": "bad word"})
+	}
+	write(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "textDocument/publishDiagnostics",
+		"params":  map[string]interface{}{"uri": uri, "diagnostics": diags},
+	})
+}
+
+//----------
+
+func wordParams(m *msg) (*posParams, string, bool) {
+	p := &posParams{}
+	_ = json.Unmarshal(m.Params, p)
+	lines := strings.Split(docs[p.TextDocument.URI], "\n")
+	if p.Position.Line >= len(lines) {
+		return p, "", false
+	}
+	l := []rune(lines[p.Position.Line])
+	a, b := p.Position.Character, p.Position.Character
+	for a > 0 && a <= len(l) && unicode.IsLetter(l[a-1]) {
+		a--
+	}
+	for b < len(l) && unicode.IsLetter(l[b]) {
+		b++
+	}
+	return p, string(l[a:b]), a < b
+}
+
+// Ranges of the word (ascii content only).
+func occurrences(text, word string) []rang {
+	w := []rang{}
+	if word == "" {
+		return w
+	}
+	for li, l := range strings.Split(text, "\n") {
+		for i := 0; i+len(word) <= len(l); i++ {
+			if l[i:i+len(word)] != word {
+				continue
+			}
+			if i > 0 && unicode.IsLetter(rune(l[i-1])) {
+				continue
+			}
+			if j := i + len(word); j < len(l) && unicode.IsLetter(rune(l[j])) {
+				continue
+			}
+			w = append(w, rang{position{li, i}, position{li, i + len(word)}})
+		}
+	}
+	return w
+}
+
+//----------
+
+func reply(m *msg, result interface{}) {
+	write(map[string]interface{}{"jsonrpc": "2.0", "id": m.ID, "result": result})
+}
+
+func write(v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("Content-Length: %d\r\n\r\n%s", len(b), b)
+}
+
+func read(r *bufio.Reader) (*msg, error) {
+	h, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(h.Get("Content-Length"))
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	m := &msg{}
+	return m, json.Unmarshal(b, m)
+}
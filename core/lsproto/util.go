@@ -0,0 +1,79 @@
+package lsproto
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+func FilenameToURI(filename string) string {
+	u := &url.URL{Scheme: "file", Path: filepath.ToSlash(filename)}
+	return u.String()
+}
+
+func URIToFilename(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported uri scheme: %v", uri)
+	}
+	return filepath.FromSlash(u.Path), nil
+}
+
+//----------
+
+// Converts a byte offset into a line/utf16-character position.
+func OffsetPosition(b []byte, offset int) Position {
+	if offset > len(b) {
+		offset = len(b)
+	}
+	p := Position{}
+	for i := 0; i < offset; {
+		ru, size := utf8.DecodeRune(b[i:])
+		i += size
+		if ru == '\n' {
+			p.Line++
+			p.Character = 0
+			continue
+		}
+		p.Character += utf16Len(ru)
+	}
+	return p
+}
+
+// Converts a line/utf16-character position into a byte offset. Positions past the line end (or content end) are adjusted to the line end (or content end).
+func PositionOffset(b []byte, p Position) int {
+	i := 0
+	for l := 0; l < p.Line; l++ {
+		k := bytes.IndexByte(b[i:], '\n')
+		if k < 0 {
+			return len(b)
+		}
+		i += k + 1
+	}
+	for c := 0; c < p.Character && i < len(b); {
+		ru, size := utf8.DecodeRune(b[i:])
+		if ru == '\n' {
+			break
+		}
+		c += utf16Len(ru)
+		i += size
+	}
+	return i
+}
+
+func RangeOffsets(b []byte, r Range) (int, int) {
+	return PositionOffset(b, r.Start), PositionOffset(b, r.End)
+}
+
+func utf16Len(ru rune) int {
+	if r1, _ := utf16.EncodeRune(ru); r1 != utf8.RuneError {
+		return 2 // surrogate pair
+	}
+	return 1
+}
@@ -0,0 +1,423 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jmigpin/editor/core/lsproto"
+	"github.com/jmigpin/editor/core/parseutil"
+	"github.com/jmigpin/editor/core/toolbarparser"
+	"github.com/jmigpin/editor/util/iout"
+	"github.com/jmigpin/editor/util/osutil"
+)
+
+// Language server requests timeout (includes starting the server on first use).
+const lsprotoTimeout = 15 * time.Second
+
+// Keeps the language server in sync with the row content (opened/changed). Sent after the content stops changing (the content is only read then). Requests sync the content they use.
+func (info *ERowInfo) lsprotoDidChange() {
+	if !info.lsprotoFile() {
+		return
+	}
+	info.lsprotoChange.seq++
+	seq := info.lsprotoChange.seq
+	if info.lsprotoChange.timer != nil {
+		info.lsprotoChange.timer.Stop()
+	}
+	info.lsprotoChange.timer = time.AfterFunc(250*time.Millisecond, func() {
+		info.Ed.UI.RunOnUIGoRoutine(func() {
+			if info.lsprotoChange.seq != seq || !info.lsprotoFile() {
+				return
+			}
+			b, err := info.ERows[0].TextAreaBytesCopy()
+			if err != nil {
+				return
+			}
+			info.Ed.LSProtoMan.DidChange(info.Name(), b)
+		})
+	})
+}
+
+// Rows content was saved.
+func (info *ERowInfo) lsprotoDidSave() {
+	if !info.lsprotoFile() {
+		return
+	}
+	b, err := info.ERows[0].TextAreaBytesCopy()
+	if err != nil {
+		return
+	}
+	info.Ed.LSProtoMan.DidSave(info.Name(), b)
+}
+
+func (info *ERowInfo) lsprotoDidClose() {
+	// pending change would open the document again
+	info.lsprotoChange.seq++
+	if info.lsprotoChange.timer != nil {
+		info.lsprotoChange.timer.Stop()
+	}
+	if info.Ed.LSProtoMan != nil && info.IsFileButNotDir() {
+		info.Ed.LSProtoMan.DidClose(info.Name())
+	}
+}
+
+func (info *ERowInfo) lsprotoFile() bool {
+	return info.Ed.LSProtoMan != nil &&
+		len(info.ERows) > 0 &&
+		info.IsFileButNotDir() &&
+		info.Ed.LSProtoMan.Has(info.Name())
+}
+
+//----------
+
+// Runs a request with the row content at the cursor in the background. The result function runs in the UI goroutine.
+func lsprotoRequest(erow *ERow, fn func(ctx context.Context, filename string, b []byte, offset int) (func() error, error)) error {
+	offset := erow.Row.TextArea.TextCursor.Index()
+	return lsprotoRequestAt(erow, offset, fn)
+}
+
+func lsprotoRequestAt(erow *ERow, offset int, fn func(ctx context.Context, filename string, b []byte, offset int) (func() error, error)) error {
+	if !erow.Info.lsprotoFile() {
+		return fmt.Errorf("no language server for this row")
+	}
+	b, err := erow.TextAreaBytesCopy()
+	if err != nil {
+		return err
+	}
+
+	ed := erow.Ed
+	filename := erow.Info.Name()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), lsprotoTimeout)
+		defer cancel()
+		res, err := fn(ctx, filename, b, offset)
+		ed.UI.RunOnUIGoRoutine(func() {
+			if err == nil {
+				err = res()
+			}
+			if err != nil {
+				ed.Error(err)
+			}
+		})
+	}()
+	return nil
+}
+
+//----------
+
+// Opens the definition of the identifier at index when the server replies. If notFound is not nil, it runs (UI goroutine) instead of reporting an error when the server fails or has no definition (ex: content cmds try the next cmd).
+func LSProtoDefinition(erow *ERow, index int, notFound func()) error {
+	return lsprotoRequestAt(erow, index, func(ctx context.Context, filename string, b []byte, offset int) (func() error, error) {
+		locs, err := erow.Ed.LSProtoMan.Definition(ctx, filename, b, offset)
+		if err == nil && len(locs) == 0 {
+			err = fmt.Errorf("definition not found")
+		}
+		if err != nil {
+			if notFound != nil {
+				return func() error {
+					if erowIsOpen(erow) {
+						notFound()
+					}
+					return nil
+				}, nil
+			}
+			return nil, err
+		}
+		return func() error {
+			if !erowIsOpen(erow) {
+				return nil
+			}
+			fp, _, err := lsprotoLocationFilePos(erow.Ed, locs[0])
+			if err != nil {
+				return err
+			}
+			// place the file under the calling row
+			OpenERowFilePosVisibleOrNew(erow.Ed, fp, erow.Row.PosBelow())
+			return nil
+		}, nil
+	})
+}
+
+// Lists the references of the identifier at the cursor in the "+References" row.
+func LSProtoReferencesCmd(erow *ERow) error {
+	return lsprotoRequest(erow, func(ctx context.Context, filename string, b []byte, offset int) (func() error, error) {
+		locs, err := erow.Ed.LSProtoMan.References(ctx, filename, b, offset)
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			buf := &bytes.Buffer{}
+			for _, loc := range locs {
+				fp, text, err := lsprotoLocationFilePos(erow.Ed, loc)
+				if err != nil {
+					return err
+				}
+				name := parseutil.EscapeFilename(fp.Filename)
+				fmt.Fprintf(buf, "%v:%v:%v: %s\n", name, fp.Line, fp.Column, text)
+			}
+			if len(locs) == 0 {
+				return fmt.Errorf("no references found")
+			}
+			erow2, _ := erow.Ed.ExistingOrNewERow("+References")
+			erow2.Row.TextArea.SetBytesClearPos(buf.Bytes())
+			erow2.Flash()
+			return nil
+		}, nil
+	})
+}
+
+func LSProtoHoverCmd(erow *ERow) error {
+	return lsprotoRequest(erow, func(ctx context.Context, filename string, b []byte, offset int) (func() error, error) {
+		s, err := erow.Ed.LSProtoMan.Hover(ctx, filename, b, offset)
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			if s == "" {
+				return fmt.Errorf("no hover information")
+			}
+			erow.Ed.Messagef("%v", s)
+			return nil
+		}, nil
+	})
+}
+
+func LSProtoCompletionCmd(erow *ERow) error {
+	return lsprotoRequest(erow, func(ctx context.Context, filename string, b []byte, offset int) (func() error, error) {
+		items, err := erow.Ed.LSProtoMan.Completion(ctx, filename, b, offset)
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			if len(items) == 0 {
+				return fmt.Errorf("no completions")
+			}
+			u := []string{}
+			for _, it := range items {
+				s := it.Label
+				if it.Detail != "" {
+					s += " " + it.Detail
+				}
+				u = append(u, s)
+			}
+			erow.Ed.Messagef("completion:\n\t%v", strings.Join(u, "\n\t"))
+			return nil
+		}, nil
+	})
+}
+
+// Renames the identifier at the cursor. Open rows get the edits in their content (one undo step per row), files that are not open are changed on disk. Fails without changes if the rows content changed while waiting for the server.
+func LSProtoRenameCmd(erow *ERow, part *toolbarparser.Part) error {
+	args := part.Args[1:]
+	if len(args) != 1 {
+		return fmt.Errorf("expecting 1 argument")
+	}
+	newName := args[0].UnquotedStr()
+	hashes := openRowsHashes(erow.Ed)
+	return lsprotoRequest(erow, func(ctx context.Context, filename string, b []byte, offset int) (func() error, error) {
+		m, err := erow.Ed.LSProtoMan.Rename(ctx, filename, b, offset, newName)
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			if len(m) == 0 {
+				return fmt.Errorf("nothing to rename")
+			}
+			// edits were computed with the content at the request
+			for fn := range m {
+				if err := checkOpenRowHash(erow.Ed, hashes, fn); err != nil {
+					return err
+				}
+			}
+			fedits := map[string][]*fileEdit{}
+			for fn, edits := range m {
+				b, err := editorFileBytes(erow.Ed, fn)
+				if err != nil {
+					return err
+				}
+				for _, e := range edits {
+					a, b := lsproto.RangeOffsets(b, e.Range)
+					fedits[fn] = append(fedits[fn], &fileEdit{a, b, []byte(e.NewText)})
+				}
+			}
+			return applyFileEdits(erow.Ed, fedits)
+		}, nil
+	})
+}
+
+// Lists the last diagnostics published for the row file in the "+Diagnostics" row.
+func LSProtoDiagnosticsCmd(erow *ERow) error {
+	if !erow.Info.lsprotoFile() {
+		return fmt.Errorf("no language server for this row")
+	}
+	filename := erow.Info.Name()
+	diags := erow.Ed.LSProtoMan.Diagnostics(filename)
+	if len(diags) == 0 {
+		return fmt.Errorf("no diagnostics")
+	}
+	b, err := erow.Row.TextArea.Bytes()
+	if err != nil {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	name := parseutil.EscapeFilename(filename)
+	for _, d := range diags {
+		offset := lsproto.PositionOffset(b, d.Range.Start)
+		line, col := offsetLineColumn(b, offset)
+		fmt.Fprintf(buf, "%v:%v:%v: %v: %v\n", name, line, col, d.SeverityStr(), d.Message)
+	}
+	erow2, _ := erow.Ed.ExistingOrNewERow("+Diagnostics")
+	erow2.Row.TextArea.SetBytesClearPos(buf.Bytes())
+	erow2.Flash()
+	return nil
+}
+
+//----------
+
+// Position of the location start, and the text of its line.
+func lsprotoLocationFilePos(ed *Editor, loc *lsproto.Location) (*parseutil.FilePos, []byte, error) {
+	filename, err := lsproto.URIToFilename(loc.URI)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := editorFileBytes(ed, filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	offset := lsproto.PositionOffset(b, loc.Range.Start)
+	line, col := offsetLineColumn(b, offset)
+	text := lineAt(b, offset)
+	return &parseutil.FilePos{Filename: filename, Line: line, Column: col}, text, nil
+}
+
+// Content hashes of the open rows (ex: detect changes while waiting for a server).
+func openRowsHashes(ed *Editor) map[string][]byte {
+	m := map[string][]byte{}
+	for fn, info := range ed.ERowInfos {
+		if len(info.ERows) == 0 || !info.IsFileButNotDir() {
+			continue
+		}
+		b, err := info.ERows[0].Row.TextArea.Bytes()
+		if err != nil {
+			continue
+		}
+		m[fn] = bytesHash(b)
+	}
+	return m
+}
+
+// Fails if the file row was opened, closed, or had its content changed since the hashes were taken.
+func checkOpenRowHash(ed *Editor, hashes map[string][]byte, filename string) error {
+	h, wasOpen := hashes[filename]
+	info, ok := ed.ERowInfos[filename]
+	isOpen := ok && len(info.ERows) > 0
+	if !wasOpen && !isOpen {
+		return nil
+	}
+	if wasOpen && isOpen {
+		b, err := info.ERows[0].Row.TextArea.Bytes()
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(bytesHash(b), h) {
+			return nil
+		}
+	}
+	return fmt.Errorf("content changed: %v", filename)
+}
+
+// Content of the open row, or the file on disk.
+func editorFileBytes(ed *Editor, filename string) ([]byte, error) {
+	if info, ok := ed.ERowInfos[filename]; ok && len(info.ERows) > 0 {
+		return info.ERows[0].Row.TextArea.Bytes()
+	}
+	return ioutil.ReadFile(filename)
+}
+
+// Line and rune column start at 1.
+func offsetLineColumn(b []byte, offset int) (int, int) {
+	line := bytes.Count(b[:offset], []byte("\n")) + 1
+	ls := bytes.LastIndexByte(b[:offset], '\n') + 1
+	col := utf8.RuneCount(b[ls:offset]) + 1
+	return line, col
+}
+
+func lineAt(b []byte, offset int) []byte {
+	ls := bytes.LastIndexByte(b[:offset], '\n') + 1
+	le := len(b)
+	if k := bytes.IndexByte(b[offset:], '\n'); k >= 0 {
+		le = offset + k
+	}
+	return bytes.TrimSpace(b[ls:le])
+}
+
+//----------
+
+// Replaces [Offset,End) with Text.
+type fileEdit struct {
+	Offset, End int
+	Text        []byte
+}
+
+// Applies the edits to the content of the open rows (one undo step per row), and writes the files that are not open. Fails without changes if the edits overlap.
+func applyFileEdits(ed *Editor, m map[string][]*fileEdit) error {
+	// sort descending to apply without adjusting offsets
+	for fn, edits := range m {
+		sort.Slice(edits, func(i, j int) bool {
+			return edits[i].Offset > edits[j].Offset
+		})
+		for i := 1; i < len(edits); i++ {
+			if edits[i].End > edits[i-1].Offset {
+				return fmt.Errorf("overlapping edits: %v", fn)
+			}
+		}
+	}
+
+	for fn, edits := range m {
+		if info, ok := ed.ERowInfos[fn]; ok && len(info.ERows) > 0 {
+			// one undo step, other rows are updated by the setstr callback
+			tc := info.ERows[0].Row.TextArea.TextCursor
+			var err error
+			tc.Edit(func() {
+				for _, e := range edits {
+					err = iout.DeleteInsert(tc.RW(), e.Offset, e.End-e.Offset, e.Text)
+					if err != nil {
+						return
+					}
+				}
+			})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		b, err := ioutil.ReadFile(fn)
+		if err != nil {
+			return err
+		}
+		for _, e := range edits {
+			if e.End > len(b) {
+				return fmt.Errorf("edit out of range: %v", fn)
+			}
+			b2 := append([]byte{}, b[:e.Offset]...)
+			b2 = append(b2, e.Text...)
+			b = append(b2, b[e.End:]...)
+		}
+		fi, err := os.Stat(fn)
+		if err != nil {
+			return err
+		}
+		if err := osutil.WriteFileAtomic(fn, b, fi.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
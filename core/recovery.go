@@ -0,0 +1,373 @@
+package core
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jmigpin/editor/core/toolbarparser"
+	"github.com/jmigpin/editor/util/diffutil"
+	"github.com/jmigpin/editor/util/osutil"
+)
+
+// Edited files content is saved periodically to this directory to allow recovery after a crash. A snapshot is removed when the file is saved or its rows are closed.
+func recoveryDir() string {
+	home := os.Getenv("HOME")
+	return filepath.Join(home, ".editor_recovery")
+}
+
+// Recovery filename (inside a session directory) is the hash of the file path.
+func recoveryFilename(dir, name string) string {
+	h := bytesHash([]byte(name))
+	return filepath.Join(dir, hex.EncodeToString(h))
+}
+
+const recoveryInterval = 30 * time.Second
+
+//----------
+
+type recoveryFile struct {
+	Name    string
+	Time    time.Time
+	Content []byte
+}
+
+func readRecoveryFile(filename string) (*recoveryFile, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rf := &recoveryFile{}
+	if err := gob.NewDecoder(f).Decode(rf); err != nil {
+		return nil, fmt.Errorf("recovery file: %v: %v", filename, err)
+	}
+	return rf, nil
+}
+
+//----------
+
+// Each editor instance writes its snapshots to its own session directory, locked while the instance runs. Only the snapshots of sessions that are not locked (the instance is gone) are offered for recovery.
+type recoverySession struct {
+	dir  string   // created on the first write
+	lock *os.File // lock file in dir, locked until close
+}
+
+func (rs *recoverySession) init() error {
+	if rs.dir != "" {
+		return nil
+	}
+	if err := os.MkdirAll(recoveryDir(), 0700); err != nil {
+		return err
+	}
+
+	// lock before the directory gets a listed name (hidden names are not listed) to not be taken as a dead session
+	tmp, err := ioutil.TempDir(recoveryDir(), ".session")
+	if err != nil {
+		return err
+	}
+	f, err := lockRecoverySession(tmp)
+	if err != nil {
+		_ = os.RemoveAll(tmp)
+		return err
+	}
+	dir := filepath.Join(recoveryDir(), filepath.Base(tmp)[1:])
+	if err := os.Rename(tmp, dir); err != nil {
+		f.Close()
+		_ = os.RemoveAll(tmp)
+		return err
+	}
+
+	rs.dir = dir
+	rs.lock = f
+	return nil
+}
+
+func (rs *recoverySession) write(rf *recoveryFile) error {
+	if err := rs.init(); err != nil {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(rf); err != nil {
+		return err
+	}
+	return osutil.WriteFileAtomic(recoveryFilename(rs.dir, rf.Name), buf.Bytes(), 0600)
+}
+
+// Removes the snapshot of this session and of dead sessions (content is on disk or was discarded).
+func (rs *recoverySession) remove(name string) error {
+	err := rs.removeOwn(name)
+	if err2 := rs.removeDead(name); err2 != nil && err == nil {
+		err = err2
+	}
+	return err
+}
+
+func (rs *recoverySession) removeDead(name string) error {
+	var err error
+	walkDeadRecoverySessions(rs, func(dir string) {
+		err2 := os.Remove(recoveryFilename(dir, name))
+		if err2 != nil && !os.IsNotExist(err2) && err == nil {
+			err = err2
+		}
+	})
+	return err
+}
+
+func (rs *recoverySession) removeOwn(name string) error {
+	if rs.dir == "" {
+		return nil
+	}
+	err := os.Remove(recoveryFilename(rs.dir, name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Removes the session directory if it has no snapshots. Snapshots left (unsaved content) stay available to the next instances.
+func (rs *recoverySession) close() {
+	if rs.dir == "" {
+		return
+	}
+	removeRecoverySessionIfEmpty(rs.dir)
+	rs.lock.Close() // unlocks
+	rs.dir = ""
+	rs.lock = nil
+}
+
+//----------
+
+// Calls fn with the directory of each session that is not locked by a running instance. The session stays locked during fn.
+func walkDeadRecoverySessions(rs *recoverySession, fn func(dir string)) {
+	fis, err := ioutil.ReadDir(recoveryDir())
+	if err != nil {
+		return
+	}
+	for _, fi := range fis {
+		if !fi.IsDir() || strings.HasPrefix(fi.Name(), ".") {
+			continue
+		}
+		dir := filepath.Join(recoveryDir(), fi.Name())
+		if dir == rs.dir {
+			continue
+		}
+		f, err := lockRecoverySession(dir)
+		if err != nil {
+			continue // running instance
+		}
+		fn(dir)
+		removeRecoverySessionIfEmpty(dir)
+		f.Close()
+	}
+}
+
+func lockRecoverySession(dir string) (*os.File, error) {
+	f, err := os.OpenFile(filepath.Join(dir, "lock"), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func removeRecoverySessionIfEmpty(dir string) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, fi := range fis {
+		if fi.Name() != "lock" {
+			return
+		}
+	}
+	_ = os.Remove(filepath.Join(dir, "lock"))
+	_ = os.Remove(dir)
+}
+
+// Snapshots of dead sessions, the most recent for each name.
+func deadRecoveryFiles(rs *recoverySession) ([]*recoveryFile, []error) {
+	m := map[string]*recoveryFile{}
+	var errs []error
+	walkDeadRecoverySessions(rs, func(dir string) {
+		fis, err := ioutil.ReadDir(dir)
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		for _, fi := range fis {
+			if fi.Name() == "lock" {
+				continue
+			}
+			rf, err := readRecoveryFile(filepath.Join(dir, fi.Name()))
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if rf2, ok := m[rf.Name]; !ok || rf.Time.After(rf2.Time) {
+				m[rf.Name] = rf
+			}
+		}
+	})
+	rfs := []*recoveryFile{}
+	for _, rf := range m {
+		rfs = append(rfs, rf)
+	}
+	sort.Slice(rfs, func(a, b int) bool {
+		return rfs[a].Name < rfs[b].Name
+	})
+	return rfs, errs
+}
+
+//----------
+
+// Runs until the editor closes.
+func (ed *Editor) runRecoverySnapshots() {
+	t := time.NewTicker(recoveryInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ed.close:
+			return
+		case <-t.C:
+			ed.UI.RunOnUIGoRoutine(ed.saveRecoverySnapshots)
+		}
+	}
+}
+
+func (ed *Editor) saveRecoverySnapshots() {
+	for _, info := range ed.ERowInfos {
+		if err := info.saveRecoverySnapshot(); err != nil {
+			ed.Error(err)
+		}
+	}
+}
+
+//----------
+
+// Saves the rows content if edited. Only writes if the content changed since the last snapshot.
+func (info *ERowInfo) saveRecoverySnapshot() error {
+	if !info.IsFileButNotDir() || len(info.ERows) == 0 {
+		return nil
+	}
+	b, edited, err := info.rowsEdited()
+	if err != nil {
+		return err
+	}
+	if !edited {
+		return info.removeRecoverySnapshot()
+	}
+	h := bytesHash(b)
+	if bytes.Equal(h, info.recoveryHash) {
+		return nil
+	}
+	rf := &recoveryFile{Name: info.Name(), Time: time.Now(), Content: b}
+	if err := info.Ed.recovery.write(rf); err != nil {
+		return err
+	}
+	info.recoveryHash = h
+	return nil
+}
+
+func (info *ERowInfo) removeRecoverySnapshot() error {
+	if info.recoveryHash == nil {
+		return nil
+	}
+	info.recoveryHash = nil
+	return info.Ed.recovery.removeOwn(info.Name())
+}
+
+//----------
+
+// Lists the recoverable files (if any) in the "+Recovery" row, with a diff against the disk content.
+func (ed *Editor) showRecoveryRow() {
+	rfs, errs := deadRecoveryFiles(ed.recovery)
+	for _, err := range errs {
+		ed.Error(err)
+	}
+	if len(rfs) == 0 {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "# unsaved content from a previous session: click a \"RecoverFile\" line to restore it into a row (the file is only written on save)\n")
+	for _, rf := range rfs {
+		fmt.Fprintf(buf, "\nRecoverFile %v\n", rf.Name)
+		fmt.Fprintf(buf, "# snapshot time: %v\n", rf.Time.Format(historyTimeFormat))
+		disk, err := ioutil.ReadFile(rf.Name)
+		if err != nil {
+			fmt.Fprintf(buf, "# %v\n", err)
+			continue
+		}
+		err = diffutil.WriteUnified(buf, "disk", "recovery", disk, rf.Content, 3)
+		if err != nil {
+			fmt.Fprintf(buf, "# %v\n", err)
+		}
+	}
+
+	erow, _ := ed.ExistingOrNewERow("+Recovery")
+	erow.Row.TextArea.SetBytesClearPos(buf.Bytes())
+	erow.Flash()
+}
+
+//----------
+
+// Usage: RecoverFile <filename>
+func RecoverFileCmd(ed *Editor, part *toolbarparser.Part) error {
+	args := part.Args[1:]
+	if len(args) != 1 {
+		return fmt.Errorf("expecting filename argument")
+	}
+	return RecoverFile(ed, args[0].UnquotedStr())
+}
+
+// Restores the recovery snapshot content into a row. The file is not saved.
+func RecoverFile(ed *Editor, name string) error {
+	name = strings.TrimSpace(name)
+	var rf *recoveryFile
+	rfs, _ := deadRecoveryFiles(ed.recovery)
+	for _, rf2 := range rfs {
+		if rf2.Name == name {
+			rf = rf2
+		}
+	}
+	if rf == nil {
+		return fmt.Errorf("recovery file not found: %v", name)
+	}
+
+	var err error
+
+	info := ed.ReadERowInfo(name)
+	var erow *ERow
+	if len(info.ERows) > 0 {
+		erow = info.ERows[0]
+	} else {
+		erow, err = info.NewERowCreateOnErr(ed.GoodRowPos())
+		if err != nil {
+			ed.Error(err)
+		}
+	}
+	erow.Row.TextArea.SetBytes(rf.Content) // updates duplicate rows
+	erow.Flash()
+
+	// the dead snapshot is only removed once this session has its own (a crash before would lose the content)
+	info.recoveryHash = nil
+	if err := info.saveRecoverySnapshot(); err != nil {
+		return err
+	}
+	if info.recoveryHash == nil {
+		return nil // not written (ex: not edited), kept until the file is saved
+	}
+	return ed.recovery.removeDead(name)
+}
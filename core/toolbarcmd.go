@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -167,17 +168,28 @@ func toolbarCmd(ed *Editor, part *toolbarparser.Part, erow *ERow) {
 		rowCmd(func(e *ERow) { e.Row.Maximize() })
 
 	case "Save":
-		rowCmd(func(e *ERow) { SaveCmd(e.Info) })
+		rowCmdErr(func(e *ERow) error { return SaveCmdFlags(e.Info, part) })
 	case "SaveAllFiles":
 		rootOnlyCmd(func() { SaveAllFilesCmd(ed) })
 
 	case "Reload":
 		rowCmd(func(e *ERow) { ReloadCmd(e) })
+	case "DiffDisk":
+		rowCmdErr(func(e *ERow) error { return DiffDiskCmd(e) })
+	case "DiffRows":
+		rowCmdErr(func(e *ERow) error { return DiffRowsCmd(e, part) })
+	case "MergeReload":
+		rowCmdErr(func(e *ERow) error { return MergeReloadCmd(e) })
 	case "ReloadAllFiles":
 		rootOnlyCmd(func() { ReloadAllFilesCmd(ed) })
 	case "ReloadAll":
 		rootOnlyCmd(func() { ReloadAllCmd(ed) })
 
+	case "History":
+		rowCmdErr(func(e *ERow) error { return HistoryCmd(e) })
+	case "HistoryGoto":
+		rowCmdErr(func(e *ERow) error { return HistoryGotoCmd(e, part) })
+
 	case "Stop":
 		rowCmd(func(e *ERow) { e.Exec.Stop() })
 
@@ -186,25 +198,76 @@ func toolbarCmd(ed *Editor, part *toolbarparser.Part, erow *ERow) {
 
 	case "Find":
 		rowCmdErr(func(e *ERow) error { return FindCmd(e, part) })
+	case "FindAll":
+		rowCmdErr(func(e *ERow) error { return FindAllCmd(e, part) })
 	case "Replace":
 		rowCmdErr(func(e *ERow) error { return ReplaceCmd(e, part) })
 	case "GotoLine":
 		rowCmdErr(func(e *ERow) error { return GotoLineCmd(e, part) })
 	case "CopyFilePosition":
 		rowCmdErr(func(e *ERow) error { return CopyFilePositionCmd(ed, e) })
+	case "GotoMatchingBracket":
+		rowCmdErr(func(e *ERow) error { return textutil.GotoMatchingBracket(e.Row.TextArea.TextEditX) })
+	case "SelectInsideBrackets":
+		rowCmdErr(func(e *ERow) error { return textutil.SelectInsideBrackets(e.Row.TextArea.TextEditX) })
+	case "SelectEnclosingBlock":
+		rowCmdErr(func(e *ERow) error { return textutil.SelectEnclosingBlock(e.Row.TextArea.TextEditX) })
+	case "Fold":
+		rowCmdErr(func(e *ERow) error { return FoldCmd(e) })
+	case "FoldAll":
+		rowCmdErr(func(e *ERow) error { return FoldAllCmd(e) })
+	case "Unfold":
+		rowCmdErr(func(e *ERow) error { return UnfoldCmd(e) })
+	case "UnfoldAll":
+		rowCmdErr(func(e *ERow) error { return UnfoldAllCmd(e) })
 	case "ToggleRowHBar":
 		rowCmdErr(func(e *ERow) error { return ToggleRowHBarCmd(ed, e) })
 
 	case "ListDir":
 		rowCmdErr(func(e *ERow) error { return ListDirCmd(e, part) })
+	case "Grep":
+		rowCmdErr(func(e *ERow) error { return GrepCmd(e, part) })
+
+	case "Git":
+		rowCmdErr(func(e *ERow) error { return GitCmd(e) })
+	case "GitDiff":
+		rowCmdErr(func(e *ERow) error { return GitDiffCmd(e, part) })
+	case "GitAdd":
+		rowCmdErr(func(e *ERow) error { return GitAddCmd(e, part) })
+	case "GitCommit":
+		rowCmdErr(func(e *ERow) error { return GitCommitCmd(e, part) })
+	case "GitBlame":
+		rowCmdErr(func(e *ERow) error { return GitBlameCmd(e) })
 
 	case "XdgOpenDir":
 		rowCmdErr(func(e *ERow) error { return XdgOpenDirCmd(e) })
 	case "GoRename":
 		rowCmdErr(func(e *ERow) error { return GoRenameCmd(e, part) })
+	case "GoReferences":
+		rowCmdErr(func(e *ERow) error { return GoReferencesCmd(e) })
+	case "GoDoc":
+		rowCmdErr(func(e *ERow) error { return GoDocCmd(e) })
 	case "GoDebug":
 		rowCmdErr(func(e *ERow) error { return GoDebugCmd(e, part) })
 
+	case "LSProtoDefinition":
+		rowCmdErr(func(e *ERow) error { return LSProtoDefinition(e, e.Row.TextArea.TextCursor.Index(), nil) })
+	case "LSProtoReferences":
+		rowCmdErr(func(e *ERow) error { return LSProtoReferencesCmd(e) })
+	case "LSProtoHover":
+		rowCmdErr(func(e *ERow) error { return LSProtoHoverCmd(e) })
+	case "LSProtoCompletion":
+		rowCmdErr(func(e *ERow) error { return LSProtoCompletionCmd(e) })
+	case "LSProtoRename":
+		rowCmdErr(func(e *ERow) error { return LSProtoRenameCmd(e, part) })
+	case "LSProtoDiagnostics":
+		rowCmdErr(func(e *ERow) error { return LSProtoDiagnosticsCmd(e) })
+
+	case "RecoverFile":
+		if err := RecoverFileCmd(ed, part); err != nil {
+			ed.Errorf("%v: %v", arg0, err)
+		}
+
 	case "ColorTheme":
 		colorThemeCmd(ed)
 	case "FontTheme":
@@ -251,10 +314,24 @@ func NewRowCmd(ed *Editor) {
 //----------
 
 func SaveCmd(info *ERowInfo) {
-	if err := info.SaveFile(); err != nil {
+	if err := info.SaveFile(false); err != nil {
 		info.Ed.Error(err)
 	}
 }
+
+// Flags: -force (overwrite even if the file changed on disk).
+func SaveCmdFlags(info *ERowInfo, part *toolbarparser.Part) error {
+	force := false
+	for _, a := range part.Args[1:] {
+		switch s := a.UnquotedStr(); s {
+		case "-force":
+			force = true
+		default:
+			return fmt.Errorf("unexpected argument: %v", s)
+		}
+	}
+	return info.SaveFile(force)
+}
 func SaveAllFilesCmd(ed *Editor) {
 	for _, info := range ed.ERowInfos {
 		if info.IsFileButNotDir() {
@@ -268,6 +345,16 @@ func SaveAllFilesCmd(ed *Editor) {
 func ReloadCmd(erow *ERow) {
 	erow.Reload()
 }
+func MergeReloadCmd(erow *ERow) error {
+	n, err := erow.Info.MergeReloadFile()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return fmt.Errorf("%v conflict(s), search for \"<<<<<<<\"", n)
+	}
+	return nil
+}
 func ReloadAllFilesCmd(ed *Editor) {
 	for _, info := range ed.ERowInfos {
 		if info.IsFileButNotDir() {
@@ -294,9 +381,42 @@ func ReloadAllCmd(ed *Editor) {
 //----------
 
 func FindCmd(erow *ERow, part *toolbarparser.Part) error {
-	args := part.Args[1:]
+	fa, err := parseFindCmdArgs(part)
+	if err != nil {
+		return err
+	}
+
+	te := erow.Row.TextArea.TextEdit
+	var found bool
+	if fa.re != nil {
+		found, err = textutil.FindRegexp(te, fa.re)
+	} else {
+		found, err = textutil.Find(te, fa.str, fa.ignoreCase)
+	}
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("string not found: %q", fa.str)
+	}
+	return nil
+}
+
+//----------
+
+type findCmdArgs struct {
+	str        string
+	re         *regexp.Regexp // not nil if "-re" flag is set
+	ignoreCase bool
+}
+
+// Flags: -re (regular expression), -case (case sensitive).
+func parseFindCmdArgs(part *toolbarparser.Part) (*findCmdArgs, error) {
+	args, flags := parseCmdFlags(part.Args[1:], "-re", "-case")
+	reOpt, caseOpt := flags["-re"], flags["-case"]
+
 	if len(args) < 1 {
-		return fmt.Errorf("expecting argument")
+		return nil, fmt.Errorf("expecting argument")
 	}
 	var str string
 	if len(args) == 1 {
@@ -308,29 +428,60 @@ func FindCmd(erow *ERow, part *toolbarparser.Part) error {
 		str = strings.TrimSpace(s)
 	}
 
-	found, err := textutil.Find(erow.Row.TextArea.TextEdit, str)
-	if err != nil {
-		return err
-	}
-	if !found {
-		return fmt.Errorf("string not found: %q", str)
+	fa := &findCmdArgs{str: str, ignoreCase: !caseOpt}
+	if reOpt {
+		restr := str
+		if !caseOpt {
+			restr = "(?i)" + restr
+		}
+		re, err := regexp.Compile(restr)
+		if err != nil {
+			return nil, err
+		}
+		fa.re = re
 	}
-	return nil
+	return fa, nil
 }
 
 //----------
 
+// Flags: -re (regular expression, new string can use $1-style submatches), -i (ignore case). Case sensitive by default.
 func ReplaceCmd(erow *ERow, part *toolbarparser.Part) error {
-	args := part.Args[1:]
+	args, flags := parseCmdFlags(part.Args[1:], "-re", "-i")
+	reOpt, icaseOpt := flags["-re"], flags["-i"]
+
 	if len(args) != 2 {
 		return fmt.Errorf("expecting 2 arguments")
 	}
 
 	old, new := args[0].UnquotedStr(), args[1].UnquotedStr()
 
-	replaced, err := textutil.Replace(erow.Row.TextArea.TextEdit, old, new)
-	if err != nil {
-		return err
+	te := erow.Row.TextArea.TextEdit
+	var replaced bool
+	if reOpt || (icaseOpt && old != "") {
+		restr := old
+		if !reOpt {
+			// literal string, ignoring case
+			restr = regexp.QuoteMeta(old)
+			new = strings.Replace(new, "$", "$$", -1)
+		}
+		if icaseOpt {
+			restr = "(?i)" + restr
+		}
+		re, err := regexp.Compile(restr)
+		if err != nil {
+			return err
+		}
+		replaced, err = textutil.ReplaceRegexp(te, re, new)
+		if err != nil {
+			return err
+		}
+	} else {
+		var err error
+		replaced, err = textutil.Replace(te, old, new)
+		if err != nil {
+			return err
+		}
 	}
 	if !replaced {
 		return fmt.Errorf("string not replaced: %q", old)
@@ -338,6 +489,26 @@ func ReplaceCmd(erow *ERow, part *toolbarparser.Part) error {
 	return nil
 }
 
+// Leading flags (any order) that are in the names list. Returns the remaining args and the flags found.
+func parseCmdFlags(args []*toolbarparser.Arg, names ...string) ([]*toolbarparser.Arg, map[string]bool) {
+	flags := map[string]bool{}
+	for ; len(args) > 0; args = args[1:] {
+		s := args[0].Str()
+		found := false
+		for _, n := range names {
+			if s == n {
+				found = true
+				break
+			}
+		}
+		if !found {
+			break
+		}
+		flags[s] = true
+	}
+	return args, flags
+}
+
 //----------
 
 func CopyFilePositionCmd(ed *Editor, erow *ERow) error {
@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"runtime/pprof"
+	"strings"
 
 	"github.com/jmigpin/editor/core"
 	_ "github.com/jmigpin/editor/core/contentcmds"
@@ -30,6 +31,8 @@ func main() {
 	tabWidthFlag := flag.Int("tabwidth", 8, "")
 	shadowsFlag := flag.Bool("shadows", true, "shadow effects on some elements")
 	sessionNameFlag := flag.String("sessionname", "", "open existing session")
+	lsprotoFlag := &stringsFlag{}
+	flag.Var(lsprotoFlag, "lsproto", "language server \"language,exts,cmd\", can be repeated. Ex: \"go,.go,gopls\"")
 
 	flag.Parse()
 
@@ -59,9 +62,24 @@ func main() {
 
 		SessionName: *sessionNameFlag,
 		Filenames:   flag.Args(),
+
+		LSProtos: *lsprotoFlag,
 	}
 	_, err := core.NewEditor(eopt)
 	if err != nil {
 		log.Fatal(err)
 	}
 }
+
+//----------
+
+// Flag that can be repeated.
+type stringsFlag []string
+
+func (sf *stringsFlag) String() string {
+	return strings.Join(*sf, " ")
+}
+func (sf *stringsFlag) Set(s string) error {
+	*sf = append(*sf, s)
+	return nil
+}
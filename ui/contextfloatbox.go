@@ -2,46 +2,230 @@ package ui
 
 import (
 	"image"
+	"strings"
 
+	"github.com/jmigpin/editor/util/drawutil/drawer3"
 	"github.com/jmigpin/editor/util/uiutil/event"
 	"github.com/jmigpin/editor/util/uiutil/widget"
 )
 
+// Floating box at the text cursor. Shows a scrollable list of entries (ex: code completion candidates) with one selected entry, and the selected entry documentation below.
 type ContextFloatBox struct {
 	*widget.FloatBox
-	Label   *widget.Label
-	root    *Root
-	Enabled bool // used externally
+	List *widget.TextEditX
+	Doc  *widget.Label
+	root *Root
+
+	OnClickEntry func(i int)
+
+	listSA   *widget.ScrollArea
+	lines    []int // start index of the entries in the list text
+	selected int
 }
 
 func NewContextFloatBox(root *Root) *ContextFloatBox {
 	cfb := &ContextFloatBox{root: root}
 
-	cfb.Label = widget.NewLabel(root.UI)
-	cfb.Label.Text.SetStr("testing")
-	cfb.Label.Pad.Left = 5
-	cfb.Label.Pad.Right = 5
-	cfb.Label.Border.SetAll(1)
+	cfb.List = widget.NewTextEditX(root.UI, root.UI)
+	if d, ok := cfb.List.Drawer.(*drawer3.PosDrawer); ok {
+		d.Cursor.SetOn(false)
+	}
+	cfb.listSA = widget.NewScrollArea(root.UI, cfb.List, false, true)
+	cfb.listSA.LeftScroll = ScrollBarLeft
+
+	cfb.Doc = widget.NewLabel(root.UI)
+	cfb.Doc.Pad.Left = 5
+	cfb.Doc.Pad.Right = 5
+	cfb.Doc.Border.Top = 1
 
-	// TODO: scrollarea
-	//scrollArea := widget.NewScrollArea(l.UI, cfb.Label)
-	//scrollArea.LeftScroll = ScrollbarLeft
-	//scrollArea.ScrollWidth = ScrollbarWidth
-	//border := widget.NewPad(root.UI, scrollArea)
-	//border.Set(1)
+	layout := &cfbLayout{cfb: cfb}
+	layout.Append(cfb.listSA, cfb.Doc)
 
-	container := WrapInBottomShadowOrNone(root.UI, cfb.Label)
+	border := widget.NewBorder(root.UI, layout)
+	border.SetAll(1)
+
+	container := WrapInBottomShadowOrNone(root.UI, border)
 	cfb.FloatBox = widget.NewFloatBox(root.MultiLayer, container)
+	cfb.FloatBox.Marks.Add(widget.MarkForceZeroBounds) // hidden
 
 	return cfb
 }
 
+//----------
+
+func (cfb *ContextFloatBox) Visible() bool {
+	return !cfb.Marks.HasAny(widget.MarkForceZeroBounds)
+}
+
+// Shows the box with its top left corner at p.
+func (cfb *ContextFloatBox) Show(p image.Point) {
+	cfb.RefPoint = p
+	cfb.Marks.Remove(widget.MarkForceZeroBounds)
+	cfb.MarkNeedsLayoutAndPaint()
+}
+
+func (cfb *ContextFloatBox) Hide() {
+	if !cfb.Visible() {
+		return
+	}
+	cfb.Marks.Add(widget.MarkForceZeroBounds)
+	cfb.MarkNeedsLayout()
+	cfb.root.BgLayer.RectNeedsPaint(cfb.Bounds)
+}
+
+//----------
+
+// One entry per line. Selects the first entry.
+func (cfb *ContextFloatBox) SetEntries(entries []string) {
+	cfb.lines = cfb.lines[:0]
+	k := 0
+	for _, e := range entries {
+		cfb.lines = append(cfb.lines, k)
+		k += len(e) + 1
+	}
+	cfb.List.SetStrClearHistory(strings.Join(entries, "\n"))
+	cfb.selected = -1
+	cfb.Select(0)
+	cfb.MarkNeedsLayoutAndPaint()
+}
+
+// Text without entries (ex: a signature), with the segment highlighted (if not empty).
+func (cfb *ContextFloatBox) SetText(str string, seg [2]int) {
+	cfb.lines = cfb.lines[:0]
+	cfb.selected = -1
+	cfb.List.SetStrClearHistory(str)
+	tc := cfb.List.TextCursor
+	if seg[1] > seg[0] {
+		tc.SetSelection(seg[0], seg[1])
+	} else {
+		tc.SetSelectionOff()
+	}
+	cfb.MarkNeedsLayoutAndPaint()
+}
+
+func (cfb *ContextFloatBox) Selected() int {
+	return cfb.selected
+}
+
+func (cfb *ContextFloatBox) Select(i int) {
+	if i < 0 || i >= len(cfb.lines) {
+		return
+	}
+	cfb.selected = i
+
+	// highlight the entry line
+	a := cfb.lines[i]
+	b := len(cfb.List.Str())
+	if i+1 < len(cfb.lines) {
+		b = cfb.lines[i+1] - 1
+	}
+	cfb.List.TextCursor.SetSelection(a, b)
+	cfb.List.MakeRangeVisible(a, b-a)
+}
+
+// Moves the selection by n entries (wraps around).
+func (cfb *ContextFloatBox) MoveSelection(n int) {
+	l := len(cfb.lines)
+	if l == 0 {
+		return
+	}
+	cfb.Select(((cfb.selected+n)%l + l) % l)
+}
+
+// Empty string hides the documentation.
+func (cfb *ContextFloatBox) SetDoc(s string) {
+	cfb.Doc.Text.SetStr(s)
+	cfb.MarkNeedsLayoutAndPaint()
+}
+
+//----------
+
 func (cfb *ContextFloatBox) OnInputEvent(ev interface{}, p image.Point) event.Handle {
-	switch ev.(type) {
+	switch t := ev.(type) {
 	case *event.KeyUp,
 		*event.KeyDown:
 		// let lower layers get events
 		return event.NotHandled
+	case *event.MouseClick:
+		if t.Button == event.ButtonLeft && p.In(cfb.List.Bounds) && len(cfb.lines) > 0 {
+			i := cfb.entryAt(cfb.List.GetIndex(p))
+			cfb.Select(i)
+			if cfb.OnClickEntry != nil {
+				cfb.OnClickEntry(i)
+			}
+		}
 	}
 	return event.Handled
 }
+
+func (cfb *ContextFloatBox) entryAt(index int) int {
+	for i := len(cfb.lines) - 1; i >= 0; i-- {
+		if index >= cfb.lines[i] {
+			return i
+		}
+	}
+	return 0
+}
+
+//----------
+
+const (
+	cfbListMaxLines = 10
+	cfbDocMaxLines  = 8
+	cfbMaxWidth     = 35 // in line heights
+)
+
+// List with a limited height, and the documentation below (if any).
+type cfbLayout struct {
+	widget.ENode
+	cfb     *ContextFloatBox
+	listDy  int
+	visible bool // documentation
+}
+
+func (l *cfbLayout) Measure(hint image.Point) image.Point {
+	l.cfb.listSA.ScrollWidth = UIThemeUtil.GetScrollBarWidth(l.cfb.List.TreeThemeFont())
+
+	lh := l.cfb.List.LineHeight()
+	w := hint.X
+	if u := cfbMaxWidth * lh; w > u {
+		w = u
+	}
+
+	h1 := image.Point{w, minInt(hint.Y, cfbListMaxLines*lh)}
+	m1 := l.cfb.listSA.Measure(h1)
+	l.listDy = m1.Y
+
+	m := m1
+	l.visible = l.cfb.Doc.Text.Str() != ""
+	if l.visible {
+		h2 := image.Point{w, minInt(hint.Y-m1.Y, cfbDocMaxLines*lh)}
+		m2 := l.cfb.Doc.Measure(h2)
+		if m2.X > m.X {
+			m.X = m2.X
+		}
+		m.Y += m2.Y
+	}
+	return m
+}
+
+func (l *cfbLayout) Layout() {
+	b := l.Bounds
+	r1 := b
+	r1.Max.Y = minInt(b.Max.Y, b.Min.Y+l.listDy)
+	l.cfb.listSA.Embed().Bounds = r1
+
+	r2 := b
+	r2.Min.Y = r1.Max.Y
+	if !l.visible {
+		r2.Max.Y = r2.Min.Y
+	}
+	l.cfb.Doc.Bounds = r2
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
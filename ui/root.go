@@ -26,9 +26,8 @@ func (root *Root) Init() {
 	bgLayer.YAxis = true
 	root.BgLayer.Append(bgLayer)
 
-	// context floatbox layer
-	//root.ContextFloatBox = NewContextFloatBox(root)
-	//root.FloatLayer.Append(root.ContextFloatBox)
+	// context floatbox (float layer, hidden)
+	root.ContextFloatBox = NewContextFloatBox(root)
 
 	// background layer
 	{
@@ -1,9 +1,12 @@
 package ui
 
 import (
+	"fmt"
 	"image"
+	"strings"
 
 	"github.com/jmigpin/editor/util/evreg"
+	"github.com/jmigpin/editor/util/iout"
 	"github.com/jmigpin/editor/util/uiutil/event"
 	"github.com/jmigpin/editor/util/uiutil/widget"
 )
@@ -44,6 +47,7 @@ func NewRow(col *Column) *Row {
 		row.TextArea.EnableHighlightCursorWord(true)
 		row.TextArea.EnableColorizeSyntax(true)
 		row.TextArea.EnableParenthesisMatch(true)
+		row.TextArea.OnBracketHint = row.showBracketHint
 
 		row.ScrollArea = widget.NewScrollArea(row.ui, row.TextArea, false, true)
 		row.ScrollArea.LeftScroll = ScrollBarLeft
@@ -67,6 +71,45 @@ func (row *Row) Close() {
 
 //----------
 
+// Shows the line of the matching bracket in the toolbar when it is off screen.
+func (row *Row) showBracketHint(index int) {
+	if index < 0 {
+		row.Toolbar.SetHint("")
+		return
+	}
+	rw := row.TextArea.TextCursor.RW()
+	if index >= rw.Len() {
+		row.Toolbar.SetHint("")
+		return
+	}
+	line, err := iout.LineNumber(rw, index)
+	if err != nil {
+		row.Toolbar.SetHint("")
+		return
+	}
+	// long lines: limited to the content around the bracket
+	a, err := iout.LineStartIndex(rw, index)
+	if err != nil {
+		a = index
+	}
+	e, _, err := iout.LineEndIndex(rw, index)
+	if err != nil {
+		e = index + 1
+	}
+	b, err := rw.ReadNAt(a, e-a)
+	if err != nil {
+		row.Toolbar.SetHint("")
+		return
+	}
+	s := strings.TrimSpace(string(b))
+	if r := []rune(s); len(r) > 60 {
+		s = string(r[:60]) + "..."
+	}
+	row.Toolbar.SetHint(fmt.Sprintf("%v: %v", line, s))
+}
+
+//----------
+
 func (row *Row) activate() {
 	if row.HasState(RowStateActive) {
 		return
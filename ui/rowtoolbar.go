@@ -32,6 +32,26 @@ func (tb *RowToolbar) Layout() {
 	tb.Toolbar.Layout()
 }
 
+// Shows a hint at the end of the first toolbar line (ex: matching bracket line). Empty string clears.
+func (tb *RowToolbar) SetHint(s string) {
+	d, ok := tb.Drawer.(*drawer3.PosDrawer)
+	if !ok {
+		return
+	}
+	if s == "" {
+		d.Annotations.SetOn(false)
+		d.Annotations.Opt.Entries = nil
+	} else {
+		d.Annotations.SetOn(true)
+		d.Annotations.Opt.Select.Line = -1
+		d.Annotations.Opt.Entries = []*drawer3.Annotation{{Offset: 0, Bytes: []byte(s)}}
+	}
+	d.SetNeedMeasure(true)
+	tb.MarkNeedsLayoutAndPaint()
+}
+
+//----------
+
 func (tb *RowToolbar) OnThemeChange() {
 	tb.Toolbar.OnThemeChange()
 	tb.Square.Size = UIThemeUtil.RowSquareSize(tb.TreeThemeFont())
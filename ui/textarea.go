@@ -24,6 +24,7 @@ func NewTextArea(ui *UI) *TextArea {
 	ta.TextEditInputHandler = textutil.NewTextEditInputHandler(ta.TextEditX)
 
 	ta.OnSetStr = ta.onSetStr
+	ta.OnSetOffset = ta.onSetOffset
 	ta.EvReg = evreg.NewRegister()
 
 	return ta
@@ -36,6 +37,11 @@ func (ta *TextArea) onSetStr() {
 	ta.EvReg.RunCallbacks(TextAreaSetStrEventId, ev)
 }
 
+func (ta *TextArea) onSetOffset() {
+	ev := &TextAreaSetOffsetEvent{ta}
+	ta.EvReg.RunCallbacks(TextAreaSetOffsetEventId, ev)
+}
+
 //----------
 
 func (ta *TextArea) OnInputEvent(ev0 interface{}, p image.Point) event.Handle {
@@ -108,6 +114,7 @@ const (
 	TextAreaSetStrEventId = iota
 	TextAreaCmdEventId
 	TextAreaAnnotationClickEventId
+	TextAreaSetOffsetEventId
 )
 
 type TextAreaCmdEvent struct {
@@ -117,6 +124,9 @@ type TextAreaCmdEvent struct {
 type TextAreaSetStrEvent struct {
 	TextArea *TextArea
 }
+type TextAreaSetOffsetEvent struct {
+	TextArea *TextArea
+}
 type TextAreaAnnotationClickEvent struct {
 	TextArea        *TextArea
 	AnnotationIndex int
@@ -41,19 +41,37 @@ func userPalette() widget.Palette {
 
 func lightThemeColors(node widget.Node) {
 	pal := widget.Palette{
-		"text_cursor_fg":            cint(0x0),
-		"text_fg":                   cint(0x0),
-		"text_bg":                   cint(0xffffff),
-		"text_selection_fg":         nil,
-		"text_selection_bg":         cint(0xeeee9e), // yellow
-		"text_colorize_string_fg":   nil,
-		"text_colorize_comments_fg": cint(0x757575), // grey 600
-		"text_highlightword_fg":     nil,
-		"text_highlightword_bg":     cint(0xc6ee9e), // green
-		"text_wrapline_fg":          cint(0x0),
-		"text_wrapline_bg":          cint(0xd8d8d8),
-		"text_parenthesis_fg":       nil,
-		"text_parenthesis_bg":       cint(0xd8d8d8),
+		"text_cursor_fg":              cint(0x0),
+		"text_fg":                     cint(0x0),
+		"text_bg":                     cint(0xffffff),
+		"text_selection_fg":           nil,
+		"text_selection_bg":           cint(0xeeee9e), // yellow
+		"text_colorize_string_fg":     nil,
+		"text_colorize_comments_fg":   cint(0x757575), // grey 600
+		"text_colorize_keyword_fg":    cint(0x0d47a1), // blue 900
+		"text_colorize_type_fg":       cint(0x00695c), // teal 800
+		"text_colorize_builtin_fg":    cint(0x6a1b9a), // purple 800
+		"text_colorize_number_fg":     cint(0xbf360c), // deep orange 900
+		"text_colorize_key_fg":        cint(0x0d47a1), // blue 900
+		"text_colorize_heading_fg":    cint(0x0d47a1), // blue 900
+		"text_colorize_local_fg":      nil,
+		"text_colorize_param_fg":      cint(0x795548), // brown
+		"text_colorize_var_fg":        cint(0x283593), // indigo 800
+		"text_colorize_func_fg":       cint(0xad1457), // pink 800
+		"text_colorize_const_fg":      cint(0xbf360c), // deep orange 900
+		"text_colorize_field_fg":      cint(0x455a64), // blue grey 700
+		"text_colorize_unresolved_fg": nil,
+		"text_colorize_unresolved_bg": cint(0xffcdd2), // red 100
+		"text_highlightword_fg":       nil,
+		"text_highlightword_bg":       cint(0xc6ee9e), // green
+		"text_wrapline_fg":            cint(0x0),
+		"text_wrapline_bg":            cint(0xd8d8d8),
+		"text_fold_fg":                cint(0x0),
+		"text_fold_bg":                cint(0xd8d8d8),
+		"text_parenthesis_fg":         nil,
+		"text_parenthesis_bg":         cint(0xd8d8d8),
+		"text_diffinsert_bg":          cint(0xd4f4d4), // green
+		"text_diffdelete_bg":          cint(0xf8d4d4), // red
 
 		"toolbar_text_bg":          cint(0xecf0f1), // "clouds" grey
 		"toolbar_text_wrapline_bg": cint(0xccccd8),
@@ -91,16 +109,34 @@ func lightThemeColors(node widget.Node) {
 
 func darkThemeColors(node widget.Node) {
 	pal := widget.Palette{
-		"text_cursor_fg":            cint(0xffffff),
-		"text_fg":                   cint(0xffffff),
-		"text_bg":                   cint(0x0),
-		"text_selection_fg":         cint(0xffffff),
-		"text_selection_bg":         cint(0xafa753), // yellow
-		"text_colorize_string_fg":   nil,
-		"text_colorize_comments_fg": cint(0xb8b8b8),
-		"text_highlightword_bg":     cint(0x58842d), // green
-		"text_wrapline_fg":          cint(0xffffff),
-		"text_wrapline_bg":          cint(0x595959),
+		"text_cursor_fg":              cint(0xffffff),
+		"text_fg":                     cint(0xffffff),
+		"text_bg":                     cint(0x0),
+		"text_selection_fg":           cint(0xffffff),
+		"text_selection_bg":           cint(0xafa753), // yellow
+		"text_colorize_string_fg":     nil,
+		"text_colorize_comments_fg":   cint(0xb8b8b8),
+		"text_colorize_keyword_fg":    cint(0x90caf9), // blue 200
+		"text_colorize_type_fg":       cint(0x80cbc4), // teal 200
+		"text_colorize_builtin_fg":    cint(0xce93d8), // purple 200
+		"text_colorize_number_fg":     cint(0xffab91), // deep orange 200
+		"text_colorize_key_fg":        cint(0x90caf9), // blue 200
+		"text_colorize_heading_fg":    cint(0x90caf9), // blue 200
+		"text_colorize_local_fg":      nil,
+		"text_colorize_param_fg":      cint(0xbcaaa4), // brown 200
+		"text_colorize_var_fg":        cint(0x9fa8da), // indigo 200
+		"text_colorize_func_fg":       cint(0xf48fb1), // pink 200
+		"text_colorize_const_fg":      cint(0xffab91), // deep orange 200
+		"text_colorize_field_fg":      cint(0xb0bec5), // blue grey 200
+		"text_colorize_unresolved_fg": nil,
+		"text_colorize_unresolved_bg": cint(0x8b2b2b), // red
+		"text_highlightword_bg":       cint(0x58842d), // green
+		"text_wrapline_fg":            cint(0xffffff),
+		"text_wrapline_bg":            cint(0x595959),
+		"text_fold_fg":                cint(0xffffff),
+		"text_fold_bg":                cint(0x595959),
+		"text_diffinsert_bg":          cint(0x2d5a2d), // green
+		"text_diffdelete_bg":          cint(0x6b2d2d), // red
 
 		"toolbar_text_fg":          cint(0xffffff),
 		"toolbar_text_bg":          cint(0x808080),
@@ -138,17 +174,35 @@ func darkThemeColors(node widget.Node) {
 
 func acmeThemeColors(node widget.Node) {
 	pal := widget.Palette{
-		"text_cursor_fg":            cint(0x0),
-		"text_fg":                   cint(0x0),
-		"text_bg":                   cint(0xffffea),
-		"text_selection_fg":         nil,
-		"text_selection_bg":         cint(0xeeee9e), // yellow
-		"text_colorize_string_fg":   nil,
-		"text_colorize_comments_fg": cint(0x757575), // grey 600
-		"text_highlightword_fg":     nil,
-		"text_highlightword_bg":     cint(0xc6ee9e), // green
-		"text_wrapline_fg":          cint(0x0),
-		"text_wrapline_bg":          cint(0xd8d8c6),
+		"text_cursor_fg":              cint(0x0),
+		"text_fg":                     cint(0x0),
+		"text_bg":                     cint(0xffffea),
+		"text_selection_fg":           nil,
+		"text_selection_bg":           cint(0xeeee9e), // yellow
+		"text_colorize_string_fg":     nil,
+		"text_colorize_comments_fg":   cint(0x757575), // grey 600
+		"text_colorize_keyword_fg":    cint(0x000099), // acme blue
+		"text_colorize_type_fg":       cint(0x006666),
+		"text_colorize_builtin_fg":    cint(0x660066),
+		"text_colorize_number_fg":     cint(0x993300),
+		"text_colorize_key_fg":        cint(0x000099),
+		"text_colorize_heading_fg":    cint(0x000099),
+		"text_colorize_local_fg":      nil,
+		"text_colorize_param_fg":      cint(0x663300),
+		"text_colorize_var_fg":        cint(0x000066),
+		"text_colorize_func_fg":       cint(0x990066),
+		"text_colorize_const_fg":      cint(0x993300),
+		"text_colorize_field_fg":      cint(0x333333),
+		"text_colorize_unresolved_fg": nil,
+		"text_colorize_unresolved_bg": cint(0xffcccc),
+		"text_highlightword_fg":       nil,
+		"text_highlightword_bg":       cint(0xc6ee9e), // green
+		"text_wrapline_fg":            cint(0x0),
+		"text_wrapline_bg":            cint(0xd8d8c6),
+		"text_fold_fg":                cint(0x0),
+		"text_fold_bg":                cint(0xd8d8c6),
+		"text_diffinsert_bg":          cint(0xd6eed6), // green
+		"text_diffdelete_bg":          cint(0xeed6d6), // red
 
 		"toolbar_text_bg":          cint(0xeaffff),
 		"toolbar_text_wrapline_bg": cint(0xc6d8d8),
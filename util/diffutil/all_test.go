@@ -0,0 +1,206 @@
+package diffutil
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestMyers1(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for k := 0; k < 500; k++ {
+		a := randSeq(rnd, rnd.Intn(20))
+		b := randSeq(rnd, rnd.Intn(20))
+		testMyers(t, a, b)
+	}
+}
+
+func TestMyers2(t *testing.T) {
+	// bigger sequences (recursion on the middle snakes)
+	rnd := rand.New(rand.NewSource(2))
+	for k := 0; k < 50; k++ {
+		a := randSeq(rnd, rnd.Intn(300))
+		b := randSeq(rnd, rnd.Intn(300))
+		testMyers(t, a, b)
+	}
+}
+
+func testMyers(t *testing.T, a, b []byte) {
+	t.Helper()
+	ops := Myers(len(a), len(b), func(i, j int) bool { return a[i] == b[j] })
+
+	// rebuild b from a
+	w := []byte{}
+	d := 0
+	for _, o := range ops {
+		switch o.Type {
+		case Equal:
+			if !bytes.Equal(a[o.A:o.A+o.N], b[o.B:o.B+o.N]) {
+				t.Fatalf("%q %q: bad equal op %+v", a, b, o)
+			}
+			w = append(w, a[o.A:o.A+o.N]...)
+		case Insert:
+			w = append(w, b[o.B:o.B+o.N]...)
+			d += o.N
+		case Delete:
+			d += o.N
+		}
+	}
+	if !bytes.Equal(w, b) {
+		t.Fatalf("%q %q: rebuilt %q", a, b, w)
+	}
+
+	// shortest edit script
+	if e := len(a) + len(b) - 2*lcs(a, b); d != e {
+		t.Fatalf("%q %q: edits %v, expected %v", a, b, d, e)
+	}
+}
+
+func randSeq(rnd *rand.Rand, n int) []byte {
+	w := make([]byte, n)
+	for i := range w {
+		w[i] = "abc"[rnd.Intn(3)]
+	}
+	return w
+}
+
+func lcs(a, b []byte) int {
+	t := make([][]int, len(a)+1)
+	for i := range t {
+		t[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				t[i][j] = t[i+1][j+1] + 1
+			} else if t[i+1][j] > t[i][j+1] {
+				t[i][j] = t[i+1][j]
+			} else {
+				t[i][j] = t[i][j+1]
+			}
+		}
+	}
+	return t[0][0]
+}
+
+func TestLineChanges1(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n"
+	b := "0\n1\n2x\n3\n5\n"
+	cs := LineChanges([]byte(a), []byte(b))
+	u := []string{}
+	for _, c := range cs {
+		u = append(u, fmt.Sprintf("%v,%v,%v,%v", c.Pos, c.End, c.Inserted, c.Deleted))
+	}
+	r := strings.Join(u, " ")
+	e := "0,2,true,false 4,7,true,true 9,9,false,true"
+	if r != e {
+		t.Fatal(r)
+	}
+}
+
+//----------
+
+func TestUnified1(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "1\n2\n3\n4x\n5\n6\n7\n8\n9\n10\n12\n13"
+	e := `--- a
++++ b
+@@ -1,12 +1,12 @@
+ 1
+ 2
+ 3
+-4
++4x
+ 5
+ 6
+ 7
+ 8
+ 9
+ 10
+-11
+ 12
++13
+\ No newline at end of file
+`
+	buf := &bytes.Buffer{}
+	if err := WriteUnified(buf, "a", "b", []byte(a), []byte(b), 3); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != e {
+		t.Fatalf("\n%s", buf.String())
+	}
+}
+
+func TestUnified2(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	b := "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n"
+	e := `--- a
++++ b
+@@ -1,2 +1,3 @@
++0
+ 1
+ 2
+@@ -8,3 +9,2 @@
+ 8
+ 9
+-10
+`
+	buf := &bytes.Buffer{}
+	if err := WriteUnified(buf, "a", "b", []byte(a), []byte(b), 2); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != e {
+		t.Fatalf("\n%s", buf.String())
+	}
+}
+
+func TestUnified3(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := WriteUnified(buf, "a", "b", []byte("abc\n"), []byte("abc\n"), 3); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Fatal(buf.String())
+	}
+}
+
+//----------
+
+func TestMerge1(t *testing.T) {
+	base := "1\n2\n3\n4\n5\n6\n7\n"
+	a := "1\n2a\n3\n4\n5\n6\n7\n"
+	b := "1\n2\n3\n4\n5\n6b\n7\n8\n"
+	m, n := Merge3([]byte(base), []byte(a), []byte(b), "a", "b")
+	if n != 0 || string(m) != "1\n2a\n3\n4\n5\n6b\n7\n8\n" {
+		t.Fatalf("%v\n%s", n, m)
+	}
+}
+
+func TestMerge2(t *testing.T) {
+	base := "1\n2\n3\n"
+	a := "1\n2a\n3\n"
+	b := "1\n2b\n3" // last line also changed (no newline)
+	e := "1\n<<<<<<< a\n2a\n3\n=======\n2b\n3\n>>>>>>> b\n"
+	m, n := Merge3([]byte(base), []byte(a), []byte(b), "a", "b")
+	if n != 1 || string(m) != e {
+		t.Fatalf("%v\n%s", n, m)
+	}
+}
+
+func TestMerge3(t *testing.T) {
+	// same change on both sides, and a delete
+	base := "1\n2\n3\n4\n"
+	a := "0\n1\n2x\n3\n4\n"
+	b := "1\n2x\n4\n"
+	m, n := Merge3([]byte(base), []byte(a), []byte(b), "a", "b")
+	if n != 1 {
+		t.Fatalf("%v\n%s", n, m)
+	}
+	// changes touch: "2x" (both) and the delete of "3" (b) are grouped
+	e := "0\n1\n<<<<<<< a\n2x\n3\n=======\n2x\n>>>>>>> b\n4\n"
+	if string(m) != e {
+		t.Fatalf("\n%s", m)
+	}
+}
@@ -0,0 +1,229 @@
+package diffutil
+
+import (
+	"bytes"
+)
+
+type OpType int
+
+const (
+	Equal OpType = iota
+	Insert
+	Delete
+)
+
+func (t OpType) String() string {
+	switch t {
+	case Insert:
+		return "insert"
+	case Delete:
+		return "delete"
+	default:
+		return "equal"
+	}
+}
+
+// N elements starting at A (first sequence) and at B (second sequence). Inserts have the elements in B, deletes in A.
+type Op struct {
+	Type OpType
+	A, B int
+	N    int
+}
+
+//----------
+
+// Myers diff algorithm (O((n+m)d) time, O(n+m) space). The equal func compares the i-th element of the first sequence with the j-th element of the second.
+func Myers(n, m int, equal func(i, j int) bool) []*Op {
+	ops := &opsBuilder{}
+	myers2(ops, 0, n, 0, m, equal)
+	return ops.ops
+}
+
+// Linear space refinement: finds the middle snake of the shortest edit script and recurses on both sides of it.
+func myers2(ops *opsBuilder, a0, a1, b0, b1 int, equal func(i, j int) bool) {
+	// common prefix
+	p := 0
+	for a0+p < a1 && b0+p < b1 && equal(a0+p, b0+p) {
+		p++
+	}
+	ops.add(Equal, a0, b0, p)
+	a0, b0 = a0+p, b0+p
+	// common suffix
+	s := 0
+	for a0 < a1-s && b0 < b1-s && equal(a1-1-s, b1-1-s) {
+		s++
+	}
+	a1, b1 = a1-s, b1-s
+
+	n, m := a1-a0, b1-b0
+	if n == 0 || m == 0 {
+		ops.add(Delete, a0, b0, n)
+		ops.add(Insert, a0+n, b0, m)
+	} else {
+		// without a common prefix/suffix the edit script has at least 2 edits, and both sides have less edits
+		x, y, u, v := middleSnake(a0, a1, b0, b1, equal)
+		myers2(ops, a0, a0+x, b0, b0+y, equal)
+		ops.add(Equal, a0+x, b0+y, u-x)
+		myers2(ops, a0+u, a1, b0+v, b1, equal)
+	}
+
+	ops.add(Equal, a1, b1, s)
+}
+
+// Returns the snake (x,y)->(u,v) (relative to a0/b0) in the middle of a shortest edit script. Searches forward from the start and backward from the end until the paths overlap.
+func middleSnake(a0, a1, b0, b1 int, equal func(i, j int) bool) (x, y, u, v int) {
+	n, m := a1-a0, b1-b0
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	off := maxD + 1
+	vf := make([]int, 2*maxD+3) // furthest x in forward diagonal k (x-y=k)
+	vb := make([]int, 2*maxD+3) // furthest x in backward diagonal k (reversed sequences)
+
+	for d := 0; d <= maxD; d++ {
+		// forward
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && vf[off+k-1] < vf[off+k+1]) {
+				x = vf[off+k+1] // down (insert)
+			} else {
+				x = vf[off+k-1] + 1 // right (delete)
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && equal(a0+x, b0+y) {
+				x++
+				y++
+			}
+			vf[off+k] = x
+			// backward diagonal delta-k was reached in step d-1
+			if kb := delta - k; odd && kb >= -(d-1) && kb <= d-1 {
+				if x+vb[off+kb] >= n {
+					return x0, y0, x, y
+				}
+			}
+		}
+		// backward
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && vb[off+k-1] < vb[off+k+1]) {
+				x = vb[off+k+1]
+			} else {
+				x = vb[off+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && equal(a1-1-x, b1-1-y) {
+				x++
+				y++
+			}
+			vb[off+k] = x
+			// forward diagonal delta-k was reached in step d
+			if kf := delta - k; !odd && kf >= -d && kf <= d {
+				if x+vf[off+kf] >= n {
+					return n - x, m - y, n - x0, m - y0
+				}
+			}
+		}
+	}
+	panic("middle snake not found")
+}
+
+//----------
+
+type opsBuilder struct {
+	ops []*Op
+}
+
+// Merges with the previous op if consecutive and of the same type.
+func (ob *opsBuilder) add(t OpType, a, b, n int) {
+	if n == 0 {
+		return
+	}
+	if l := len(ob.ops); l > 0 {
+		o := ob.ops[l-1]
+		if o.Type == t {
+			switch t {
+			case Equal:
+				if o.A+o.N == a && o.B+o.N == b {
+					o.N += n
+					return
+				}
+			case Insert:
+				if o.B+o.N == b {
+					o.N += n
+					return
+				}
+			case Delete:
+				if o.A+o.N == a {
+					o.N += n
+					return
+				}
+			}
+		}
+	}
+	ob.ops = append(ob.ops, &Op{t, a, b, n})
+}
+
+//----------
+
+// Splits into lines, keeping the newline.
+func SplitLines(b []byte) [][]byte {
+	w := [][]byte{}
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			w = append(w, b)
+			break
+		}
+		w = append(w, b[:i+1])
+		b = b[i+1:]
+	}
+	return w
+}
+
+// Line diff of a and b.
+func Lines(a, b [][]byte) []*Op {
+	return Myers(len(a), len(b), func(i, j int) bool {
+		return bytes.Equal(a[i], b[j])
+	})
+}
+
+//----------
+
+// Changed lines of b relative to a, with byte offsets in b. Modified lines have both inserted and deleted set. Deleted lines have an empty range at the start of the line that follows them in b.
+type LineChange struct {
+	Pos, End          int
+	Inserted, Deleted bool
+}
+
+func LineChanges(a, b []byte) []*LineChange {
+	la, lb := SplitLines(a), SplitLines(b)
+
+	// line start offsets in b
+	ob := make([]int, len(lb)+1)
+	for i, l := range lb {
+		ob[i+1] = ob[i] + len(l)
+	}
+
+	w := []*LineChange{}
+	var c *LineChange
+	for _, o := range Lines(la, lb) {
+		if o.Type == Equal {
+			c = nil
+			continue
+		}
+		if c == nil {
+			c = &LineChange{Pos: ob[o.B], End: ob[o.B]}
+			w = append(w, c)
+		}
+		switch o.Type {
+		case Delete:
+			c.Deleted = true
+		case Insert:
+			c.Inserted = true
+			c.End = ob[o.B+o.N]
+		}
+	}
+	return w
+}
@@ -0,0 +1,143 @@
+package diffutil
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Line based three-way merge of the changes from base to a, and from base to b. Changes that overlap (or touch) and are not equal are kept in conflict sections with markers ("<<<<<<< nameA", "=======", ">>>>>>> nameB").
+func Merge3(base, a, b []byte, nameA, nameB string) (_ []byte, conflicts int) {
+	lo := SplitLines(base)
+	la, lb := SplitLines(a), SplitLines(b)
+
+	chs := []*change{}
+	chs = append(chs, changes(Lines(lo, la), 0)...)
+	chs = append(chs, changes(Lines(lo, lb), 1)...)
+	sort.SliceStable(chs, func(i, j int) bool {
+		return chs[i].o0 < chs[j].o0
+	})
+
+	buf := &bytes.Buffer{}
+	side := [2][][]byte{la, lb}
+	k := 0 // base line
+	for i := 0; i < len(chs); {
+		// group changes that overlap in the base
+		o0, o1 := chs[i].o0, chs[i].o1
+		j := i + 1
+		for ; j < len(chs) && chs[j].o0 <= o1; j++ {
+			if chs[j].o1 > o1 {
+				o1 = chs[j].o1
+			}
+		}
+		group := chs[i:j]
+		i = j
+
+		// unchanged base lines
+		writeLines(buf, lo[k:o0])
+		k = o1
+
+		// content of each side for the base range
+		has := [2]bool{}
+		content := [2][][]byte{}
+		for s := 0; s < 2; s++ {
+			var first, last *change
+			for _, c := range group {
+				if c.side == s {
+					if first == nil {
+						first = c
+					}
+					last = c
+				}
+			}
+			if first == nil {
+				content[s] = lo[o0:o1]
+				continue
+			}
+			has[s] = true
+			s0 := first.s0 - (first.o0 - o0)
+			s1 := last.s1 + (o1 - last.o1)
+			content[s] = side[s][s0:s1]
+		}
+
+		switch {
+		case !has[1]:
+			writeLines(buf, content[0])
+		case !has[0]:
+			writeLines(buf, content[1])
+		case equalLines(content[0], content[1]):
+			writeLines(buf, content[0])
+		default:
+			conflicts++
+			buf.WriteString("<<<<<<< " + nameA + "\n")
+			writeLinesNl(buf, content[0])
+			buf.WriteString("=======\n")
+			writeLinesNl(buf, content[1])
+			buf.WriteString(">>>>>>> " + nameB + "\n")
+		}
+	}
+	writeLines(buf, lo[k:])
+
+	return buf.Bytes(), conflicts
+}
+
+//----------
+
+// Base lines [o0,o1) replaced by side lines [s0,s1).
+type change struct {
+	side   int
+	o0, o1 int
+	s0, s1 int
+}
+
+func changes(ops []*Op, side int) []*change {
+	chs := []*change{}
+	var c *change
+	for _, o := range ops {
+		if o.Type == Equal {
+			c = nil
+			continue
+		}
+		if c == nil {
+			c = &change{side: side, o0: o.A, o1: o.A, s0: o.B, s1: o.B}
+			chs = append(chs, c)
+		}
+		switch o.Type {
+		case Delete:
+			c.o1 = o.A + o.N
+		case Insert:
+			c.s1 = o.B + o.N
+		}
+	}
+	return chs
+}
+
+//----------
+
+func writeLines(buf *bytes.Buffer, lines [][]byte) {
+	for _, l := range lines {
+		buf.Write(l)
+	}
+}
+
+// Ensures a newline at the end to keep the markers in their own lines.
+func writeLinesNl(buf *bytes.Buffer, lines [][]byte) {
+	writeLines(buf, lines)
+	if n := len(lines); n > 0 {
+		l := lines[n-1]
+		if len(l) == 0 || l[len(l)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+}
+
+func equalLines(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+package diffutil
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Writes a unified diff (same format as "diff -u") with n lines of context. Writes nothing if the contents are equal.
+func WriteUnified(w io.Writer, nameA, nameB string, a, b []byte, n int) error {
+	la, lb := SplitLines(a), SplitLines(b)
+	ops := Lines(la, lb)
+
+	bw := bufio.NewWriter(w)
+	header := false
+
+	for i := 0; i < len(ops); {
+		// next change
+		for i < len(ops) && ops[i].Type == Equal {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		// extend the hunk while the equal lines between changes fit in the context
+		j := i
+		for j < len(ops) {
+			o := ops[j]
+			if o.Type != Equal || (o.N <= 2*n && j+1 < len(ops)) {
+				j++
+				continue
+			}
+			break
+		}
+
+		h := &hunk{}
+
+		// leading context
+		if i > 0 {
+			o := ops[i-1]
+			c := minInt(n, o.N)
+			h.a, h.b = o.A+o.N-c, o.B+o.N-c
+			h.add(' ', la[h.a:h.a+c])
+		} else {
+			h.a, h.b = ops[i].A, ops[i].B
+		}
+		for _, o := range ops[i:j] {
+			switch o.Type {
+			case Equal:
+				h.add(' ', la[o.A:o.A+o.N])
+			case Delete:
+				h.add('-', la[o.A:o.A+o.N])
+			case Insert:
+				h.add('+', lb[o.B:o.B+o.N])
+			}
+		}
+		// trailing context
+		if j < len(ops) {
+			o := ops[j]
+			h.add(' ', la[o.A:o.A+minInt(n, o.N)])
+		}
+
+		if !header {
+			header = true
+			fmt.Fprintf(bw, "--- %s\n+++ %s\n", nameA, nameB)
+		}
+		h.write(bw)
+
+		i = j
+	}
+
+	return bw.Flush()
+}
+
+//----------
+
+type hunk struct {
+	a, b   int // start lines
+	na, nb int // number of lines
+	lines  []hunkLine
+}
+
+type hunkLine struct {
+	c    byte
+	line []byte
+}
+
+func (h *hunk) add(c byte, lines [][]byte) {
+	for _, l := range lines {
+		h.lines = append(h.lines, hunkLine{c, l})
+		if c != '+' {
+			h.na++
+		}
+		if c != '-' {
+			h.nb++
+		}
+	}
+}
+
+func (h *hunk) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "@@ -%s +%s @@\n", hunkRange(h.a, h.na), hunkRange(h.b, h.nb))
+	for _, hl := range h.lines {
+		w.WriteByte(hl.c)
+		w.Write(hl.line)
+		if len(hl.line) == 0 || hl.line[len(hl.line)-1] != '\n' {
+			w.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func hunkRange(start, n int) string {
+	switch n {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, n)
+	}
+}
+
+//----------
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,181 @@
+package drawer3
+
+import (
+	"strings"
+)
+
+const brackets = "{}()[]"
+
+// Finds the bracket matching the one at index. Brackets inside strings and comments (colorize syntax state) are ignored. Searches from index (backward for closing brackets), restoring position data close to where the scan starts. The last result is cached until the next measure.
+func (d *PosDrawer) MatchingBracket(index int) (int, bool) {
+	if !d.ready() {
+		return 0, false
+	}
+	if c := d.bracketc; c != nil && c.index == index && !d.needMeasure {
+		return c.match, c.ok
+	}
+	match, ok := d.matchingBracket(index)
+	d.bracketc = &bracketCache{index, match, ok}
+	return match, ok
+}
+
+func (d *PosDrawer) matchingBracket(index int) (int, bool) {
+	ru, _, err := d.reader.ReadRuneAt(index)
+	if err != nil {
+		return 0, false
+	}
+	k := strings.IndexRune(brackets, ru)
+	if k < 0 {
+		return 0, false
+	}
+	if k%2 == 0 {
+		return d.matchingClose(index, ru, rune(brackets[k+1]))
+	}
+	return d.matchingOpen(index, rune(brackets[k-1]), ru)
+}
+
+func (d *PosDrawer) matchingClose(index int, open, close rune) (int, bool) {
+	c := 0 // open count, includes the bracket at index
+	match, found := 0, false
+	d.scanBrackets(index, func(ri int, ru rune, code bool) bool {
+		if ri < index {
+			return true
+		}
+		if !code {
+			// bracket at index is inside a string or comment
+			return ri != index
+		}
+		switch ru {
+		case open:
+			c++
+		case close:
+			c--
+			if c == 0 {
+				match, found = ri, true
+				return false
+			}
+		}
+		return true
+	})
+	return match, found
+}
+
+func (d *PosDrawer) matchingOpen(index int, open, close rune) (int, bool) {
+	c := 0 // close count
+	match, found := 0, false
+	first := true
+	d.scanBracketsBackward(index+1, func(ri int, ru rune) bool {
+		if first {
+			first = false
+			// bracket at index is inside a string or comment
+			return ri == index
+		}
+		switch ru {
+		case close:
+			c++
+		case open:
+			if c == 0 {
+				match, found = ri, true
+				return false
+			}
+			c--
+		}
+		return true
+	})
+	return match, found
+}
+
+type bracketCache struct {
+	index, match int
+	ok           bool
+}
+
+//----------
+
+// Calls fn with the open brackets that enclose the index, from the innermost to the outermost. Brackets are matched by type (closing brackets without a matching open bracket are ignored). The backward scan stops when fn returns false.
+func (d *PosDrawer) EnclosingOpenBrackets(index int, fn func(open int) bool) {
+	if !d.ready() {
+		return
+	}
+	closes := map[rune]int{} // close count by open bracket
+	d.scanBracketsBackward(index, func(ri int, ru rune) bool {
+		k := strings.IndexRune(brackets, ru)
+		if k%2 == 1 {
+			closes[rune(brackets[k-1])]++
+			return true
+		}
+		if closes[ru] > 0 {
+			closes[ru]--
+			return true
+		}
+		return fn(ri)
+	})
+}
+
+//----------
+
+// Calls fn for each content rune starting close to index (restores position data). The code argument is false if the rune is inside a string or comment. Stops when fn returns false.
+func (d *PosDrawer) scanBrackets(index int, fn func(ri int, ru rune, code bool) bool) {
+	bs := bracketScan{cs: &d.ColorizeSyntax, fn: fn}
+	exts := []Ext{&d.rr, &d.ColorizeSyntax, &bs}
+
+	postStart := func() {
+		if index > 0 {
+			d.pd.RestoreCloseToIndex(index) // setups keepers exts
+		}
+	}
+
+	RunExts(d, &d.rr, exts, postStart)
+}
+
+// Calls fn for the brackets outside strings and comments before index, from the closest to the farthest. Content is scanned forward in blocks of growing size (restoring position data close to the block start), going backward from index. Stops when fn returns false.
+func (d *PosDrawer) scanBracketsBackward(index int, fn func(ri int, ru rune) bool) {
+	type bracket struct {
+		ri int
+		ru rune
+	}
+	size := 4 * 1024
+	for b := index; b > 0; size *= 2 {
+		a := b - size
+		if a < 0 {
+			a = 0
+		}
+		w := []bracket{}
+		d.scanBrackets(a, func(ri int, ru rune, code bool) bool {
+			if ri >= b {
+				return false
+			}
+			if ri >= a && code && strings.ContainsRune(brackets, ru) {
+				w = append(w, bracket{ri, ru})
+			}
+			return true
+		})
+		for i := len(w) - 1; i >= 0; i-- {
+			if !fn(w[i].ri, w[i].ru) {
+				return
+			}
+		}
+		b = a
+	}
+}
+
+//----------
+
+type bracketScan struct {
+	EExt
+	cs *ColorizeSyntax
+	fn func(ri int, ru rune, code bool) bool
+}
+
+func (bs *bracketScan) Iterate(r *ExtRunner) {
+	if r.RR.RiClone() || r.RR.Ru == 0 {
+		r.NextExt()
+		return
+	}
+	code := !bs.cs.On() || bs.cs.code()
+	if !bs.fn(r.RR.Ri, r.RR.Ru, code) {
+		r.Stop()
+		return
+	}
+	r.NextExt()
+}
@@ -5,6 +5,7 @@ import (
 	"unicode"
 
 	"github.com/jmigpin/editor/util/iout"
+	"github.com/jmigpin/editor/util/syntaxutil"
 )
 
 type ColorizeSyntax struct {
@@ -17,6 +18,14 @@ type ColorizeSyntax struct {
 	normalFuncs []func(r *ExtRunner)
 	cline       []rune
 	cenc0       []rune
+
+	// state after skipped content (ex: folds), cleared on measure
+	skips map[csSkip]ColorizeSyntaxData
+}
+
+type csSkip struct {
+	data ColorizeSyntaxData // state before the skipped content
+	ri   int                // position after the skipped content
 }
 
 func ColorizeSyntax1(d Drawer) ColorizeSyntax {
@@ -51,6 +60,12 @@ func (cs *ColorizeSyntax) Iterate(r *ExtRunner) {
 		return
 	}
 
+	if cs.Opt.Tokenizer != nil {
+		cs.token(r)
+		r.NextExt()
+		return
+	}
+
 	cs.preState(r)
 
 	if !r.NextExt() {
@@ -120,6 +135,41 @@ func (cs *ColorizeSyntax) quotes(r *ExtRunner) {
 
 //----------
 
+// Reads the next token when the current one ends. The token end and state are kept to allow restoring in the middle of a token.
+func (cs *ColorizeSyntax) token(r *ExtRunner) {
+	ri := r.RR.Ri
+	if ri < cs.data.index {
+		return
+	}
+	if ri > cs.data.index {
+		cs.skip(r)
+		if ri < cs.data.index {
+			return // inside a token that started in the skipped content
+		}
+	}
+	tok := &cs.data.token
+	tok.class, cs.data.index, tok.state = cs.Opt.Tokenizer.Token(r.RR.reader, ri, tok.state)
+}
+
+// Content was skipped (ex: fold): runs the tokenizer over it since the state depends on it (ex: end of a multiline comment).
+func (cs *ColorizeSyntax) skip(r *ExtRunner) {
+	k := csSkip{cs.data, r.RR.Ri}
+	if d, ok := cs.skips[k]; ok {
+		cs.data = d
+		return
+	}
+	tok := &cs.data.token
+	for cs.data.index < r.RR.Ri {
+		tok.class, cs.data.index, tok.state = cs.Opt.Tokenizer.Token(r.RR.reader, cs.data.index, tok.state)
+	}
+	if cs.skips == nil {
+		cs.skips = map[csSkip]ColorizeSyntaxData{}
+	}
+	cs.skips[k] = cs.data
+}
+
+//----------
+
 func (cs *ColorizeSyntax) postState(r *ExtRunner) {
 	switch cs.data.state {
 	case CSSCommentLine:
@@ -142,6 +192,17 @@ func (cs *ColorizeSyntax) postState(r *ExtRunner) {
 
 //----------
 
+// Current rune is not inside a string or comment.
+func (cs *ColorizeSyntax) code() bool {
+	if cs.Opt.Tokenizer != nil {
+		c := cs.data.token.class
+		return c != syntaxutil.String && c != syntaxutil.Comment
+	}
+	return cs.data.state == CSSNormal
+}
+
+//----------
+
 // Implements PosDataKeeper
 func (cs *ColorizeSyntax) KeepPosData() interface{} {
 	return cs.data
@@ -158,6 +219,10 @@ type ColorizeSyntaxData struct {
 	state CSState
 	quote rune
 	index int
+	token struct {
+		class syntaxutil.Class
+		state syntaxutil.State
+	}
 }
 
 //----------
@@ -185,6 +250,12 @@ type ColorizeSyntaxOpt struct {
 		Enclosed [2]string // ex: "/*" "*/"
 		Fg       color.Color
 	}
+
+	// If not nil, replaces the comments and strings states. Comments and strings use the colors above.
+	Tokenizer syntaxutil.Tokenizer
+	Token     struct {
+		Fg [syntaxutil.NClasses]color.Color // indexed by class
+	}
 }
 
 //----------
@@ -205,6 +276,12 @@ func (csc *ColorizeSyntaxColor) Iterate(r *ExtRunner) {
 		return
 	}
 
+	if csc.csyntax.Opt.Tokenizer != nil {
+		csc.tokenColor()
+		r.NextExt()
+		return
+	}
+
 	switch csc.csyntax.data.state {
 	case CSSString, CSSStringEscape:
 		fg := csc.csyntax.Opt.String.Fg
@@ -219,3 +296,19 @@ func (csc *ColorizeSyntaxColor) Iterate(r *ExtRunner) {
 	}
 	r.NextExt()
 }
+
+func (csc *ColorizeSyntaxColor) tokenColor() {
+	opt := &csc.csyntax.Opt
+	var fg color.Color
+	switch c := csc.csyntax.data.token.class; c {
+	case syntaxutil.String:
+		fg = opt.String.Fg
+	case syntaxutil.Comment:
+		fg = opt.Comment.Fg
+	default:
+		fg = opt.Token.Fg[c]
+	}
+	if fg != nil {
+		csc.cc.Fg = fg
+	}
+}
@@ -19,6 +19,7 @@ type Cursor struct {
 
 	// start values
 	delay *CursorDelay
+	ei    int // extra indexes position
 }
 
 func Cursor1(cc *CurColors) Cursor {
@@ -31,6 +32,7 @@ func (c *Cursor) setup(img draw.Image) {
 
 func (c *Cursor) Start(r *ExtRunner) {
 	c.delay = nil
+	c.ei = 0
 }
 
 func (c *Cursor) Iterate(r *ExtRunner) {
@@ -45,7 +47,7 @@ func (c *Cursor) Iterate(r *ExtRunner) {
 		c.draw(c.delay, r)
 		c.delay = nil
 	}
-	if r.RR.Ri == c.Opt.Index { // also runs when ri==eos
+	if r.RR.Ri == c.Opt.Index || c.isExtraIndex(r.RR.Ri) { // also runs when ri==eos
 		offset := mathutil.PIntf2(r.D.Offset())
 		pos := r.D.Bounds().Min
 		pb := r.RR.OffsetPenBoundsRect(offset, pos)
@@ -55,6 +57,18 @@ func (c *Cursor) Iterate(r *ExtRunner) {
 	r.NextExt()
 }
 
+func (c *Cursor) isExtraIndex(ri int) bool {
+	for ; c.ei < len(c.Opt.Extra); c.ei++ {
+		i := c.Opt.Extra[c.ei]
+		if ri < i {
+			return false
+		} else if ri == i {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Cursor) End(r *ExtRunner) {
 	// draw at eos
 	if c.delay != nil {
@@ -106,5 +120,6 @@ type CursorDelay struct {
 
 type CursorOpt struct {
 	Index int
+	Extra []int // extra cursors indexes (multi-cursor), assumed to be ordered
 	Fg    color.Color
 }
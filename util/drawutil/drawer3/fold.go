@@ -0,0 +1,138 @@
+package drawer3
+
+import (
+	"image/color"
+	"sort"
+)
+
+// Hides ranges of the content (ex: function bodies), drawing a placeholder instead.
+type Fold struct {
+	EExt
+	Opt FoldOpt
+	d   Drawer // needed by SetOn
+
+	// start values
+	index int // region being tested (-1 if not searched yet)
+	state FoldState
+}
+
+func Fold1(d Drawer) Fold {
+	return Fold{d: d}
+}
+
+func (f *Fold) SetOn(v bool) {
+	if v != f.EExt.On() {
+		f.d.SetNeedMeasure(true)
+	}
+	f.EExt.SetOn(v)
+}
+
+func (f *Fold) Start(r *ExtRunner) {
+	f.index = -1
+	f.state = FoldStateNormal
+}
+
+func (f *Fold) Iterate(r *ExtRunner) {
+	if r.RR.RiClone() {
+		r.NextExt()
+		return
+	}
+
+	ri := r.RR.Ri
+	rs := f.Opt.Regions
+
+	// the first position might not be at the start of the content
+	if f.index < 0 {
+		f.index = sort.Search(len(rs), func(i int) bool {
+			return rs[i].End > ri
+		})
+	}
+	for ; f.index < len(rs) && rs[f.index].End <= ri; f.index++ {
+	}
+
+	if f.index < len(rs) && rs[f.index].Pos == ri {
+		f.iteratePlaceholder(r, rs[f.index])
+		return
+	}
+
+	r.NextExt()
+}
+
+// Iterates the placeholder runes instead of the current rune, and continues at the region end.
+func (f *Fold) iteratePlaceholder(r *ExtRunner, fr *FoldRegion) {
+	rr := r.RR
+	size := len(string(rr.Ru)) // added by the runereader after returning
+
+	s := f.Opt.Placeholder
+	if s == "" {
+		s = "..."
+	}
+
+	// kern was added with the hidden rune
+	rr.Pen.X -= rr.Kern
+
+	f.state = FoldStateOn
+	defer func() { f.state = FoldStateNormal }()
+
+	k := 0
+	for _, ru := range s {
+		// Only the first rune is not a clone: indexof/pointof map the placeholder to the region start. The others don't advance the index.
+		if k == 1 {
+			rr.PushRiClone()
+			defer rr.PopRiClone()
+		}
+		k++
+		if !rr.Iterate2(r, ru, 0) {
+			return
+		}
+	}
+
+	// continue at the region end (pen was already advanced)
+	rr.Ri = fr.End - size
+	rr.Advance = 0
+}
+
+//----------
+
+type FoldState int
+
+const (
+	FoldStateNormal FoldState = iota
+	FoldStateOn               // iterating the placeholder
+)
+
+//----------
+
+type FoldOpt struct {
+	Regions     []*FoldRegion // ordered, non-overlapping
+	Placeholder string        // defaults to "..."
+	Fg, Bg      color.Color
+}
+
+type FoldRegion struct {
+	Pos, End int // hidden range
+}
+
+//----------
+
+type FoldColor struct {
+	EExt
+	fold *Fold
+	cc   *CurColors
+}
+
+func FoldColor1(fold *Fold, cc *CurColors) FoldColor {
+	return FoldColor{fold: fold, cc: cc}
+}
+
+func (fc *FoldColor) Iterate(r *ExtRunner) {
+	if fc.fold.On() && fc.fold.state == FoldStateOn {
+		if fc.fold.Opt.Fg != nil {
+			fc.cc.Fg = fc.fold.Opt.Fg
+		}
+		if fc.fold.Opt.Bg != nil {
+			fc.cc.Bg = fc.fold.Opt.Bg
+		}
+	}
+	r.NextExt()
+}
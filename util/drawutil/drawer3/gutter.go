@@ -0,0 +1,140 @@
+package drawer3
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sort"
+
+	"github.com/jmigpin/editor/util/imageutil"
+	"github.com/jmigpin/editor/util/mathutil"
+)
+
+// Draws line markers (ex: changed lines) on the left border.
+type Gutter struct {
+	EExt
+	Opt GutterOpt
+
+	// setup values
+	img draw.Image
+
+	// start values
+	index int // marker being tested (-1 if not searched yet)
+	lastY int // last line drawn
+}
+
+func Gutter1() Gutter {
+	return Gutter{}
+}
+
+func (g *Gutter) setup(img draw.Image) {
+	g.img = img
+}
+
+func (g *Gutter) Start(r *ExtRunner) {
+	g.index = -1
+	g.lastY = -1
+}
+
+func (g *Gutter) Iterate(r *ExtRunner) {
+	if r.RR.RiClone() {
+		r.NextExt()
+		return
+	}
+
+	ri := r.RR.Ri
+	ms := g.Opt.Markers
+
+	// the first position might not be at the start of the content
+	if g.index < 0 {
+		g.index = sort.Search(len(ms), func(i int) bool {
+			return !ms[i].before(ri)
+		})
+	}
+	for ; g.index < len(ms) && ms[g.index].before(ri); g.index++ {
+	}
+
+	if g.index < len(ms) {
+		m := ms[g.index]
+		if m.covers(ri) {
+			g.draw(r, m)
+		}
+	}
+
+	r.NextExt()
+}
+
+func (g *Gutter) draw(r *ExtRunner, m *GutterMarker) {
+	offset := mathutil.PIntf2(r.D.Offset())
+	pos := r.D.Bounds().Min
+	pb := r.RR.OffsetPenBoundsRect(offset, pos)
+
+	// once per line (also covers wrapped lines)
+	if pb.Min.Y == g.lastY {
+		return
+	}
+	g.lastY = pb.Min.Y
+
+	var c color.Color
+	w := g.Opt.Width
+	if w <= 0 {
+		w = 3
+	}
+	rect := image.Rect(pos.X, pb.Min.Y, pos.X+w, pb.Max.Y)
+	switch m.Type {
+	case GMInserted:
+		c = g.Opt.Inserted
+	case GMModified:
+		c = g.Opt.Modified
+	case GMDeleted:
+		// small mark at the top of the line after the deleted lines
+		c = g.Opt.Deleted
+		rect.Max.Y = rect.Min.Y + rect.Dy()/3
+		rect.Max.X = rect.Min.X + 2*w
+	}
+	if c == nil {
+		return
+	}
+	rect = rect.Intersect(r.D.Bounds())
+	imageutil.FillRectangle(g.img, &rect, c)
+}
+
+//----------
+
+type GutterOpt struct {
+	Markers []*GutterMarker // ordered, non-overlapping
+	Width   int             // pixels
+
+	Inserted, Modified, Deleted color.Color
+}
+
+//----------
+
+type GutterMarker struct {
+	Pos, End int // deleted markers have an empty range at the start of the line after the deleted lines
+	Type     GutterMarkerType
+}
+
+func (m *GutterMarker) before(ri int) bool {
+	if m.Pos == m.End {
+		return m.Pos < ri
+	}
+	return m.End <= ri
+}
+
+func (m *GutterMarker) covers(ri int) bool {
+	if m.Pos == m.End {
+		return m.Pos == ri
+	}
+	return m.Pos <= ri && ri < m.End
+}
+
+//----------
+
+type GutterMarkerType int
+
+const (
+	GMInserted GutterMarkerType = iota
+	GMModified
+	GMDeleted
+)
@@ -2,10 +2,12 @@ package drawer3
 
 import (
 	"image"
+	"strings"
 	"testing"
 
 	"github.com/jmigpin/editor/util/drawutil"
 	"github.com/jmigpin/editor/util/iout"
+	"github.com/jmigpin/editor/util/syntaxutil"
 )
 
 func Test1(t *testing.T) {
@@ -59,3 +61,187 @@ func Test1(t *testing.T) {
 		}
 	}
 }
+
+func TestFold1(t *testing.T) {
+	s := "a\n{\nb\nc\n}\nd"
+	pos, end := 2, 8 // "\nb\nc\n"
+
+	d := NewPosDrawer()
+	d.SetFace(drawutil.GetTestFace())
+	d.SetReader(iout.NewRW([]byte(s)))
+	d.SetBounds(image.Rect(0, 0, 500, 500))
+	d.Fold.SetOn(true)
+	d.Fold.Opt.Regions = []*FoldRegion{{Pos: pos, End: end}}
+
+	m := d.Measure()
+	lh := d.LineHeight()
+	if m.Y != 3*lh {
+		t.Fatalf("measure: %v, lh=%v", m, lh)
+	}
+
+	// index after the region is on the 3rd line
+	pd := d.PointOf(len(s) - 1)
+	if pd.Y != 2*lh || pd.X != 0 {
+		t.Fatalf("pointof: %v", pd)
+	}
+	if i := d.IndexOf(pd); i != len(s)-1 {
+		t.Fatalf("indexof: %v", i)
+	}
+
+	// placeholder maps to the region start
+	p0 := d.PointOf(pos)
+	if p0.Y != lh {
+		t.Fatalf("pointof: %v", p0)
+	}
+	p1 := p0.Add(image.Point{1, 1})
+	if i := d.IndexOf(p1); i != pos {
+		t.Fatalf("indexof: %v", i)
+	}
+
+	// hidden indexes are after the placeholder
+	pe := d.PointOf(end)
+	if d.PointOf(pos+1) != pe || pe.Y != lh || pe.X <= p0.X {
+		t.Fatalf("pointof: %v %v", d.PointOf(pos+1), pe)
+	}
+}
+
+func TestFoldComment1(t *testing.T) {
+	s := "/**\n * a\n */\nvar b = 1"
+	pos, end := 3, 12 // "\n * a\n */"
+
+	d := NewPosDrawer()
+	d.SetFace(drawutil.GetTestFace())
+	d.SetReader(iout.NewRW([]byte(s)))
+	d.SetBounds(image.Rect(0, 0, 500, 500))
+	d.ColorizeSyntax.SetOn(true)
+	d.ColorizeSyntax.Opt.Tokenizer = syntaxutil.ExtTokenizer(".go")
+	d.Fold.SetOn(true)
+	d.Fold.Opt.Regions = []*FoldRegion{{Pos: pos, End: end}}
+	_ = d.Measure()
+
+	// token class at each visited index
+	classes := map[int]syntaxutil.Class{}
+	tc := &testClassExt{cs: &d.ColorizeSyntax, m: classes}
+	exts := append(append([]Ext{}, d.pexts...), tc)
+	RunExts(d, &d.rr, exts, nil)
+
+	if _, ok := classes[pos+1]; ok {
+		t.Fatal("hidden index was visited")
+	}
+	if c := classes[0]; c != syntaxutil.Comment {
+		t.Fatalf("comment start: %v", c)
+	}
+	// code after the folded comment
+	for i := end + 1; i < len(s); i++ {
+		if c := classes[i]; c == syntaxutil.Comment {
+			t.Fatalf("index %v: comment after the fold", i)
+		}
+	}
+	if c := classes[end+1]; c != syntaxutil.Keyword {
+		t.Fatalf("keyword: %v", c)
+	}
+}
+
+type testClassExt struct {
+	EExt
+	cs *ColorizeSyntax
+	m  map[int]syntaxutil.Class
+}
+
+func (e *testClassExt) Iterate(r *ExtRunner) {
+	if !r.RR.RiClone() {
+		e.m[r.RR.Ri] = e.cs.data.token.class
+	}
+	r.NextExt()
+}
+
+func TestMatchingBracket1(t *testing.T) {
+	s := "f(a, \")\", /* ( */ g(b))\n// (\n{[c]}"
+
+	d := NewPosDrawer()
+	d.SetFace(drawutil.GetTestFace())
+	d.SetReader(iout.NewRW([]byte(s)))
+	d.SetBounds(image.Rect(0, 0, 500, 500))
+	d.ColorizeSyntax.SetOn(true)
+	d.ColorizeSyntax.Opt.Comment.Line = "//"
+	d.ColorizeSyntax.Opt.Comment.Enclosed = [2]string{"/*", "*/"}
+	_ = d.Measure()
+
+	tests := []struct {
+		index, match int
+		ok           bool
+	}{
+		{1, 22, true},
+		{22, 1, true},
+		{19, 21, true},
+		{21, 19, true},
+		{6, 0, false},  // inside string
+		{13, 0, false}, // inside comment
+		{27, 0, false}, // inside line comment
+		{29, 33, true},
+		{32, 30, true},
+	}
+	for _, u := range tests {
+		m, ok := d.MatchingBracket(u.index)
+		if ok != u.ok || m != u.match {
+			t.Fatalf("index %v: got %v %v, expecting %v %v", u.index, m, ok, u.match, u.ok)
+		}
+	}
+
+	opens := enclosingOpenBrackets(d, 31)
+	if len(opens) != 2 || opens[0] != 30 || opens[1] != 29 {
+		t.Fatalf("enclosing: %v", opens)
+	}
+}
+
+func TestMatchingBracket2(t *testing.T) {
+	// brackets far apart (backward scan in blocks), with a comment crossing blocks
+	fill := strings.Repeat("a\n", 3000)
+	s := "{(" + fill + "/* ) " + fill + " */" + fill + "[x" + fill + ")}"
+	open1, open2 := 0, 1
+	x := strings.Index(s, "x")
+	close2, close1 := len(s)-2, len(s)-1
+
+	d := NewPosDrawer()
+	d.SetFace(drawutil.GetTestFace())
+	d.SetReader(iout.NewRW([]byte(s)))
+	d.SetBounds(image.Rect(0, 0, 500, 500))
+	d.ColorizeSyntax.SetOn(true)
+	d.ColorizeSyntax.Opt.Comment.Enclosed = [2]string{"/*", "*/"}
+	_ = d.Measure()
+
+	if m, ok := d.MatchingBracket(close2); !ok || m != open2 {
+		t.Fatalf("got %v %v", m, ok)
+	}
+	if m, ok := d.MatchingBracket(close1); !ok || m != open1 {
+		t.Fatalf("got %v %v", m, ok)
+	}
+	if m, ok := d.MatchingBracket(open2); !ok || m != close2 {
+		t.Fatalf("got %v %v", m, ok)
+	}
+
+	// unclosed "[" is enclosing
+	opens := enclosingOpenBrackets(d, x)
+	if len(opens) != 3 || opens[0] != x-1 || opens[1] != open2 || opens[2] != open1 {
+		t.Fatalf("enclosing: %v", opens)
+	}
+
+	// stops at the innermost
+	n := 0
+	d.EnclosingOpenBrackets(x, func(o int) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Fatalf("calls: %v", n)
+	}
+}
+
+func enclosingOpenBrackets(d *PosDrawer, index int) []int {
+	w := []int{}
+	d.EnclosingOpenBrackets(index, func(o int) bool {
+		w = append(w, o)
+		return true
+	})
+	return w
+}
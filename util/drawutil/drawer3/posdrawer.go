@@ -17,6 +17,8 @@ type PosDrawer struct {
 	ColorizeSyntax ColorizeSyntax
 	Segments       Segments
 	Annotations    Annotations
+	Gutter         Gutter
+	Fold           Fold
 
 	mexts []Ext // measure extentions
 	dexts []Ext // draw extentions
@@ -30,11 +32,13 @@ type PosDrawer struct {
 	wlinec   WrapLineColor
 	csyntaxc ColorizeSyntaxColor
 	annc     AnnotationsColor
+	foldc    FoldColor
 	cc       CurColors
 	bgf      BgFill
 	dru      DrawRune
 
 	measurement image.Point
+	bracketc    *bracketCache // cleared on measure
 }
 
 func NewPosDrawer() *PosDrawer {
@@ -50,6 +54,10 @@ func NewPosDrawer() *PosDrawer {
 	d.Segments.SetOn(false)
 	d.Annotations = Annotations1()
 	d.Annotations.SetOn(false)
+	d.Gutter = Gutter1()
+	d.Gutter.SetOn(false)
+	d.Fold = Fold1(d)
+	d.Fold.SetOn(false)
 
 	// d.rr // no init
 	// d.cc // no init
@@ -59,6 +67,7 @@ func NewPosDrawer() *PosDrawer {
 	d.csyntaxc = ColorizeSyntaxColor1(&d.ColorizeSyntax, &d.cc)
 	d.wlinec = WrapLineColor1(&d.WrapLine, &d.cc)
 	d.annc = AnnotationsColor1(&d.Annotations, &d.cc)
+	d.foldc = FoldColor1(&d.Fold, &d.cc)
 	d.bgf = BgFill1(&d.cc)
 	d.dru = DrawRune1(&d.cc)
 
@@ -72,6 +81,7 @@ func NewPosDrawer() *PosDrawer {
 
 	d.pexts = []Ext{
 		&d.rr,
+		&d.Fold,
 		&d.line,
 		&d.WrapLine,
 		&d.ColorizeSyntax,
@@ -88,7 +98,9 @@ func NewPosDrawer() *PosDrawer {
 		&d.Segments,
 		&d.wlinec,
 		&d.annc,
+		&d.foldc,
 		&d.bgf,
+		&d.Gutter,
 		&d.dru,
 		&d.Cursor,
 	}...)
@@ -113,6 +125,8 @@ func (d *PosDrawer) Measure() image.Point {
 		return d.measurement
 	}
 	d.needMeasure = false
+	d.bracketc = nil
+	d.ColorizeSyntax.skips = nil
 
 	// restores original offset after measuring
 	keep := d.WrapLine.On() && d.Offset().Y > 0
@@ -161,6 +175,7 @@ func (d *PosDrawer) Draw(img draw.Image, fg color.Color) {
 	d.cc.setup(fg)
 	d.Cursor.setup(img)
 	d.bgf.setup(img)
+	d.Gutter.setup(img)
 	d.dru.setup(img)
 
 	postStart := func() {
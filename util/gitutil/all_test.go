@@ -0,0 +1,196 @@
+package gitutil
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestStatus1(t *testing.T) {
+	dir := tmpRepo(t)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	mustWrite(t, dir, "a.txt", "a")
+	mustWrite(t, dir, "b.txt", "b")
+	mustWrite(t, dir, ".gitignore", "c.txt\n")
+	mustGit(t, dir, "add", ".")
+	mustGit(t, dir, "commit", "-m", "first")
+
+	mustWrite(t, dir, "a.txt", "a2")  // modified
+	mustWrite(t, dir, "c.txt", "c")   // ignored
+	mustWrite(t, dir, "d/d.txt", "d") // untracked dir
+	mustGit(t, dir, "mv", "b.txt", "b2.txt")
+
+	entries, err := Status(ctx, filepath.Join(dir, "d"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := []string{}
+	for _, e := range entries {
+		s := e.Code() + " " + strings.TrimPrefix(e.Path, dir)
+		if e.OrigPath != "" {
+			s += " " + strings.TrimPrefix(e.OrigPath, dir)
+		}
+		u = append(u, s)
+	}
+	sort.Strings(u)
+	r := strings.Join(u, "\n")
+	e := "! /c.txt\n? /d\nM /a.txt\nR /b2.txt /b.txt"
+	if r != e {
+		t.Fatalf("\n%v", r)
+	}
+}
+
+func TestStatus2(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gitutil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// not a repository
+	_, err = Status(context.Background(), dir, false)
+	if err == nil {
+		t.Fatal("expecting error")
+	}
+}
+
+func TestStatus3(t *testing.T) {
+	dir := tmpRepo(t)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	mustWrite(t, dir, "a.txt", "a")
+	mustWrite(t, dir, "d/b.txt", "b")
+	mustGit(t, dir, "add", ".")
+	mustGit(t, dir, "commit", "-m", "first")
+
+	mustWrite(t, dir, "a.txt", "a2")   // modified, outside the pathspec
+	mustWrite(t, dir, "d/b.txt", "b2") // modified
+	mustWrite(t, dir, "d/c.txt", "c")  // untracked
+
+	entries, err := Status(ctx, filepath.Join(dir, "d"), false, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := []string{}
+	for _, e := range entries {
+		u = append(u, e.Code()+" "+strings.TrimPrefix(e.Path, dir))
+	}
+	sort.Strings(u)
+	r := strings.Join(u, "\n")
+	e := "? /d/c.txt\nM /d/b.txt"
+	if r != e {
+		t.Fatalf("\n%v", r)
+	}
+}
+
+func TestShowHead1(t *testing.T) {
+	dir := tmpRepo(t)
+	defer os.RemoveAll(dir)
+
+	mustWrite(t, dir, "a/a.txt", "a")
+	mustGit(t, dir, "add", ".")
+	mustGit(t, dir, "commit", "-m", "first")
+	mustWrite(t, dir, "a/a.txt", "a2")
+
+	b, err := ShowHead(context.Background(), filepath.Join(dir, "a/a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "a" {
+		t.Fatalf("%q", b)
+	}
+
+	// not in HEAD
+	mustWrite(t, dir, "a/b.txt", "b")
+	if _, err := ShowHead(context.Background(), filepath.Join(dir, "a/b.txt")); err == nil {
+		t.Fatal("expecting error")
+	}
+}
+
+func TestBlame1(t *testing.T) {
+	dir := tmpRepo(t)
+	defer os.RemoveAll(dir)
+
+	mustWrite(t, dir, "a.txt", "1\n2\n")
+	mustGit(t, dir, "add", ".")
+	mustGit(t, dir, "commit", "-m", "first")
+
+	name := filepath.Join(dir, "a.txt")
+	lines, err := Blame(context.Background(), name, []byte("1\nx\n2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("%v lines", len(lines))
+	}
+	l0, l1, l2 := lines[0], lines[1], lines[2]
+	if !l0.Committed() || l1.Committed() || l0 != l2 {
+		t.Fatalf("%+v %+v %+v", l0, l1, l2)
+	}
+	if l0.Author != "test" || l0.Summary != "first" || l0.Time.IsZero() {
+		t.Fatalf("%+v", l0)
+	}
+}
+
+func TestParseBlame1(t *testing.T) {
+	h1 := strings.Repeat("a1", 20) // sha1
+	h2 := strings.Repeat("b2", 32) // sha256
+	s := h1 + " 1 1 1\nauthor x\nsummary s1\n\tline1\n" +
+		h2 + " 1 2 1\nauthor y\nsummary s2\n\tline2\n"
+	lines, err := parseBlame([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 2 || lines[0].Hash != h1 || lines[1].Hash != h2 || lines[1].Author != "y" {
+		t.Fatalf("%+v", lines)
+	}
+
+	// bad hash
+	if _, err := parseBlame([]byte("xyz 1 1 1\n\tline1\n")); err == nil {
+		t.Fatal("expecting error")
+	}
+}
+
+//----------
+
+func tmpRepo(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "gitutil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// top level is reported without symlinks
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustGit(t, dir, "init", "-q")
+	mustGit(t, dir, "config", "user.name", "test")
+	mustGit(t, dir, "config", "user.email", "test@test")
+	return dir
+}
+
+func mustGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	if _, err := Run(context.Background(), dir, args...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustWrite(t *testing.T, dir, name, s string) {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(p, []byte(s), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,124 @@
+package gitutil
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type BlameLine struct {
+	Hash    string
+	Author  string
+	Time    time.Time
+	Summary string
+}
+
+// Not committed lines have a zero hash.
+func (bl *BlameLine) Committed() bool {
+	return strings.Trim(bl.Hash, "0") != ""
+}
+
+// Blame of each line. If content is not nil, it is used instead of the file on disk (lines match the content).
+func Blame(ctx context.Context, filename string, content []byte) ([]*BlameLine, error) {
+	dir, base := filepath.Split(filename)
+	args := []string{"blame", "--porcelain"}
+	if content != nil {
+		args = append(args, "--contents", "-")
+	}
+	args = append(args, "--", base)
+
+	var in io.Reader
+	if content != nil {
+		in = bytes.NewReader(content)
+	}
+	b, err := RunStdin(ctx, dir, in, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseBlame(b)
+}
+
+// Hex object name: 40 (sha1) or 64 (sha256) characters.
+func isHash(s string) bool {
+	if len(s) != 40 && len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func parseBlame(b []byte) ([]*BlameLine, error) {
+	commits := map[string]*BlameLine{}
+	lines := []*BlameLine{}
+	var cur *BlameLine
+	final := 0
+
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(nil, 1024*1024)
+	for sc.Scan() {
+		s := sc.Text()
+
+		// line content
+		if strings.HasPrefix(s, "\t") {
+			if cur == nil {
+				return nil, fmt.Errorf("blame: content without header")
+			}
+			for len(lines) < final {
+				lines = append(lines, nil)
+			}
+			lines[final-1] = cur
+			cur = nil
+			continue
+		}
+
+		// header: <hash> <orig-line> <final-line> [<num-lines>]
+		if cur == nil {
+			f := strings.Fields(s)
+			if len(f) < 3 || !isHash(f[0]) {
+				return nil, fmt.Errorf("blame: bad header: %q", s)
+			}
+			n, err := strconv.Atoi(f[2])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("blame: bad header: %q", s)
+			}
+			final = n
+			c, ok := commits[f[0]]
+			if !ok {
+				c = &BlameLine{Hash: f[0]}
+				commits[f[0]] = c
+			}
+			cur = c
+			continue
+		}
+
+		// commit info
+		k, v := s, ""
+		if i := strings.Index(s, " "); i >= 0 {
+			k, v = s[:i], s[i+1:]
+		}
+		switch k {
+		case "author":
+			cur.Author = v
+		case "author-time":
+			if u, err := strconv.ParseInt(v, 10, 64); err == nil {
+				cur.Time = time.Unix(u, 0)
+			}
+		case "summary":
+			cur.Summary = v
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
@@ -0,0 +1,121 @@
+package gitutil
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"unicode"
+)
+
+// Runs git in dir and returns the output (stdout).
+func Run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	return RunStdin(ctx, dir, nil, args...)
+}
+
+func RunStdin(ctx context.Context, dir string, in io.Reader, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdin = in
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	b, err := cmd.Output()
+	if err != nil {
+		u := bytes.TrimRightFunc(stderr.Bytes(), unicode.IsSpace)
+		return nil, fmt.Errorf("git %v: %v: %s", args[0], err, u)
+	}
+	return b, nil
+}
+
+// Working tree top level directory. Fails if dir is not inside a working tree.
+func TopLevel(ctx context.Context, dir string) (string, error) {
+	b, err := Run(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSpace(b)), nil
+}
+
+// Content of the file at HEAD.
+func ShowHead(ctx context.Context, filename string) ([]byte, error) {
+	dir, base := filepath.Split(filename)
+	return Run(ctx, dir, "show", "HEAD:./"+base)
+}
+
+//----------
+
+// Entry from "git status --porcelain".
+type StatusEntry struct {
+	X, Y     byte   // index and working tree status
+	Path     string // absolute
+	OrigPath string // absolute, renames/copies only
+	Dir      bool   // untracked/ignored directory (path ends with "/")
+}
+
+// Short status code: "M", "A", "D", "R", "?" (untracked), "!" (ignored), or two letters if the index and working tree differ (ex: "MM").
+func (e *StatusEntry) Code() string {
+	switch {
+	case e.X == '?' && e.Y == '?':
+		return "?"
+	case e.X == '!' && e.Y == '!':
+		return "!"
+	case e.X == ' ':
+		return string(e.Y)
+	case e.Y == ' ':
+		return string(e.X)
+	default:
+		return string([]byte{e.X, e.Y})
+	}
+}
+
+// Status of the working tree that contains dir, limited to the pathspecs (relative to dir) if any. Paths are absolute.
+func Status(ctx context.Context, dir string, ignored bool, pathspecs ...string) ([]*StatusEntry, error) {
+	top, err := TopLevel(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"status", "--porcelain", "-z"}
+	if ignored {
+		args = append(args, "--ignored")
+	}
+	if len(pathspecs) > 0 {
+		args = append(args, "--")
+		args = append(args, pathspecs...)
+	}
+	// porcelain paths are relative to the top level regardless of the running dir
+	b, err := Run(ctx, dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseStatus(top, b)
+}
+
+func parseStatus(top string, b []byte) ([]*StatusEntry, error) {
+	w := []*StatusEntry{}
+	fields := bytes.Split(b, []byte{0})
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		if len(f) == 0 {
+			continue
+		}
+		if len(f) < 4 || f[2] != ' ' {
+			return nil, fmt.Errorf("bad status entry: %q", f)
+		}
+		e := &StatusEntry{X: f[0], Y: f[1]}
+		p := string(f[3:])
+		e.Dir = p[len(p)-1] == '/'
+		e.Path = filepath.Join(top, p)
+		// renames and copies have the original path in the next field
+		if e.X == 'R' || e.X == 'C' {
+			i++
+			if i >= len(fields) {
+				return nil, fmt.Errorf("missing original path: %q", f)
+			}
+			e.OrigPath = filepath.Join(top, string(fields[i]))
+		}
+		w = append(w, e)
+	}
+	return w, nil
+}
@@ -2,6 +2,9 @@ package iout
 
 import (
 	"bytes"
+	"math/rand"
+	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -66,6 +69,103 @@ func TestRW1(t *testing.T) {
 	}
 }
 
+func TestRope1(t *testing.T) {
+	rw := NewRW(nil)
+	r := NewRope(nil)
+
+	rnd := rand.New(rand.NewSource(1))
+	data := bytes.Repeat([]byte("0123456789αβγ\n"), 1024)
+
+	check := func() {
+		t.Helper()
+		if r.Len() != rw.Len() {
+			t.Fatalf("len %v != %v", r.Len(), rw.Len())
+		}
+		b1, err := r.ReadNAt(0, r.Len())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b1, rw.buf) {
+			t.Fatal("content differs")
+		}
+	}
+
+	for k := 0; k < 2000; k++ {
+		l := rw.Len()
+		if rnd.Intn(3) > 0 || l == 0 {
+			i := rnd.Intn(l + 1)
+			n := rnd.Intn(8)
+			if k%50 == 0 {
+				n = rnd.Intn(len(data))
+			}
+			p := data[:n]
+			if err := rw.Insert(i, p); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Insert(i, p); err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			i := rnd.Intn(l + 1)
+			n := rnd.Intn(l - i + 1)
+			if k%7 != 0 && n > 16 {
+				n = 16
+			}
+			if err := rw.Delete(i, n); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Delete(i, n); err != nil {
+				t.Fatal(err)
+			}
+		}
+		check()
+
+		// random reads
+		l = rw.Len()
+		i := rnd.Intn(l + 1)
+		n := rnd.Intn(l - i + 1)
+		b1, err := r.ReadNSliceAt(i, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b2, _ := rw.ReadNSliceAt(i, n)
+		if !bytes.Equal(b1, b2) {
+			t.Fatalf("read %v %v", i, n)
+		}
+		ru1, s1, err1 := r.ReadRuneAt(i)
+		ru2, s2, err2 := rw.ReadRuneAt(i)
+		if ru1 != ru2 || s1 != s2 || err1 != err2 {
+			t.Fatalf("readrune %v", i)
+		}
+		ru1, s1, err1 = r.ReadLastRuneAt(i)
+		ru2, s2, err2 = rw.ReadLastRuneAt(i)
+		if ru1 != ru2 || s1 != s2 || err1 != err2 {
+			t.Fatalf("readlastrune %v", i)
+		}
+	}
+
+	// tree should be balanced
+	if h := r.root.height(); h > 30 {
+		t.Fatalf("height %v", h)
+	}
+}
+
+func TestRope2(t *testing.T) {
+	r := NewRope([]byte("0123"))
+	if _, err := r.ReadNAt(2, 3); err == nil {
+		t.Fatal("expecting error")
+	}
+	if err := r.Delete(3, 2); err == nil {
+		t.Fatal("expecting error")
+	}
+	if err := r.Insert(5, []byte("a")); err == nil {
+		t.Fatal("expecting error")
+	}
+	if _, _, err := r.ReadRuneAt(4); err == nil {
+		t.Fatal("expecting eof")
+	}
+}
+
 func TestIndex1(t *testing.T) {
 	s := "0123456789"
 	for i := 0; i < 32*1024; i++ {
@@ -93,3 +193,57 @@ func TestIndex2(t *testing.T) {
 		t.Fatal("not found")
 	}
 }
+
+func TestIndexRegexp1(t *testing.T) {
+	s := "0123 abc 456 abd"
+	rw := NewRW([]byte(s))
+	re := regexp.MustCompile(`ab(.)`)
+	m, err := IndexRegexp(rw, 8, rw.Len()-8, re)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == nil || m[0] != 13 || m[1] != 16 || m[2] != 15 {
+		t.Fatal(m)
+	}
+}
+
+func TestIndexRegexp2(t *testing.T) {
+	s := "0123 abc"
+	rw := NewRW([]byte(s))
+	re := regexp.MustCompile(`abc`)
+	m, err := IndexRegexp(rw, 0, 7, re)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != nil {
+		t.Fatal(m)
+	}
+}
+
+func TestIndexRegexp3(t *testing.T) {
+	s := "xaaab"
+	rw := NewRW([]byte(s))
+	re := regexp.MustCompile(`a+b`)
+	// a match starting before the range doesn't hide the one inside
+	m, err := IndexRegexp(rw, 2, 3, re)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == nil || m[0] != 2 || m[1] != 5 {
+		t.Fatal(m)
+	}
+}
+
+func TestLineNumber1(t *testing.T) {
+	s := strings.Repeat("0123456789\n", 10*1024)
+	rw := NewRW([]byte(s))
+	for _, i := range []int{0, 10, 11, 12, 50*1024 + 3, len(s)} {
+		n, err := LineNumber(rw, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n2 := strings.Count(s[:i], "\n") + 1; n != n2 {
+			t.Fatalf("index %v: got %v, expecting %v", i, n, n2)
+		}
+	}
+}
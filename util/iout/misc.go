@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"regexp"
 )
 
 var ErrLimitReached = errors.New("limit reached")
@@ -83,6 +84,37 @@ func index3(r Reader, i, len int, sep []byte, toLower bool) (int, error) {
 
 //----------
 
+// Returns the regexp submatch indexes of the leftmost match in [i, i+le), or (nil, nil) if not found. Indexes are absolute positions in the reader.
+func IndexRegexp(r Reader, i, le int, re *regexp.Regexp) ([]int, error) {
+	ms, err := FindAllRegexp(r, i, le, re)
+	if err != nil || len(ms) == 0 {
+		return nil, err
+	}
+	return ms[0], nil
+}
+
+// Returns the regexp submatch indexes of all the matches inside [i, i+le) (same matches as regexp.FindAllSubmatchIndex on that range, so "^" and "$" also match at the range limits). Only the range is read. Indexes are absolute positions in the reader.
+func FindAllRegexp(r Reader, i, le int, re *regexp.Regexp) ([][]int, error) {
+	if i+le > r.Len() {
+		le = r.Len() - i
+	}
+	b, err := r.ReadNSliceAt(i, le)
+	if err != nil {
+		return nil, err
+	}
+	ms := re.FindAllSubmatchIndex(b, -1)
+	for _, m := range ms {
+		for k := range m {
+			if m[k] >= 0 { // unmatched submatches are -1
+				m[k] += i
+			}
+		}
+	}
+	return ms, nil
+}
+
+//----------
+
 func IndexFunc(r Reader, i, len int, truth bool, f func(rune) bool) (index, size int, err error) {
 	max := i + len
 	for {
@@ -157,3 +189,24 @@ func LinesIndexes(r Reader, a, b int) (int, int, bool, error) {
 	}
 	return ls, le, newline, nil
 }
+
+// Line number (starts at 1) of the index. Counts the newlines before i, reading in chunks.
+func LineNumber(r Reader, i int) (int, error) {
+	if i > r.Len() {
+		i = r.Len()
+	}
+	n := 1
+	for k := 0; k < i; {
+		l := 32 * 1024
+		if k+l > i {
+			l = i - k
+		}
+		b, err := r.ReadNSliceAt(k, l)
+		if err != nil {
+			return 0, err
+		}
+		n += bytes.Count(b, []byte("\n"))
+		k += l
+	}
+	return n, nil
+}
@@ -0,0 +1,325 @@
+package iout
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// Rope implements ReadWriter with a balanced tree (AVL) of byte chunks. Insert and delete cost O(log n) instead of copying the tail of the content.
+type Rope struct {
+	root *ropeNode
+}
+
+func NewRope(b []byte) *Rope {
+	return &Rope{root: newRopeLeaves(b)}
+}
+
+func (r *Rope) Len() int {
+	return r.root.len()
+}
+
+//----------
+
+func (r *Rope) ReadRuneAt(i int) (ru rune, size int, err error) {
+	l := r.Len()
+	if i < 0 || i > l {
+		return 0, 0, errors.New("bad index")
+	}
+	n := utf8.UTFMax
+	if i+n > l {
+		n = l - i
+	}
+	b, err := r.ReadNSliceAt(i, n)
+	if err != nil {
+		return 0, 0, err
+	}
+	ru, size = utf8.DecodeRune(b)
+	if size == 0 {
+		return 0, 0, io.EOF
+	}
+	return ru, size, nil
+}
+
+func (r *Rope) ReadLastRuneAt(i int) (ru rune, size int, err error) {
+	if i < 0 || i > r.Len() {
+		return 0, 0, errors.New("bad index")
+	}
+	n := utf8.UTFMax
+	if i-n < 0 {
+		n = i
+	}
+	b, err := r.ReadNSliceAt(i-n, n)
+	if err != nil {
+		return 0, 0, err
+	}
+	ru, size = utf8.DecodeLastRune(b)
+	if size == 0 {
+		return 0, 0, io.EOF
+	}
+	return ru, size, nil
+}
+
+//----------
+
+func (r *Rope) ReadNAt(i, n int) ([]byte, error) {
+	if err := r.checkRead(i, n); err != nil {
+		return nil, err
+	}
+	w := make([]byte, 0, n)
+	return r.root.appendRange(w, i, n), nil
+}
+
+// Returns a slice of the content if the range is inside a single chunk, otherwise a copy. Chunks are never modified, so the slice is always valid.
+func (r *Rope) ReadNSliceAt(i, n int) ([]byte, error) {
+	if err := r.checkRead(i, n); err != nil {
+		return nil, err
+	}
+	if b, ok := r.root.sliceAt(i, n); ok {
+		return b, nil
+	}
+	w := make([]byte, 0, n)
+	return r.root.appendRange(w, i, n), nil
+}
+
+func (r *Rope) checkRead(i, n int) error {
+	if n < 0 {
+		return fmt.Errorf("bad n: %v", n)
+	}
+	if i < 0 || i > r.Len() {
+		return errors.New("bad index")
+	}
+	if i+n > r.Len() {
+		return io.EOF
+	}
+	return nil
+}
+
+//----------
+
+func (r *Rope) Insert(i int, p []byte) error {
+	if i < 0 || i > r.Len() {
+		return fmt.Errorf("bad index: %v", i)
+	}
+	if len(p) == 0 {
+		return nil
+	}
+	r.root = ropeInsert(r.root, i, p)
+	return nil
+}
+
+func (r *Rope) Delete(i, le int) error {
+	if i < 0 || i+le > r.Len() {
+		return fmt.Errorf("bad index: %v", i)
+	}
+	if le == 0 {
+		return nil
+	}
+	if le < 0 {
+		return fmt.Errorf("bad len: %v", le)
+	}
+	r.root = ropeDelete(r.root, i, le)
+	return nil
+}
+
+//----------
+
+// Max size of a leaf chunk. Small writes copy at most this size.
+const ropeLeafSize = 2 * 1024
+
+// Nodes are immutable after creation: a leaf has only bytes, an internal node has both children.
+type ropeNode struct {
+	left, right *ropeNode
+	b           []byte // leaf content
+	n           int    // total length
+	h           int    // height (leaf=0)
+}
+
+func newRopeNode(l, r *ropeNode) *ropeNode {
+	h := l.h
+	if r.h > h {
+		h = r.h
+	}
+	return &ropeNode{left: l, right: r, n: l.n + r.n, h: h + 1}
+}
+
+func newRopeLeaf(b []byte) *ropeNode {
+	if len(b) == 0 {
+		return nil
+	}
+	return &ropeNode{b: b, n: len(b)}
+}
+
+// Copies b into a balanced tree of leaves.
+func newRopeLeaves(b []byte) *ropeNode {
+	if len(b) == 0 {
+		return nil
+	}
+	if len(b) <= ropeLeafSize {
+		return newRopeLeaf(append([]byte(nil), b...))
+	}
+	// split at a leaf boundary to keep the leaves full
+	k := (len(b) / ropeLeafSize / 2) * ropeLeafSize
+	if k == 0 {
+		k = ropeLeafSize
+	}
+	return newRopeNode(newRopeLeaves(b[:k]), newRopeLeaves(b[k:]))
+}
+
+func (n *ropeNode) len() int {
+	if n == nil {
+		return 0
+	}
+	return n.n
+}
+
+func (n *ropeNode) height() int {
+	if n == nil {
+		return -1
+	}
+	return n.h
+}
+
+func (n *ropeNode) isLeaf() bool {
+	return n.left == nil
+}
+
+//----------
+
+func (n *ropeNode) sliceAt(i, le int) ([]byte, bool) {
+	for n != nil && !n.isLeaf() {
+		if i+le <= n.left.n {
+			n = n.left
+		} else if i >= n.left.n {
+			i -= n.left.n
+			n = n.right
+		} else {
+			return nil, false
+		}
+	}
+	if n == nil {
+		return nil, le == 0
+	}
+	return n.b[i : i+le], true
+}
+
+func (n *ropeNode) appendRange(w []byte, i, le int) []byte {
+	if n == nil || le <= 0 {
+		return w
+	}
+	if n.isLeaf() {
+		return append(w, n.b[i:i+le]...)
+	}
+	if i < n.left.n {
+		k := n.left.n - i
+		if k > le {
+			k = le
+		}
+		w = n.left.appendRange(w, i, k)
+		i, le = n.left.n, le-k
+	}
+	if le > 0 {
+		w = n.right.appendRange(w, i-n.left.n, le)
+	}
+	return w
+}
+
+//----------
+
+func ropeInsert(n *ropeNode, i int, p []byte) *ropeNode {
+	if n == nil {
+		return newRopeLeaves(p)
+	}
+	if n.isLeaf() {
+		if n.n+len(p) <= ropeLeafSize {
+			w := make([]byte, 0, n.n+len(p))
+			w = append(w, n.b[:i]...)
+			w = append(w, p...)
+			w = append(w, n.b[i:]...)
+			return newRopeLeaf(w)
+		}
+		l := newRopeLeaf(n.b[:i])
+		r := newRopeLeaf(n.b[i:])
+		return ropeJoin(ropeJoin(l, newRopeLeaves(p)), r)
+	}
+	if i <= n.left.n {
+		return ropeJoin(ropeInsert(n.left, i, p), n.right)
+	}
+	return ropeJoin(n.left, ropeInsert(n.right, i-n.left.n, p))
+}
+
+func ropeDelete(n *ropeNode, i, le int) *ropeNode {
+	if n == nil || le <= 0 {
+		return n
+	}
+	if i == 0 && le >= n.n {
+		return nil
+	}
+	if n.isLeaf() {
+		w := make([]byte, 0, n.n-le)
+		w = append(w, n.b[:i]...)
+		w = append(w, n.b[i+le:]...)
+		return newRopeLeaf(w)
+	}
+	l, r := n.left, n.right
+	if i < l.n {
+		k := l.n - i
+		if k > le {
+			k = le
+		}
+		l = ropeDelete(l, i, k)
+		i, le = l.len(), le-k
+	}
+	if le > 0 {
+		r = ropeDelete(r, i-l.len(), le)
+	}
+	return ropeJoin(l, r)
+}
+
+//----------
+
+// Concatenates two trees keeping the result balanced.
+func ropeJoin(l, r *ropeNode) *ropeNode {
+	if l == nil {
+		return r
+	}
+	if r == nil {
+		return l
+	}
+	// merge small leaves to avoid fragmentation
+	if l.isLeaf() && r.isLeaf() && l.n+r.n <= ropeLeafSize {
+		w := make([]byte, 0, l.n+r.n)
+		w = append(w, l.b...)
+		w = append(w, r.b...)
+		return newRopeLeaf(w)
+	}
+	if l.h > r.h+1 {
+		return ropeBalance(l.left, ropeJoin(l.right, r))
+	}
+	if r.h > l.h+1 {
+		return ropeBalance(ropeJoin(l, r.left), r.right)
+	}
+	return newRopeNode(l, r)
+}
+
+// Creates a node with the children, doing rotations if the heights differ by more then one.
+func ropeBalance(l, r *ropeNode) *ropeNode {
+	switch d := l.height() - r.height(); {
+	case d > 1:
+		if l.left.height() < l.right.height() {
+			// left-right case
+			lr := l.right
+			return newRopeNode(newRopeNode(l.left, lr.left), newRopeNode(lr.right, r))
+		}
+		return newRopeNode(l.left, newRopeNode(l.right, r))
+	case d < -1:
+		if r.right.height() < r.left.height() {
+			// right-left case
+			rl := r.left
+			return newRopeNode(newRopeNode(l, rl.left), newRopeNode(rl.right, r.right))
+		}
+		return newRopeNode(newRopeNode(l, r.left), r.right)
+	}
+	return newRopeNode(l, r)
+}
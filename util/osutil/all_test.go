@@ -0,0 +1,152 @@
+package osutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestWriteFileAtomic1(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osutil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// new file
+	name := filepath.Join(dir, "a.sh")
+	if err := WriteFileAtomic(name, []byte("1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mustContent(t, name, "1")
+
+	// keep mode
+	if err := os.Chmod(name, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileAtomic(name, []byte("2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mustContent(t, name, "2")
+	fi, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0755 {
+		t.Fatalf("mode %v", fi.Mode())
+	}
+
+	// no temporary files left
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fis) != 1 {
+		t.Fatalf("%v files", len(fis))
+	}
+}
+
+func TestWriteFileAtomic2(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osutil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "a.txt")
+	if err := ioutil.WriteFile(name, []byte("1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// symlink is kept
+	link := filepath.Join(dir, "link.txt")
+	if err := os.Symlink(name, link); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileAtomic(link, []byte("2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Lstat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode()&os.ModeSymlink == 0 {
+		t.Fatal("symlink replaced")
+	}
+	mustContent(t, name, "2")
+
+	// hard link keeps sharing the content
+	hard := filepath.Join(dir, "hard.txt")
+	if err := os.Link(name, hard); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileAtomic(name, []byte("3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mustContent(t, hard, "3")
+}
+
+func TestWriteFileAtomic3(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osutil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// keep setuid/sticky bits
+	name := filepath.Join(dir, "a.sh")
+	if err := ioutil.WriteFile(name, []byte("1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mode := 0755 | os.ModeSetuid | os.ModeSticky
+	if err := os.Chmod(name, mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileAtomic(name, []byte("2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mustContent(t, name, "2")
+	fi, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode() != mode {
+		t.Fatalf("mode %v", fi.Mode())
+	}
+}
+
+func TestWriteFileAtomic4(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osutil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// new file respects the umask
+	old := syscall.Umask(022)
+	defer syscall.Umask(old)
+	name := filepath.Join(dir, "a.txt")
+	if err := WriteFileAtomic(name, []byte("1"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0644 {
+		t.Fatalf("mode %v", fi.Mode())
+	}
+}
+
+func mustContent(t *testing.T, name, s string) {
+	t.Helper()
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != s {
+		t.Fatalf("%v: %q", name, b)
+	}
+}
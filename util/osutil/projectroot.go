@@ -0,0 +1,23 @@
+package osutil
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// First directory, from dir up to its parents, with a version control or go.mod file. The stop directory (if not empty) and its parents are not searched. Returns dir if not found.
+func ProjectRoot(dir, stop string) string {
+	markers := []string{".git", "go.mod"}
+	for d := dir; ; {
+		for _, m := range markers {
+			if _, err := os.Stat(filepath.Join(d, m)); err == nil {
+				return d
+			}
+		}
+		d2 := filepath.Dir(d)
+		if d2 == d || d2 == stop {
+			return dir
+		}
+		d = d2
+	}
+}
@@ -0,0 +1,128 @@
+package osutil
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// Writes the file by renaming a synced temporary file (same directory) over the target, so a crash never leaves a partially written file. The mode and ownership of an existing file are kept (perm is used for new files, masked by the umask). Symlinks are followed (the link is kept and the target is replaced). Files with hard links are written in place since a rename would detach them from the other links.
+func WriteFileAtomic(name string, b []byte, perm os.FileMode) error {
+	// write to the symlink target
+	if n, err := filepath.EvalSymlinks(name); err == nil {
+		name = n
+	}
+
+	fi, err := os.Stat(name)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	uid, gid := -1, -1
+	if fi != nil {
+		// keep the setuid/setgid/sticky bits (os.FileMode bits, converted by chmod)
+		perm = fi.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+		if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+			if st.Nlink > 1 {
+				return writeFileInPlace(name, b, perm)
+			}
+			uid, gid = int(st.Uid), int(st.Gid)
+		}
+	}
+
+	// a new file is created with perm to have the umask applied
+	tperm := perm
+	if fi != nil {
+		tperm = 0600
+	}
+	dir, base := filepath.Split(name)
+	f, err := createTempFile(dir, "."+base+".tmp", tperm)
+	if err != nil {
+		// no permission to create files in the dir, but might have permission to write the file
+		if os.IsPermission(err) && fi != nil {
+			return writeFileInPlace(name, b, perm)
+		}
+		return err
+	}
+	tmp := f.Name()
+	ok := false
+	defer func() {
+		if !ok {
+			_ = f.Close() // might be already closed
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	if _, err := f.Write(b); err != nil {
+		return err
+	}
+	if uid >= 0 {
+		if err := f.Chown(uid, gid); err != nil {
+			// can't keep the ownership (not the owner), keep the file
+			return writeFileInPlace(name, b, perm)
+		}
+	}
+	if fi != nil {
+		// after chown, which clears the setuid/setgid bits
+		if err := f.Chmod(perm); err != nil {
+			return err
+		}
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		return err
+	}
+	ok = true
+
+	syncDir(dir)
+	return nil
+}
+
+// Like ioutil.TempFile, but the file is created with perm.
+func createTempFile(dir, prefix string, perm os.FileMode) (*os.File, error) {
+	for i := 0; i < 10000; i++ {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if os.IsExist(err) {
+			continue
+		}
+		return f, err
+	}
+	return nil, &os.PathError{Op: "createtemp", Path: filepath.Join(dir, prefix+"*"), Err: os.ErrExist}
+}
+
+func writeFileInPlace(name string, b []byte, perm os.FileMode) error {
+	flags := os.O_WRONLY | os.O_TRUNC | os.O_CREATE
+	f, err := os.OpenFile(name, flags, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Makes the rename durable. Errors are ignored since not all filesystems support it.
+func syncDir(dir string) {
+	if dir == "" {
+		dir = "."
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_ = f.Sync()
+}
@@ -0,0 +1,155 @@
+package syntaxutil
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jmigpin/editor/util/iout"
+)
+
+func TestTokenizerGo1(t *testing.T) {
+	s := "package a\n" +
+		"func f(a int) string { return len(x.len) + 0x1f /* c1\n" +
+		"c2 */ }\n" +
+		"var s = `r1\n" +
+		"r2` // c3\n" +
+		"var c = 'a' + nil"
+	e := "K:package\n" +
+		"K:func T:int T:string K:return B:len N:0x1f C:/* c1\n" +
+		"C:c2 */\n" +
+		"K:var S:`r1\n" +
+		"S:r2` C:// c3\n" +
+		"K:var S:'a' B:nil"
+	testTokenizer(t, ".go", s, e)
+}
+
+func TestTokenizerC1(t *testing.T) {
+	s := "#include <a.h>\n" +
+		"int f() { return sizeof(\"a\\\"b\") + 1.5f; } // c\n" +
+		"/* c1\n" +
+		"c2 */ x"
+	e := "K:#include\n" +
+		"T:int K:return K:sizeof S:\"a\\\"b\" N:1.5f C:// c\n" +
+		"C:/* c1\n" +
+		"C:c2 */"
+	testTokenizer(t, ".c", s, e)
+}
+
+func TestTokenizerSh1(t *testing.T) {
+	s := "if [ $# -gt 0 ]; then echo \"a\n" +
+		"b\" ${x} a#b 'c\\' # d\n" +
+		"fi"
+	e := "K:if B:$# N:0 K:then B:echo S:\"a\n" +
+		"S:b\" B:${x} S:'c\\' C:# d\n" +
+		"K:fi"
+	testTokenizer(t, ".sh", s, e)
+}
+
+func TestTokenizerJson1(t *testing.T) {
+	s := "{\"a\": [1, -2.5e3, \"b\", true, null]}"
+	e := "Y:\"a\" N:1 N:2.5e3 S:\"b\" B:true B:null"
+	testTokenizer(t, ".json", s, e)
+}
+
+func TestTokenizerYaml1(t *testing.T) {
+	s := "a-b: 1 # c\n" +
+		"- \"d\": url://e\n" +
+		"  f: true"
+	e := "Y:a-b N:1 C:# c\n" +
+		"Y:\"d\"\n" +
+		"Y:f B:true"
+	testTokenizer(t, ".yaml", s, e)
+}
+
+func TestTokenizerMd1(t *testing.T) {
+	s := "# h1\n" +
+		"- a `b` [c](d)\n" +
+		"```\n" +
+		"e\n" +
+		"```\n" +
+		"> f"
+	e := "H:# h1\n" +
+		"K:- S:`b` Y:[c](d)\n" +
+		"S:```\n" +
+		"S:e\n" +
+		"S:```\n" +
+		"C:> f"
+	testTokenizer(t, ".md", s, e)
+}
+
+//----------
+
+func testTokenizer(t *testing.T, ext, s, e string) {
+	t.Helper()
+	tz := ExtTokenizer(ext)
+	if tz == nil {
+		t.Fatalf("no tokenizer: %v", ext)
+	}
+	rw := iout.NewRW([]byte(s))
+	names := "-KTBNSCYH"
+	u := []string{}
+	var st State
+	for i := 0; i < len(s); {
+		c, end, st2 := tz.Token(rw, i, st)
+		if end <= i {
+			t.Fatalf("not advancing: %v", i)
+		}
+		if c != None {
+			// separate lines with a newline to ease reading the expected string
+			w := strings.TrimRight(s[i:end], "\n")
+			u = append(u, fmt.Sprintf("%c:%v", names[c], w))
+			if strings.HasSuffix(s[i:end], "\n") {
+				u = append(u, "\n")
+			}
+		} else if strings.Contains(s[i:end], "\n") {
+			u = append(u, "\n")
+		}
+		i, st = end, st2
+	}
+	r := strings.Replace(strings.Join(u, " "), " \n ", "\n", -1)
+	if r != e {
+		t.Fatalf("\n%v\n---\n%v", r, e)
+	}
+}
+
+//----------
+
+func TestGoFoldRegions1(t *testing.T) {
+	s := "package a\n" +
+		"// c1\n" +
+		"// c2\n" +
+		"func f() {\n" +
+		"	_ = []int{\n" +
+		"		1,\n" +
+		"	}\n" +
+		"	_ = []int{2}\n" +
+		"}\n"
+	e := "\"\\n// c2\" " +
+		"\"\\n\\t_ = []int{\\n\\t\\t1,\\n\\t}\\n\\t_ = []int{2}\\n\" " +
+		"\"\\n\\t\\t1,\\n\\t\""
+	testFoldRegions(t, s, GoFoldRegions([]byte(s)), e)
+}
+
+func TestIndentFoldRegions1(t *testing.T) {
+	s := "a:\n" +
+		"  b:\n" +
+		"    c\n" +
+		"\n" +
+		"  d\n" +
+		"e"
+	e := "\"\\n  b:\\n    c\\n\\n  d\" \"\\n    c\""
+	testFoldRegions(t, s, IndentFoldRegions([]byte(s)), e)
+}
+
+func testFoldRegions(t *testing.T, s string, rs []*FoldRegion, e string) {
+	t.Helper()
+	u := []string{}
+	for _, r := range rs {
+		u = append(u, fmt.Sprintf("%q", s[r.Pos:r.End]))
+	}
+	res := strings.Join(u, " ")
+	if res != e {
+		t.Fatalf("\n%v\n---\n%v", res, e)
+	}
+}
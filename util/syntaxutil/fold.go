@@ -0,0 +1,127 @@
+package syntaxutil
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+)
+
+// Foldable range. Folding hides [Pos,End).
+type FoldRegion struct {
+	Pos, End int
+}
+
+// Function bodies, composite literals and comment blocks that span multiple lines. Regions can be nested, and are ordered by position. Works with partial sources (parse errors).
+func GoFoldRegions(src []byte) []*FoldRegion {
+	fset := token.NewFileSet()
+	astFile, _ := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if astFile == nil {
+		return nil
+	}
+	tf := fset.File(astFile.Package)
+	if tf == nil {
+		return nil
+	}
+	off := func(p token.Pos) int { return tf.Offset(p) }
+
+	w := []*FoldRegion{}
+	// between braces: "{...}"
+	addBraces := func(lbrace, rbrace token.Pos) {
+		if !lbrace.IsValid() || !rbrace.IsValid() || rbrace <= lbrace {
+			return
+		}
+		a, b := off(lbrace)+1, off(rbrace)
+		if b <= len(src) && bytes.IndexByte(src[a:b], '\n') >= 0 {
+			w = append(w, &FoldRegion{a, b})
+		}
+	}
+	ast.Inspect(astFile, func(node ast.Node) bool {
+		switch t := node.(type) {
+		case *ast.FuncDecl:
+			if t.Body != nil {
+				addBraces(t.Body.Lbrace, t.Body.Rbrace)
+			}
+		case *ast.FuncLit:
+			addBraces(t.Body.Lbrace, t.Body.Rbrace)
+		case *ast.CompositeLit:
+			addBraces(t.Lbrace, t.Rbrace)
+		}
+		return true
+	})
+
+	// comments: keep the first line visible
+	for _, cg := range astFile.Comments {
+		a, b := off(cg.Pos()), off(cg.End())
+		if k := bytes.IndexByte(src[a:b], '\n'); k >= 0 {
+			w = append(w, &FoldRegion{a + k, b})
+		}
+	}
+
+	sort.Slice(w, func(i, j int) bool {
+		return w[i].Pos < w[j].Pos
+	})
+	return w
+}
+
+// Lines followed by more indented lines (blank lines are ignored). The first line is kept visible. Regions can be nested, and are ordered by position.
+func IndentFoldRegions(src []byte) []*FoldRegion {
+	type line struct {
+		start, end int // end excludes newline
+		indent     int
+	}
+	lines := []*line{}
+	for i := 0; i <= len(src); {
+		k := bytes.IndexByte(src[i:], '\n')
+		end := len(src)
+		if k >= 0 {
+			end = i + k
+		}
+		l := &line{start: i, end: end, indent: -1}
+		if s := bytes.TrimLeft(src[i:end], " \t"); len(bytes.TrimSpace(s)) > 0 {
+			l.indent = indentWidth(src[i : end-len(s)])
+		}
+		lines = append(lines, l)
+		if k < 0 {
+			break
+		}
+		i = end + 1
+	}
+
+	w := []*FoldRegion{}
+	for i, l := range lines {
+		if l.indent < 0 {
+			continue
+		}
+		// last line with a bigger indentation
+		last := -1
+		for j := i + 1; j < len(lines); j++ {
+			l2 := lines[j]
+			if l2.indent < 0 {
+				continue
+			}
+			if l2.indent <= l.indent {
+				break
+			}
+			last = j
+		}
+		if last >= 0 {
+			w = append(w, &FoldRegion{l.end, lines[last].end})
+		}
+	}
+	return w
+}
+
+// Tabs count as 8 spaces.
+func indentWidth(b []byte) int {
+	n := 0
+	for _, c := range b {
+		if c == '\t' {
+			n += 8
+		} else {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,136 @@
+package syntaxutil
+
+import (
+	"bytes"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jmigpin/editor/util/iout"
+)
+
+// Uses go/scanner on the rest of the line. The line tokens are kept for the next calls (one token is asked at a time).
+type goTokenizer struct {
+	mu   sync.Mutex
+	line goLine
+}
+
+type goLine struct {
+	start int
+	src   []byte // copy of the rest of the line at start
+	toks  []goTok
+}
+
+type goTok struct {
+	offset int // in src
+	tok    token.Token
+	lit    string
+}
+
+const (
+	goStNormal State = iota
+	goStComment
+	goStRawString
+)
+
+func (gt *goTokenizer) Token(r iout.Reader, i int, st State) (Class, int, State) {
+	b := restOfLine(r, i)
+
+	// multiline tokens
+	switch st {
+	case goStComment:
+		if k := bytes.Index(b, []byte("*/")); k >= 0 {
+			return Comment, i + k + 2, goStNormal
+		}
+		return Comment, tokenEnd(b, i, len(b)), st
+	case goStRawString:
+		if k := bytes.IndexByte(b, '`'); k >= 0 {
+			return String, i + k + 1, goStNormal
+		}
+		return String, tokenEnd(b, i, len(b)), st
+	}
+
+	if n := spaceLen(b); n > 0 {
+		return None, i + n, goStNormal
+	}
+
+	tok, lit := gt.scan(b, i)
+
+	n := len(lit)
+	if n == 0 || tok == token.SEMICOLON {
+		n = len(tok.String())
+	}
+
+	switch {
+	case tok.IsKeyword():
+		return Keyword, tokenEnd(b, i, n), goStNormal
+	case tok == token.COMMENT:
+		if lit[1] == '/' {
+			n = lineLen(b) // lit might have stripped '\r'
+		} else if len(lit) < 4 || !strings.HasSuffix(lit, "*/") {
+			return Comment, tokenEnd(b, i, len(b)), goStComment
+		}
+		return Comment, tokenEnd(b, i, n), goStNormal
+	case tok == token.STRING || tok == token.CHAR:
+		if lit[0] == '`' && (len(lit) < 2 || lit[len(lit)-1] != '`') {
+			return String, tokenEnd(b, i, len(b)), goStRawString
+		}
+		return String, tokenEnd(b, i, n), goStNormal
+	case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+		return Number, tokenEnd(b, i, n), goStNormal
+	case tok == token.IDENT:
+		return goIdentClass(r, i, lit), tokenEnd(b, i, n), goStNormal
+	}
+	return None, tokenEnd(b, i, n), goStNormal
+}
+
+// Token at i, from the scanned line if the content is the same, or scans the rest of the line.
+func (gt *goTokenizer) scan(b []byte, i int) (token.Token, string) {
+	gt.mu.Lock()
+	defer gt.mu.Unlock()
+	l := &gt.line
+	if o := i - l.start; o >= 0 && o < len(l.src) && bytes.Equal(l.src[o:], b) {
+		k := sort.Search(len(l.toks), func(k int) bool {
+			return l.toks[k].offset >= o
+		})
+		if k < len(l.toks) && l.toks[k].offset == o {
+			return l.toks[k].tok, l.toks[k].lit
+		}
+	}
+
+	l.start = i
+	l.src = append(l.src[:0], b...)
+	l.toks = l.toks[:0]
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(l.src))
+	s.Init(file, l.src, nil, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		l.toks = append(l.toks, goTok{file.Offset(pos), tok, lit})
+	}
+	if len(l.toks) == 0 {
+		return token.EOF, ""
+	}
+	return l.toks[0].tok, l.toks[0].lit
+}
+
+func goIdentClass(r iout.Reader, i int, lit string) Class {
+	// selector (ex: "a.len")
+	if prevRune(r, i) == '.' {
+		return None
+	}
+	switch types.Universe.Lookup(lit).(type) {
+	case *types.TypeName:
+		return Type
+	case *types.Builtin, *types.Const, *types.Nil:
+		return Builtin
+	}
+	return None
+}
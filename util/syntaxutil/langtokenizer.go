@@ -0,0 +1,224 @@
+package syntaxutil
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/jmigpin/editor/util/iout"
+	"github.com/jmigpin/editor/util/statemach"
+)
+
+// Configurable tokenizer for languages with c/shell like lexical rules.
+type langTokenizer struct {
+	lineComment  string
+	blockComment [2]string
+	quotes       string // quote runes
+	multiline    bool   // strings can span lines
+	idRunes      string // besides letters, digits and '_'
+	vars         bool   // shell variables (ex: "$a", "${a}")
+	preproc      bool   // c preprocessor directives (ex: "#include")
+	keys         bool   // words or strings followed by ':'
+
+	keywords, types, builtins map[string]bool
+}
+
+const (
+	langStNormal State = iota
+	langStComment
+	langStString // quote rune is kept in the upper bits
+)
+
+func (lt *langTokenizer) Token(r iout.Reader, i int, st State) (Class, int, State) {
+	b := restOfLine(r, i)
+	sm := statemach.NewString(string(b))
+
+	switch st & 0xff {
+	case langStComment:
+		k := strings.Index(sm.Input, lt.blockComment[1])
+		if k < 0 {
+			return Comment, tokenEnd(b, i, len(b)), st
+		}
+		return Comment, i + k + len(lt.blockComment[1]), langStNormal
+	case langStString:
+		quote := rune(st >> 8)
+		if lt.acceptStringEnd(sm, quote) {
+			return String, tokenEnd(b, i, sm.Pos), langStNormal
+		}
+		return String, tokenEnd(b, i, len(b)), st
+	}
+
+	if n := spaceLen(b); n > 0 {
+		return None, i + n, langStNormal
+	}
+
+	ru := sm.Peek()
+
+	// comments
+	if lt.lineComment != "" && strings.HasPrefix(sm.Input, lt.lineComment) {
+		// "#" needs to start a word in shell/yaml (ex: "$#", "a#b")
+		if lt.lineComment != "#" || unicode.IsSpace(prevRune(r, i)) {
+			return Comment, tokenEnd(b, i, lineLen(b)), langStNormal
+		}
+	}
+	if lt.blockComment[0] != "" && sm.AcceptSequence(lt.blockComment[0]) {
+		k := strings.Index(sm.Input[sm.Pos:], lt.blockComment[1])
+		if k < 0 {
+			return Comment, tokenEnd(b, i, len(b)), langStComment
+		}
+		return Comment, i + sm.Pos + k + len(lt.blockComment[1]), langStNormal
+	}
+
+	// preprocessor directive
+	if lt.preproc && ru == '#' && atLineStart(r, i) {
+		sm.Next()
+		_ = sm.AcceptSpaceExceptNewline()
+		_ = sm.AcceptLoopFn(isIdRune)
+		return Keyword, tokenEnd(b, i, sm.Pos), langStNormal
+	}
+
+	// strings
+	if strings.ContainsRune(lt.quotes, ru) {
+		sm.Next()
+		if lt.acceptStringEnd(sm, ru) {
+			return lt.keyOr(String, sm), tokenEnd(b, i, sm.Pos), langStNormal
+		}
+		if lt.multiline {
+			return String, tokenEnd(b, i, len(b)), langStString | State(ru)<<8
+		}
+		return String, tokenEnd(b, i, lineLen(b)), langStNormal
+	}
+
+	// variables
+	if lt.vars && ru == '$' {
+		sm.Next()
+		if sm.AcceptRune('{') {
+			_ = sm.AcceptLoopFn(func(ru rune) bool {
+				return ru != '}' && ru != '\n' && ru != statemach.EOS
+			})
+			_ = sm.AcceptRune('}')
+		} else if !sm.AcceptLoopFn(isIdRune) {
+			_ = sm.AcceptAny("#?$!@*-0123456789")
+		}
+		return Builtin, tokenEnd(b, i, sm.Pos), langStNormal
+	}
+
+	// numbers (also accepts suffixes, hex, exponents)
+	if unicode.IsDigit(ru) {
+		_ = sm.AcceptLoopFn(func(ru rune) bool {
+			return isIdRune(ru) || ru == '.'
+		})
+		return Number, tokenEnd(b, i, sm.Pos), langStNormal
+	}
+
+	// words
+	if ru == '_' || unicode.IsLetter(ru) {
+		_ = sm.AcceptLoopFn(func(ru rune) bool {
+			return isIdRune(ru) || strings.ContainsRune(lt.idRunes, ru)
+		})
+		w := sm.Value()
+		c := None
+		switch {
+		case lt.keywords[w]:
+			c = Keyword
+		case lt.types[w]:
+			c = Type
+		case lt.builtins[w]:
+			c = Builtin
+		}
+		return lt.keyOr(c, sm), tokenEnd(b, i, sm.Pos), langStNormal
+	}
+
+	sm.Next()
+	return None, tokenEnd(b, i, sm.Pos), langStNormal
+}
+
+// Accepts up to and including the closing quote. Backslash escapes the next rune, except inside shell single quotes.
+func (lt *langTokenizer) acceptStringEnd(sm *statemach.String, quote rune) bool {
+	escapes := "\\"
+	if lt.vars && quote == '\'' {
+		escapes = ""
+	}
+	for {
+		ru := sm.Next()
+		switch {
+		case ru == statemach.EOS:
+			return false
+		case ru == quote:
+			return true
+		case sm.IsEscapeAccept(ru, escapes):
+		}
+	}
+}
+
+// Key class if followed by ':' (and a space or the end of the line).
+func (lt *langTokenizer) keyOr(c Class, sm *statemach.String) Class {
+	if !lt.keys {
+		return c
+	}
+	p := sm.Pos
+	defer func() { sm.Pos = p }()
+	_ = sm.AcceptSpaceExceptNewline()
+	if sm.AcceptRune(':') {
+		ru := sm.Peek()
+		if ru == statemach.EOS || unicode.IsSpace(ru) {
+			return Key
+		}
+	}
+	return c
+}
+
+func isIdRune(ru rune) bool {
+	return ru == '_' || unicode.IsLetter(ru) || unicode.IsDigit(ru)
+}
+
+//----------
+
+var cTokenizer = &langTokenizer{
+	lineComment:  "//",
+	blockComment: [2]string{"/*", "*/"},
+	quotes:       "\"'",
+	preproc:      true,
+	keywords: wordSet(
+		"auto break case catch class const const_cast constexpr continue default delete do dynamic_cast else enum explicit extern for friend goto if inline mutable namespace new noexcept operator private protected public register reinterpret_cast return sizeof static static_assert static_cast struct switch template this throw try typedef typename union using virtual volatile while",
+	),
+	types: wordSet(
+		"bool char char16_t char32_t double float int long short signed unsigned void wchar_t size_t ssize_t int8_t int16_t int32_t int64_t uint8_t uint16_t uint32_t uint64_t uintptr_t",
+	),
+	builtins: wordSet("true false NULL nullptr"),
+}
+
+var shTokenizer = &langTokenizer{
+	lineComment: "#",
+	quotes:      "\"'`",
+	multiline:   true,
+	idRunes:     "-",
+	vars:        true,
+	keywords: wordSet(
+		"if then else elif fi for while until do done case esac in function select time return break continue",
+	),
+	builtins: wordSet(
+		"alias bg cd declare echo eval exec exit export fg getopts hash jobs kill let local printf pwd read readonly set shift source test trap type ulimit umask unalias unset wait",
+	),
+}
+
+var jsonTokenizer = &langTokenizer{
+	quotes:   "\"",
+	keys:     true,
+	builtins: wordSet("true false null"),
+}
+
+var yamlTokenizer = &langTokenizer{
+	lineComment: "#",
+	quotes:      "\"'",
+	idRunes:     "-./",
+	keys:        true,
+	builtins:    wordSet("true false null yes no on off True False Null"),
+}
+
+func wordSet(s string) map[string]bool {
+	m := map[string]bool{}
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
@@ -0,0 +1,88 @@
+package syntaxutil
+
+import (
+	"bytes"
+
+	"github.com/jmigpin/editor/util/iout"
+)
+
+// Markdown: headings, fenced code blocks, code spans, links, list markers and quotes.
+type mdTokenizer struct{}
+
+const (
+	mdStNormal State = iota
+	mdStFence
+)
+
+func (mdTokenizer) Token(r iout.Reader, i int, st State) (Class, int, State) {
+	b := restOfLine(r, i)
+	fence := []byte("```")
+
+	if st == mdStFence {
+		// the fence is closed by a line starting with the fence
+		if atLineStart(r, i) && bytes.HasPrefix(bytes.TrimLeft(b, " \t"), fence) {
+			return String, tokenEnd(b, i, len(b)), mdStNormal
+		}
+		return String, tokenEnd(b, i, len(b)), st
+	}
+
+	if n := spaceLen(b); n > 0 {
+		return None, i + n, mdStNormal
+	}
+
+	if atLineStart(r, i) {
+		switch {
+		case b[0] == '#':
+			return Heading, tokenEnd(b, i, lineLen(b)), mdStNormal
+		case bytes.HasPrefix(b, fence):
+			return String, tokenEnd(b, i, len(b)), mdStFence
+		case b[0] == '>':
+			return Comment, tokenEnd(b, i, lineLen(b)), mdStNormal
+		}
+		if n := mdListMarker(b); n > 0 {
+			return Keyword, i + n, mdStNormal
+		}
+	}
+
+	switch b[0] {
+	case '`':
+		if k := bytes.IndexByte(b[1:], '`'); k >= 0 {
+			return String, i + 1 + k + 1, mdStNormal
+		}
+	case '[':
+		// link: "[text](url)"
+		if k := bytes.Index(b, []byte("](")); k >= 0 {
+			if k2 := bytes.IndexByte(b[k:], ')'); k2 >= 0 {
+				return Key, i + k + k2 + 1, mdStNormal
+			}
+		}
+	}
+
+	// plain text up to the next possible token
+	k := bytes.IndexAny(b[1:], "`[\n")
+	if k < 0 {
+		return None, tokenEnd(b, i, len(b)), mdStNormal
+	}
+	return None, i + 1 + k, mdStNormal
+}
+
+// Length of a list marker (ex: "- ", "* ", "1. "), or zero.
+func mdListMarker(b []byte) int {
+	k := 0
+	for k < len(b) && b[k] >= '0' && b[k] <= '9' {
+		k++
+	}
+	if k > 0 {
+		if k < len(b) && (b[k] == '.' || b[k] == ')') {
+			k++
+		} else {
+			return 0
+		}
+	} else if len(b) > 0 && (b[0] == '-' || b[0] == '*' || b[0] == '+') {
+		k++
+	}
+	if k == 0 || k >= len(b) || (b[k] != ' ' && b[k] != '\t') {
+		return 0
+	}
+	return k
+}
@@ -0,0 +1,148 @@
+package syntaxutil
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"github.com/jmigpin/editor/util/iout"
+)
+
+// Reads one token at a time to allow incremental use (ex: a drawer that restores its state close to the visible area and continues from there).
+type Tokenizer interface {
+	// Token starting at index i. The state is the one returned with the previous token (zero at the start of the content). The end index is always greater than i.
+	Token(r iout.Reader, i int, st State) (c Class, end int, st2 State)
+}
+
+// Tokenizer state kept between tokens (ex: inside a multiline comment). Values are specific to each tokenizer.
+type State int
+
+//----------
+
+// Token class.
+type Class int
+
+const (
+	None Class = iota
+	Keyword
+	Type
+	Builtin
+	Number
+	String
+	Comment
+	Key     // ex: json/yaml keys
+	Heading // ex: markdown headings
+
+	// semantic classes (ex: from type information)
+	Local
+	Param
+	Var // package level
+	Func
+	Const
+	Field
+	Unresolved
+
+	NClasses
+)
+
+//----------
+
+var registry = map[string]Tokenizer{}
+
+// Not safe to use concurrently, should be called from init functions.
+func Register(t Tokenizer, exts ...string) {
+	for _, ext := range exts {
+		registry[ext] = t
+	}
+}
+
+// Tokenizer registered for the filename extension (ex: ".go"), or nil if none.
+func ExtTokenizer(ext string) Tokenizer {
+	return registry[ext]
+}
+
+func init() {
+	Register(&goTokenizer{}, ".go")
+	Register(cTokenizer, ".c", ".cpp", ".cc", ".h", ".hpp")
+	Register(shTokenizer, ".sh", ".bash")
+	Register(jsonTokenizer, ".json")
+	Register(yamlTokenizer, ".yaml", ".yml")
+	Register(mdTokenizer{}, ".md", ".markdown")
+}
+
+//----------
+
+// Max bytes read ahead to find a token. Tokens in longer lines might be split.
+const maxLineLen = 4096
+
+// Bytes from i to the end of the line (newline included).
+func restOfLine(r iout.Reader, i int) []byte {
+	n := r.Len() - i
+	if n > maxLineLen {
+		n = maxLineLen
+	}
+	if n <= 0 {
+		return nil
+	}
+	b, err := r.ReadNSliceAt(i, n)
+	if err != nil {
+		return nil
+	}
+	if k := bytes.IndexByte(b, '\n'); k >= 0 {
+		b = b[:k+1]
+	}
+	return b
+}
+
+// Length without the newline.
+func lineLen(b []byte) int {
+	if len(b) > 0 && b[len(b)-1] == '\n' {
+		return len(b) - 1
+	}
+	return len(b)
+}
+
+// Previous rune, or newline if at the start of the content.
+func prevRune(r iout.Reader, i int) rune {
+	ru, _, err := r.ReadLastRuneAt(i)
+	if err != nil {
+		return '\n'
+	}
+	return ru
+}
+
+// Only spaces between the line start and i.
+func atLineStart(r iout.Reader, i int) bool {
+	for ; i > 0; i-- {
+		ru, _, err := r.ReadLastRuneAt(i)
+		if err != nil || ru == '\n' {
+			return true
+		}
+		if ru != ' ' && ru != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// Number of leading spaces (including newlines).
+func spaceLen(b []byte) int {
+	for k, c := range b {
+		switch c {
+		case ' ', '\t', '\r', '\n', '\f', '\v':
+		default:
+			return k
+		}
+	}
+	return len(b)
+}
+
+// Ensures the token advances at least one rune.
+func tokenEnd(b []byte, i, n int) int {
+	if n <= 0 {
+		_, n = utf8.DecodeRune(b)
+		if n <= 0 {
+			n = 1
+		}
+	}
+	return i + n
+}
@@ -2,6 +2,7 @@ package history
 
 import (
 	"container/list"
+	"time"
 
 	"github.com/jmigpin/editor/util/iout"
 )
@@ -10,6 +11,7 @@ type Edit struct {
 	list      list.List
 	PreState  interface{}
 	PostState interface{}
+	Time      time.Time // time of the last change
 }
 
 func (edit *Edit) Append(data *iout.UndoRedo) {
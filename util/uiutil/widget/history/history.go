@@ -1,18 +1,23 @@
 package history
 
 import (
-	"container/list"
+	"time"
 )
 
+// Undo tree. New edits after an undo create a new branch instead of removing the undone edits.
 type History struct {
-	l       *list.List
-	cur     *list.Element // last edit, nil if list is empty
-	maxSize int           // max elements in list
+	root    *node // initial state (has no edit)
+	cur     *node // current state
+	size    int   // number of edits in the tree
+	maxSize int   // max edits in the tree
 	//maxDataSize int // TODO
+	nextId int
 }
 
 func NewHistory(maxSize int) *History {
-	return &History{l: list.New(), maxSize: maxSize}
+	h := &History{maxSize: maxSize}
+	h.Clear()
+	return h
 }
 
 //----------
@@ -21,28 +26,30 @@ func (h *History) Append(edit *Edit) {
 	if edit.Empty() {
 		return
 	}
-
-	if h.cur == nil {
-		h.cur = h.l.PushFront(edit)
-	} else {
-		h.cur = h.l.InsertAfter(edit, h.cur)
+	if edit.Time.IsZero() {
+		edit.Time = time.Now()
 	}
 
-	// remove all nexts
-	for e := h.cur; e.Next() != nil; {
-		h.l.Remove(e.Next())
-	}
+	n := h.newNode(edit, h.cur)
+	h.cur.children = append(h.cur.children, n)
+	h.cur.redo = n
+	h.cur = n
+	h.size++
 
-	// max size - clear backward
-	if h.l.Len() > h.maxSize {
-		diff := h.l.Len() - h.maxSize
-		h.ClearOldN(diff)
+	// max size - clear older states
+	if h.size > h.maxSize {
+		h.ClearOldN(h.size - h.maxSize)
 	}
 
 	// simplify history
 	TryToMergeLastTwoEdits(h)
 }
 
+func (h *History) newNode(edit *Edit, parent *node) *node {
+	h.nextId++
+	return &node{id: h.nextId, edit: edit, parent: parent}
+}
+
 //----------
 
 func (h *History) UndoRedo(redo bool) *Edit {
@@ -54,43 +61,249 @@ func (h *History) UndoRedo(redo bool) *Edit {
 }
 
 func (h *History) undo() *Edit {
-	if h.cur == nil {
+	if h.cur.parent == nil {
 		return nil
 	}
-	edit := h.cur.Value.(*Edit)
-	h.cur = h.cur.Prev()
-	return edit
+	n := h.cur
+	h.cur = n.parent
+	h.cur.redo = n // redo follows the last used branch
+	return n.edit
 }
 
 func (h *History) redo() *Edit {
-	var next *list.Element
-	if h.cur == nil {
-		next = h.l.Front()
-	} else {
-		next = h.cur.Next()
-	}
+	next := h.cur.redo
 	if next == nil {
-		return nil
+		if len(h.cur.children) == 0 {
+			return nil
+		}
+		next = h.cur.children[len(h.cur.children)-1]
 	}
 	h.cur = next
-	return h.cur.Value.(*Edit)
+	return h.cur.edit
+}
+
+//----------
+
+// Moves the current state to the state with the given id. Returns the edits to undo followed by the edits to redo (in order of application).
+func (h *History) Goto(id int) (undos, redos []*Edit, ok bool) {
+	target := h.root.find(id)
+	if target == nil {
+		return nil, nil, false
+	}
+
+	// target ancestors
+	anc := map[*node]bool{}
+	for n := target; n != nil; n = n.parent {
+		anc[n] = true
+	}
+
+	// undo up to the common ancestor
+	n := h.cur
+	for ; !anc[n]; n = n.parent {
+		undos = append(undos, n.edit)
+		n.parent.redo = n
+	}
+
+	// redo down to the target
+	path := []*node{}
+	for u := target; u != n; u = u.parent {
+		path = append(path, u)
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		u := path[i]
+		u.parent.redo = u
+		redos = append(redos, u.edit)
+	}
+
+	h.cur = target
+	return undos, redos, true
+}
+
+// Id of the most recent state created at or before the given time (defaults to the initial state).
+func (h *History) StateAt(t time.Time) int {
+	id := h.root.id
+	var best time.Time
+	h.root.walk(0, func(n *node, depth int) {
+		if n.edit == nil {
+			return
+		}
+		if !n.edit.Time.After(t) && !n.edit.Time.Before(best) {
+			best = n.edit.Time
+			id = n.id
+		}
+	})
+	return id
+}
+
+//----------
+
+type State struct {
+	Id      int
+	Edit    *Edit // nil on the initial state
+	Depth   int   // branch depth
+	Current bool
+}
+
+// States in tree pre-order. The last child of a node continues at the same depth, other children start a new branch (one more depth).
+func (h *History) States() []*State {
+	w := []*State{}
+	h.root.walk(0, func(n *node, depth int) {
+		s := &State{Id: n.id, Edit: n.edit, Depth: depth, Current: n == h.cur}
+		w = append(w, s)
+	})
+	return w
+}
+
+//----------
+
+// Tree as a list of edits with the index of the parent (-1 is the initial state), and the index of the current state (-1 is the initial state).
+func (h *History) Edits() (edits []*Edit, parents []int, cur int) {
+	index := map[*node]int{h.root: -1}
+	cur = -1
+	h.root.walk(0, func(n *node, depth int) {
+		if n == h.root {
+			return
+		}
+		index[n] = len(edits)
+		edits = append(edits, n.edit)
+		parents = append(parents, index[n.parent])
+		if n == h.cur {
+			cur = index[n]
+		}
+	})
+	return edits, parents, cur
+}
+
+// Replaces the history content with the tree from Edits. Parents must come before their children.
+func (h *History) SetEdits(edits []*Edit, parents []int, cur int) bool {
+	if len(edits) != len(parents) || cur >= len(edits) {
+		return false
+	}
+	h.Clear()
+	nodes := make([]*node, len(edits))
+	for i, edit := range edits {
+		p := parents[i]
+		if p >= i {
+			h.Clear()
+			return false
+		}
+		parent := h.root
+		if p >= 0 {
+			parent = nodes[p]
+		}
+		n := h.newNode(edit, parent)
+		parent.children = append(parent.children, n)
+		nodes[i] = n
+		h.size++
+	}
+	if cur >= 0 {
+		h.cur = nodes[cur]
+	}
+	return true
 }
 
 //----------
 
 func (h *History) Clear() {
-	h.l = list.New()
-	h.cur = nil
+	h.root = &node{}
+	h.cur = h.root
+	h.size = 0
 }
 
+// Removes the states that can be redone from the current state.
 func (h *History) ClearForward() {
-	for e := h.l.Back(); e != h.cur; e = h.l.Back() {
-		h.l.Remove(e)
+	for _, c := range h.cur.children {
+		h.size -= c.count()
 	}
+	h.cur.children = nil
+	h.cur.redo = nil
 }
+
+// Removes up to n older states, one at a time. Branches that start at the initial state are removed first (oldest leaves first), then the oldest state in the path to the current state becomes the initial state. The current state is kept, and its redo states are removed last.
 func (h *History) ClearOldN(n int) {
-	for e := h.l.Front(); e != h.cur && n > 0; e = h.l.Front() {
-		h.l.Remove(e)
-		n--
+	target := h.size - n
+	for h.size > target && h.clearOld1() {
+	}
+}
+
+func (h *History) clearOld1() bool {
+	// child of root in the path to the current state
+	var u *node
+	for v := h.cur; v != h.root; v = v.parent {
+		u = v
+	}
+
+	// branches that start at the initial state
+	for _, c := range h.root.children {
+		if c != u {
+			h.removeLeaf(c)
+			return true
+		}
+	}
+
+	// u edit becomes the initial state (root has no other children)
+	if u != nil && u != h.cur {
+		u.parent = nil
+		u.edit = nil
+		h.root = u
+		h.size--
+		return true
+	}
+
+	// redo states of the current state
+	if len(h.cur.children) > 0 {
+		h.removeLeaf(h.cur.children[0])
+		return true
 	}
+	return false
+}
+
+// Removes the oldest leaf of the subtree of n.
+func (h *History) removeLeaf(n *node) {
+	for len(n.children) > 0 {
+		n = n.children[0]
+	}
+	p := n.parent
+	p.children = p.children[1:]
+	if p.redo == n {
+		p.redo = nil
+	}
+	h.size--
+}
+
+//----------
+
+type node struct {
+	id       int
+	edit     *Edit
+	parent   *node
+	children []*node // in order of creation
+	redo     *node   // child to follow on redo
+}
+
+func (n *node) walk(depth int, fn func(*node, int)) {
+	fn(n, depth)
+	for i, c := range n.children {
+		d := depth
+		if i < len(n.children)-1 {
+			d++
+		}
+		c.walk(d, fn)
+	}
+}
+
+func (n *node) find(id int) *node {
+	var r *node
+	n.walk(0, func(u *node, depth int) {
+		if u.id == id {
+			r = u
+		}
+	})
+	return r
+}
+
+func (n *node) count() int {
+	c := 0
+	n.walk(0, func(*node, int) { c++ })
+	return c
 }
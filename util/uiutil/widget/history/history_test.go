@@ -0,0 +1,72 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/jmigpin/editor/util/iout"
+)
+
+func TestClearOldN1(t *testing.T) {
+	h := NewHistory(5)
+	// branches at the initial state: the current state is always a child of root
+	for i := 0; i < 20; i++ {
+		h.Append(testEdit(i))
+		if h.size > h.maxSize {
+			t.Fatalf("%v: size %v", i, h.size)
+		}
+		_ = h.UndoRedo(false)
+	}
+	if h.size != testCount(h) {
+		t.Fatalf("size %v, count %v", h.size, testCount(h))
+	}
+	// most recent branches are kept
+	edit := h.UndoRedo(true)
+	if edit == nil || edit.Entries()[0].Index != 19*10 {
+		t.Fatal(edit)
+	}
+}
+
+func TestClearOldN2(t *testing.T) {
+	h := NewHistory(10)
+	for i := 0; i < 6; i++ {
+		h.Append(testEdit(i))
+	}
+	// branch deeper in the path
+	for i := 0; i < 3; i++ {
+		_ = h.UndoRedo(false)
+	}
+	for i := 6; i < 9; i++ {
+		h.Append(testEdit(i))
+	}
+	if h.size != 9 {
+		t.Fatalf("size %v", h.size)
+	}
+
+	// removes only what is needed
+	h.ClearOldN(2)
+	if h.size != 7 || testCount(h) != 7 {
+		t.Fatalf("size %v, count %v", h.size, testCount(h))
+	}
+	h.ClearOldN(4)
+	if h.size != 3 || testCount(h) != 3 {
+		t.Fatalf("size %v, count %v", h.size, testCount(h))
+	}
+	// current state is kept
+	if h.cur.edit.Entries()[0].Index != 8*10 {
+		t.Fatal(h.cur.edit)
+	}
+}
+
+//----------
+
+// Edits that are not merged.
+func testEdit(i int) *Edit {
+	edit := &Edit{}
+	edit.Append(&iout.UndoRedo{Insert: true, Index: i * 10, S: []byte("-")})
+	return edit
+}
+
+// Number of edits in the tree.
+func testCount(h *History) int {
+	return h.root.count() - 1
+}
@@ -8,15 +8,16 @@ import (
 )
 
 func TryToMergeLastTwoEdits(h *History) {
-	if h.cur == nil {
+	prev := h.cur.parent
+	if prev == nil || prev.edit == nil {
 		return
 	}
-	prev := h.cur.Prev()
-	if prev == nil {
+	// can't change an edit that has other branches
+	if len(prev.children) != 1 || len(h.cur.children) != 0 {
 		return
 	}
-	ed1 := prev.Value.(*Edit)  // oldest
-	ed2 := h.cur.Value.(*Edit) // recent
+	ed1 := prev.edit  // oldest
+	ed2 := h.cur.edit // recent
 
 	if false ||
 		insertConsecutiveLetters(ed1, ed2) ||
@@ -25,10 +26,13 @@ func TryToMergeLastTwoEdits(h *History) {
 		// merge ed2 into ed1
 		ed1.list.PushBackList(&ed2.list)
 		ed1.PostState = ed2.PostState
+		ed1.Time = ed2.Time
 
 		// remove ed2 (h.cur)
-		h.l.Remove(h.cur)
+		prev.children = nil
+		prev.redo = nil
 		h.cur = prev
+		h.size--
 	}
 }
 
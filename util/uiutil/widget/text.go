@@ -13,8 +13,9 @@ type Text struct {
 	ENode
 	TextScroll
 
-	Drawer   drawer3.Drawer
-	OnSetStr func() // TODO: rename
+	Drawer      drawer3.Drawer
+	OnSetStr    func() // TODO: rename
+	OnSetOffset func() // offset changed (scrolled)
 
 	scrollable struct{ x, y bool }
 	ctx        ImageContext
@@ -27,7 +28,7 @@ type Text struct {
 func NewText(ctx ImageContext) *Text {
 	t := &Text{ctx: ctx}
 
-	t.brw = iout.NewRW(nil)
+	t.brw = iout.NewRope(nil)
 	//t.trw = &tRW{ReadWriter: t.brw, t: t}
 
 	t.TextScroll.Text = t
@@ -125,6 +126,9 @@ func (t *Text) SetOffset(o image.Point) {
 	if u != t.Drawer.Offset() {
 		t.Drawer.SetOffset(u)
 		t.MarkNeedsLayoutAndPaint()
+		if t.OnSetOffset != nil {
+			t.OnSetOffset()
+		}
 	}
 }
 
@@ -8,8 +8,14 @@ import (
 type TextCursor struct {
 	te      *TextEdit
 	state   TextCursorState
-	editing bool
+	editing int // nested edits count
 	tcrw    iout.ReadWriter
+
+	extra []TextCursorState // extra cursors (multi-cursor editing)
+	multi struct {
+		states []TextCursorState // all cursors while running ForEachCursor
+		cur    int               // index of the cursor being used in states
+	}
 }
 
 func NewTextCursor(te *TextEdit) *TextCursor {
@@ -32,32 +38,37 @@ func (tc *TextCursor) Edit(fn func()) {
 	fn()
 }
 
+// Edits can be nested (ex: multi-cursor editing). Only the outer edit is kept in the history.
 func (tc *TextCursor) BeginEdit() {
-	tc.panicIfEditing()
-
-	tc.editing = true
-	tc.te.TextHistory.BeginEdit()
+	tc.editing++
+	if tc.editing == 1 {
+		tc.te.TextHistory.BeginEdit()
+	}
 }
 
 func (tc *TextCursor) EndEdit() {
 	tc.panicIfNotEditing()
 
+	tc.editing--
+	if tc.editing > 0 {
+		return
+	}
+
 	defer tc.te.changes()
 
 	tc.te.TextHistory.EndEdit()
-	tc.editing = false
 }
 
 //----------
 
 func (tc *TextCursor) panicIfNotEditing() {
-	if !tc.editing {
+	if tc.editing == 0 {
 		panic("edit mode is not set")
 	}
 }
 
 func (tc *TextCursor) panicIfEditing() {
-	if tc.editing {
+	if tc.editing > 0 {
 		panic("edit mode is set")
 	}
 }
@@ -151,12 +162,171 @@ func (tc *TextCursor) LinesIndexes() (int, int, bool, error) {
 
 //----------
 
+func (tc *TextCursor) setState(state TextCursorState) {
+	// set state through the proper function calls (can't assign directly)
+	if state.selectionOn {
+		tc.SetSelection(state.selectionIndex, state.index)
+	} else {
+		tc.SetSelectionOff()
+		tc.SetIndex(state.index)
+	}
+}
+
+//----------
+
+// Adds a new primary cursor, the current one is kept as an extra cursor. The selection is on if the indexes differ.
+func (tc *TextCursor) AddCursor(si, ci int) {
+	state := TextCursorState{index: ci, selectionIndex: si, selectionOn: si != ci}
+	if tc.hasCursorState(state) {
+		return
+	}
+	tc.extra = append(tc.extra, tc.state)
+	tc.setState(state)
+	tc.te.MarkNeedsPaint()
+}
+
+// Sets one cursor per range with the selection from the first to the second index (the selection is on if the indexes differ). The last range is the primary cursor.
+func (tc *TextCursor) SetCursors(ranges [][2]int) {
+	tc.ClearExtraCursors()
+	for k, r := range ranges {
+		if k == 0 {
+			tc.setState(TextCursorState{index: r[1], selectionIndex: r[0], selectionOn: r[0] != r[1]})
+			continue
+		}
+		tc.AddCursor(r[0], r[1])
+	}
+	tc.te.MarkNeedsPaint()
+}
+
+func (tc *TextCursor) ExtraCursorsOn() bool {
+	return len(tc.extra) > 0
+}
+
+func (tc *TextCursor) ClearExtraCursors() {
+	if len(tc.extra) > 0 {
+		tc.extra = nil
+		tc.te.MarkNeedsPaint()
+	}
+}
+
+func (tc *TextCursor) hasCursorState(state TextCursorState) bool {
+	if tc.state.equalPos(state) {
+		return true
+	}
+	for _, s := range tc.extra {
+		if s.equalPos(state) {
+			return true
+		}
+	}
+	return false
+}
+
+// Cursors indexes and selection indexes (if on) of all cursors.
+func (tc *TextCursor) CursorsIndexes() (indexes []int, selections [][2]int) {
+	u := append([]TextCursorState{tc.state}, tc.extra...)
+	for _, s := range u {
+		indexes = append(indexes, s.index)
+		if s.selectionOn {
+			a, b := s.selectionIndex, s.index
+			if a > b {
+				a, b = b, a
+			}
+			selections = append(selections, [2]int{a, b})
+		}
+	}
+	return indexes, selections
+}
+
+//----------
+
+// Runs fn with each cursor (extras and primary) set as the current cursor. Writes done through RW() update the other cursors positions. Errors don't stop the iteration, the first error is returned.
+func (tc *TextCursor) ForEachCursor(fn func() error) error {
+	if len(tc.extra) == 0 {
+		return fn()
+	}
+
+	// primary is the last state
+	states := append(append([]TextCursorState{}, tc.extra...), tc.state)
+	tc.multi.states = states
+	defer func() { tc.multi.states = nil }()
+
+	var err error
+	for k := range states {
+		tc.multi.cur = k
+		tc.setState(states[k])
+		if err2 := fn(); err2 != nil && err == nil {
+			err = err2
+		}
+		states[k] = tc.state
+	}
+
+	// remove extra cursors that ended up in the same position
+	primary := states[len(states)-1]
+	tc.extra = nil
+	for _, s := range states[:len(states)-1] {
+		if !s.equalPos(primary) && !tc.hasCursorState(s) {
+			tc.extra = append(tc.extra, s)
+		}
+	}
+	tc.setState(primary)
+	tc.te.MarkNeedsPaint()
+
+	return err
+}
+
+// Updates the other cursors positions on write operations.
+func (tc *TextCursor) updateOtherCursors(i, n int, insert bool) {
+	update := func(s *TextCursorState) {
+		s.index = updateIndex(s.index, i, n, insert)
+		s.selectionIndex = updateIndex(s.selectionIndex, i, n, insert)
+		if s.selectionIndex == s.index {
+			s.selectionOn = false
+		}
+	}
+	if tc.multi.states != nil {
+		for k := range tc.multi.states {
+			if k != tc.multi.cur {
+				update(&tc.multi.states[k])
+			}
+		}
+		return
+	}
+	for k := range tc.extra {
+		update(&tc.extra[k])
+	}
+}
+
+func updateIndex(k, i, n int, insert bool) int {
+	if insert {
+		if k >= i {
+			return k + n
+		}
+		return k
+	}
+	if k >= i+n {
+		return k - n
+	}
+	if k > i {
+		return i
+	}
+	return k
+}
+
+//----------
+
 type TextCursorState struct {
 	index          int
 	selectionOn    bool
 	selectionIndex int
 }
 
+func (s TextCursorState) equalPos(s2 TextCursorState) bool {
+	if s.index != s2.index || s.selectionOn != s2.selectionOn {
+		return false
+	}
+	return !s.selectionOn || s.selectionIndex == s2.selectionIndex
+}
+
 //----------
 
 // Keeps history UndoRedo on write operations.
@@ -173,6 +343,8 @@ func (rw *tcRW) Insert(i int, p []byte) error {
 		return err
 	}
 	rw.tc.te.TextHistory.Append(ur)
+	rw.tc.updateOtherCursors(i, len(p), true)
+	rw.tc.te.onWrite(i, len(p), true)
 	return nil
 }
 
@@ -184,5 +356,7 @@ func (rw *tcRW) Delete(i, len int) error {
 		return err
 	}
 	rw.tc.te.TextHistory.Append(ur)
+	rw.tc.updateOtherCursors(i, len, false)
+	rw.tc.te.onWrite(i, len, false)
 	return nil
 }
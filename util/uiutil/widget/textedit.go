@@ -14,6 +14,8 @@ type TextEdit struct {
 
 	TextCursor  *TextCursor
 	TextHistory *TextHistory
+
+	OnWrite func(index, n int, insert bool) // inserts/deletes from the textcursor or history (ex: keep positions updated)
 }
 
 func NewTextEdit(ctx ImageContext, cctx ClipboardContext) *TextEdit {
@@ -28,6 +30,7 @@ func NewTextEdit(ctx ImageContext, cctx ClipboardContext) *TextEdit {
 
 func (te *TextEdit) SetBytes(b []byte) error {
 	tc := te.TextCursor
+	tc.ClearExtraCursors()
 	var err error
 	tc.Edit(func() {
 		err = iout.DeleteInsert(tc.RW(), 0, tc.RW().Len(), b)
@@ -37,6 +40,7 @@ func (te *TextEdit) SetBytes(b []byte) error {
 
 func (te *TextEdit) SetBytesClearPos(b []byte) error {
 	tc := te.TextCursor
+	tc.ClearExtraCursors()
 	var err error
 	tc.Edit(func() {
 		err = iout.DeleteInsert(tc.RW(), 0, tc.RW().Len(), b)
@@ -48,15 +52,21 @@ func (te *TextEdit) SetBytesClearPos(b []byte) error {
 
 func (te *TextEdit) SetBytesClearHistory(b []byte) error {
 	te.TextHistory.clear()
+	te.TextCursor.ClearExtraCursors()
+	// notify before setting to have positions updated when the changes run
+	te.onWrite(0, te.brw.Len(), false)
+	te.onWrite(0, len(b), true)
 	return te.Text.SetBytes(b) // bypasses history
 }
 
+// Cuts the top of the content to keep it under maxSize. A maxSize <= 0 has no limit.
 func (te *TextEdit) AppendBytesClearHistory(b []byte, maxSize int) error {
 	te.TextHistory.clear()
-	rw := te.brw // bypasses history
+	te.TextCursor.ClearExtraCursors()
+	rw := te.notifyW() // bypasses history
 
-	l := rw.Len() + len(b)
-	if l > maxSize {
+	l := te.brw.Len() + len(b)
+	if maxSize > 0 && l > maxSize {
 		if err := rw.Delete(0, l-maxSize); err != nil {
 			return err
 		}
@@ -65,7 +75,41 @@ func (te *TextEdit) AppendBytesClearHistory(b []byte, maxSize int) error {
 	// run changes only once for delete+insert
 	defer te.changes()
 
-	return rw.Insert(rw.Len(), b)
+	return rw.Insert(te.brw.Len(), b)
+}
+
+//----------
+
+func (te *TextEdit) onWrite(i, n int, insert bool) {
+	if te.OnWrite != nil {
+		te.OnWrite(i, n, insert)
+	}
+}
+
+// Base writer that calls OnWrite (bypasses history).
+func (te *TextEdit) notifyW() iout.Writer {
+	return &notifyWriter{Writer: te.brw, te: te}
+}
+
+type notifyWriter struct {
+	iout.Writer
+	te *TextEdit
+}
+
+func (w *notifyWriter) Insert(i int, p []byte) error {
+	if err := w.Writer.Insert(i, p); err != nil {
+		return err
+	}
+	w.te.onWrite(i, len(p), true)
+	return nil
+}
+
+func (w *notifyWriter) Delete(i, n int) error {
+	if err := w.Writer.Delete(i, n); err != nil {
+		return err
+	}
+	w.te.onWrite(i, n, false)
+	return nil
 }
 
 //----------
@@ -3,18 +3,23 @@ package widget
 import (
 	"image"
 	"image/color"
+	"sort"
 	"time"
 
 	"github.com/jmigpin/editor/core/parseutil"
 	"github.com/jmigpin/editor/util/drawutil/drawer3"
 	"github.com/jmigpin/editor/util/imageutil"
 	"github.com/jmigpin/editor/util/iout"
+	"github.com/jmigpin/editor/util/syntaxutil"
 )
 
 // textedit with extensions
 type TextEditX struct {
 	*TextEdit
 
+	OnBracketHint func(index int) // matching bracket is off screen (-1 when not)
+	bracketHint   int
+
 	comment struct {
 		line     string
 		enclosed [2]string
@@ -38,24 +43,36 @@ type TextEditX struct {
 
 func NewTextEditX(ctx ImageContext, cctx ClipboardContext) *TextEditX {
 	te := &TextEditX{
-		TextEdit: NewTextEdit(ctx, cctx),
+		TextEdit:    NewTextEdit(ctx, cctx),
+		bracketHint: -1,
 	}
 
 	if d, ok := te.Text.Drawer.(*drawer3.PosDrawer); ok {
 		d.Cursor.SetOn(true)
 
-		// segment groups:
-		//	0=selection (always on)
-		//	1=word
-		//	2=parenthesis
-		//	3=flash
 		d.Segments.SetOn(true)
-		d.Segments.Opt.SetupNGroups(4)
+		d.Segments.Opt.SetupNGroups(sgN)
+	}
+	te.OnWrite = func(i, n int, insert bool) {
+		te.updateFoldsOnWrite(i, n, insert)
+		te.updateSyntaxSegmentsOnWrite(i, n, insert)
 	}
 
 	return te
 }
 
+// segment groups (later groups override colors)
+const (
+	sgSyntax       = 0 // one group per syntax class (ex: semantic highlighting)
+	sgDiffInserted = sgSyntax + int(syntaxutil.NClasses)
+	sgDiffDeleted  = sgDiffInserted + 1
+	sgSelection    = sgDiffDeleted + 1 // always on
+	sgWord         = sgSelection + 1
+	sgParenthesis  = sgWord + 1
+	sgFlash        = sgParenthesis + 1
+	sgN            = sgFlash + 1
+)
+
 //----------
 
 func (te *TextEditX) PaintBase() {
@@ -80,16 +97,30 @@ func (te *TextEditX) updateSelectionOpt() {
 		return
 	}
 
+	// extra cursors (multi-cursor)
+	indexes, sels := te.TextCursor.CursorsIndexes()
+	d.Cursor.Opt.Extra = nil
+	if len(indexes) > 1 {
+		extra := indexes[1:] // first is the primary cursor
+		sort.Ints(extra)
+		d.Cursor.Opt.Extra = extra
+	}
+
 	if !d.Segments.On() {
 		return
 	}
 
-	sg := d.Segments.Opt.Groups[0]
-	if te.TextCursor.SelectionOn() {
+	sg := d.Segments.Opt.Groups[sgSelection]
+	if len(sels) > 0 {
 		sg.On = true
-		s, e := te.TextCursor.SelectionIndexes()
-		seg := &drawer3.Segment{s, e}
-		sg.Segs = []*drawer3.Segment{seg}
+		sort.Slice(sels, func(a, b int) bool {
+			return sels[a][0] < sels[b][0]
+		})
+		sg.Segs = nil
+		for _, u := range sels {
+			seg := &drawer3.Segment{u[0], u[1]}
+			sg.Segs = append(sg.Segs, seg)
+		}
 	} else {
 		sg.On = false
 		sg.Segs = nil
@@ -211,7 +242,7 @@ func (te *TextEditX) updateFlashOpt() {
 		return
 	}
 
-	sg := d.Segments.Opt.Groups[3]
+	sg := d.Segments.Opt.Groups[sgFlash]
 	if !te.flash.index.on {
 		sg.On = false
 		sg.Segs = nil
@@ -247,7 +278,7 @@ func (te *TextEditX) updateFlashOpt() {
 
 func (te *TextEditX) EnableParenthesisMatch(v bool) {
 	if d, ok := te.Drawer.(*drawer3.PosDrawer); ok {
-		sg := d.Segments.Opt.Groups[2]
+		sg := d.Segments.Opt.Groups[sgParenthesis]
 		sg.On = v
 	}
 
@@ -263,123 +294,208 @@ func (te *TextEditX) updateParenthesisOpt() {
 		return
 	}
 
-	sg := d.Segments.Opt.Groups[2]
+	sg := d.Segments.Opt.Groups[sgParenthesis]
 	sg.Segs = nil // might find segments or not, always start with nil
 	if !sg.On {
+		te.setBracketHint(-1)
 		return
 	}
 
-	tc := te.TextCursor
-
-	// read current rune
-	ci := tc.Index()
-	cru, _, err := tc.RW().ReadRuneAt(ci)
-	if err != nil {
+	ci := te.TextCursor.Index()
+	mi, ok := d.MatchingBracket(ci)
+	if !ok {
+		te.setBracketHint(-1)
 		return
 	}
 
-	// find parenthesis type
-	pars := []rune{
-		'{', '}',
-		'(', ')',
-		'[', ']',
+	a, b := ci, mi
+	if a > b {
+		a, b = b, a
 	}
-	var pi int
-	for ; pi < len(pars); pi++ {
-		if pars[pi] == cru {
-			break
-		}
+	sg.Segs = []*drawer3.Segment{{Pos: a, End: a + 1}, {Pos: b, End: b + 1}}
+
+	// matching bracket off screen
+	if mi < te.visibleTopIndex() || mi >= te.visibleBottomIndex() {
+		te.setBracketHint(mi)
+	} else {
+		te.setBracketHint(-1)
 	}
-	if pi >= len(pars) {
+}
+
+// Calls OnBracketHint if the index changed (-1 for no hint).
+func (te *TextEditX) setBracketHint(index int) {
+	if index == te.bracketHint || te.OnBracketHint == nil {
+		te.bracketHint = index
 		return
 	}
+	te.bracketHint = index
+	// running while painting, let the callback change other nodes later
+	te.RunOnUIGoRoutine(func() {
+		te.OnBracketHint(index)
+	})
+}
 
-	// assign open/close parenthesis
-	var open, close rune
-	isOpen := pi%2 == 0
-	if isOpen {
-		open, close = pars[pi], pars[pi+1]
-	} else {
-		open, close = pars[pi-1], pars[pi]
-	}
+//----------
 
-	if isOpen {
-		te.findParenthesisClose(ci, cru, open, close, sg)
-	} else {
-		te.findParenthesisOpen(ci, cru, open, close, sg)
+// Bracket matching the one at index. Ignores brackets inside strings and comments.
+func (te *TextEditX) MatchingBracket(index int) (int, bool) {
+	if d, ok := te.Drawer.(*drawer3.PosDrawer); ok {
+		return d.MatchingBracket(index)
 	}
+	return 0, false
 }
 
-func (te *TextEditX) findParenthesisClose(ci int, cru, open, close rune, sg *drawer3.SegGroup) {
-	tc := te.TextCursor
-	earlyExitIndex := te.visibleBottomIndex()
+// Calls fn with the open brackets enclosing the index, from the innermost to the outermost, until fn returns false.
+func (te *TextEditX) EnclosingOpenBrackets(index int, fn func(open int) bool) {
+	if d, ok := te.Drawer.(*drawer3.PosDrawer); ok {
+		d.EnclosingOpenBrackets(index, fn)
+	}
+}
 
-	seg1 := &drawer3.Segment{ci, ci + len(string(open))}
-	sg.Segs = append(sg.Segs, seg1)
+//----------
 
-	c := 0                     // match count
-	i := ci + len(string(cru)) // start searching on next rune
-	for {
-		if i >= earlyExitIndex {
-			return
+// Highlights ranges of inserted and deleted lines (ordered, non-overlapping). Nil clears.
+func (te *TextEditX) SetDiffLines(inserted, deleted [][2]int) {
+	d, ok := te.Drawer.(*drawer3.PosDrawer)
+	if !ok {
+		return
+	}
+	set := func(sg *drawer3.SegGroup, u [][2]int) {
+		sg.Segs = nil
+		for _, r := range u {
+			sg.Segs = append(sg.Segs, &drawer3.Segment{Pos: r[0], End: r[1]})
 		}
+		sg.On = len(sg.Segs) > 0
+	}
+	set(d.Segments.Opt.Groups[sgDiffInserted], inserted)
+	set(d.Segments.Opt.Groups[sgDiffDeleted], deleted)
+	te.MarkNeedsPaint()
+}
 
-		ru, size, err := tc.RW().ReadRuneAt(i)
-		if err != nil {
-			return
-		}
+func (te *TextEditX) HasDiffLines() bool {
+	if d, ok := te.Drawer.(*drawer3.PosDrawer); ok {
+		return d.Segments.Opt.Groups[sgDiffInserted].On || d.Segments.Opt.Groups[sgDiffDeleted].On
+	}
+	return false
+}
+
+//----------
+
+// Line markers drawn on the left border (ex: changed lines). Nil clears.
+func (te *TextEditX) SetGutterMarkers(markers []*drawer3.GutterMarker) {
+	if d, ok := te.Drawer.(*drawer3.PosDrawer); ok {
+		d.Gutter.Opt.Markers = markers
+		d.Gutter.SetOn(len(markers) > 0)
+		te.MarkNeedsPaint()
+	}
+}
 
-		if ru == open {
-			c++
+//----------
+
+// Hides [pos,end) drawing a placeholder. Folds inside the range are replaced. Does nothing if the range is already hidden or partially overlaps another fold.
+func (te *TextEditX) Fold(pos, end int) {
+	d, ok := te.Drawer.(*drawer3.PosDrawer)
+	if !ok || pos >= end {
+		return
+	}
+	rs := []*drawer3.FoldRegion{}
+	for _, r := range d.Fold.Opt.Regions {
+		if pos <= r.Pos && r.End <= end {
+			continue // replaced
 		}
-		if ru == close {
-			if c > 0 {
-				c--
-			} else {
-				seg2 := &drawer3.Segment{i, i + len(string(close))}
-				sg.Segs = append(sg.Segs, seg2)
-				return
-			}
+		if r.Pos < end && pos < r.End {
+			return // overlaps
 		}
+		rs = append(rs, r)
+	}
+	rs = append(rs, &drawer3.FoldRegion{Pos: pos, End: end})
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i].Pos < rs[j].Pos
+	})
+	te.setFolds(rs)
 
-		i += size
+	// cursor can't be inside hidden content
+	tc := te.TextCursor
+	if i := tc.Index(); pos < i && i < end {
+		tc.SetSelectionOff()
+		tc.SetIndex(pos)
 	}
 }
 
-func (te *TextEditX) findParenthesisOpen(ci int, cru, open, close rune, sg *drawer3.SegGroup) {
-	tc := te.TextCursor
-	earlyExitIndex := te.visibleTopIndex()
+// Unfolds the regions that intersect [pos,end] (region start/end included). Returns false if there was nothing to unfold.
+func (te *TextEditX) Unfold(pos, end int) bool {
+	d, ok := te.Drawer.(*drawer3.PosDrawer)
+	if !ok {
+		return false
+	}
+	rs := []*drawer3.FoldRegion{}
+	for _, r := range d.Fold.Opt.Regions {
+		if r.Pos <= end && pos <= r.End {
+			continue
+		}
+		rs = append(rs, r)
+	}
+	if len(rs) == len(d.Fold.Opt.Regions) {
+		return false
+	}
+	te.setFolds(rs)
+	return true
+}
 
-	seg2 := &drawer3.Segment{ci, ci + len(string(close))}
-	sg.Segs = append(sg.Segs, seg2)
+func (te *TextEditX) UnfoldAll() {
+	te.setFolds(nil)
+}
 
-	c := 0 // match count
-	for i := ci; ; {
-		if i < earlyExitIndex {
-			return
+func (te *TextEditX) IsFolded(pos, end int) bool {
+	if d, ok := te.Drawer.(*drawer3.PosDrawer); ok {
+		for _, r := range d.Fold.Opt.Regions {
+			if r.Pos <= pos && end <= r.End {
+				return true
+			}
 		}
+	}
+	return false
+}
 
-		ru, size, err := tc.RW().ReadLastRuneAt(i)
-		if err != nil {
-			return
-		}
-		i -= size
+func (te *TextEditX) setFolds(rs []*drawer3.FoldRegion) {
+	if d, ok := te.Drawer.(*drawer3.PosDrawer); ok {
+		d.Fold.Opt.Regions = rs
+		d.Fold.SetOn(len(rs) > 0)
+		d.SetNeedMeasure(true)
+		te.MarkNeedsLayoutAndPaint()
+	}
+}
 
-		if ru == close {
-			c++
-		}
-		if ru == open {
-			if c > 0 {
-				c--
-			} else {
-				seg1 := &drawer3.Segment{i, i + len(string(open))}
-				// prepend
-				sg.Segs = append([]*drawer3.Segment{seg1}, sg.Segs...)
-				return
+// Keeps the folds positions updated. Edits inside a fold unfold it.
+func (te *TextEditX) updateFoldsOnWrite(i, n int, insert bool) {
+	d, ok := te.Drawer.(*drawer3.PosDrawer)
+	if !ok || len(d.Fold.Opt.Regions) == 0 {
+		return
+	}
+	rs := []*drawer3.FoldRegion{}
+	for _, r := range d.Fold.Opt.Regions {
+		r2 := *r
+		if insert {
+			if r.Pos < i && i < r.End {
+				continue
+			}
+			if i <= r.Pos {
+				r2.Pos += n
+				r2.End += n
+			}
+		} else {
+			if i < r.End && r.Pos < i+n {
+				continue
+			}
+			if i+n <= r.Pos {
+				r2.Pos -= n
+				r2.End -= n
 			}
 		}
-
+		rs = append(rs, &r2)
 	}
+	d.Fold.Opt.Regions = rs
+	d.Fold.SetOn(len(rs) > 0)
 }
 
 //----------
@@ -402,7 +518,7 @@ func (te *TextEditX) EnableColorizeSyntax(v bool) {
 
 func (te *TextEditX) EnableHighlightCursorWord(v bool) {
 	if d, ok := te.Drawer.(*drawer3.PosDrawer); ok {
-		sg := d.Segments.Opt.Groups[1]
+		sg := d.Segments.Opt.Groups[sgWord]
 		sg.On = v
 	}
 
@@ -414,7 +530,7 @@ func (te *TextEditX) updateHighlightWordOpt() {
 		return
 	}
 
-	sg := d.Segments.Opt.Groups[1]
+	sg := d.Segments.Opt.Groups[sgWord]
 	sg.Segs = nil
 	if !sg.On {
 		return
@@ -479,6 +595,65 @@ func (te *TextEditX) updateColorizeOptComment() {
 	}
 }
 
+// Tokenizer used to colorize the syntax (nil uses only the comment strings).
+func (te *TextEditX) SetTokenizer(t syntaxutil.Tokenizer) {
+	if d, ok := te.Drawer.(*drawer3.PosDrawer); ok {
+		d.ColorizeSyntax.Opt.Tokenizer = t
+		d.SetNeedMeasure(true)
+	}
+}
+
+// Segments colored by class (ex: semantic highlighting), ordered by pos. Overrides the tokenizer colors.
+func (te *TextEditX) SetSyntaxSegments(segs map[syntaxutil.Class][]*drawer3.Segment) {
+	d, ok := te.Drawer.(*drawer3.PosDrawer)
+	if !ok {
+		return
+	}
+	for c := 0; c < int(syntaxutil.NClasses); c++ {
+		sg := d.Segments.Opt.Groups[sgSyntax+c]
+		sg.Segs = segs[syntaxutil.Class(c)]
+		sg.On = len(sg.Segs) > 0
+	}
+	te.MarkNeedsPaint()
+}
+
+// Keeps the syntax segments positions updated until new segments are set. Segments that overlap a delete are removed. Segments are copied since they can be shared (ex: cache).
+func (te *TextEditX) updateSyntaxSegmentsOnWrite(i, n int, insert bool) {
+	d, ok := te.Drawer.(*drawer3.PosDrawer)
+	if !ok {
+		return
+	}
+	for c := 0; c < int(syntaxutil.NClasses); c++ {
+		sg := d.Segments.Opt.Groups[sgSyntax+c]
+		if len(sg.Segs) == 0 {
+			continue
+		}
+		segs := make([]*drawer3.Segment, 0, len(sg.Segs))
+		for _, s := range sg.Segs {
+			s2 := *s
+			if insert {
+				if i <= s.Pos {
+					s2.Pos += n
+					s2.End += n
+				} else if i < s.End {
+					s2.End += n
+				}
+			} else {
+				if i < s.End && s.Pos < i+n {
+					continue
+				}
+				if i+n <= s.Pos {
+					s2.Pos -= n
+					s2.End -= n
+				}
+			}
+			segs = append(segs, &s2)
+		}
+		sg.Segs = segs
+		sg.On = len(sg.Segs) > 0
+	}
+}
+
 func (te *TextEditX) CommentLineSymbol() string {
 	return te.comment.line
 }
@@ -493,18 +668,24 @@ func (te *TextEditX) OnThemeChange() {
 
 		d.Cursor.Opt.Fg = pcol("text_cursor_fg")
 
+		// diff
+		sg := d.Segments.Opt.Groups[sgDiffInserted]
+		sg.Bg = pcol("text_diffinsert_bg")
+		sg = d.Segments.Opt.Groups[sgDiffDeleted]
+		sg.Bg = pcol("text_diffdelete_bg")
+
 		// selection
-		sg := d.Segments.Opt.Groups[0]
+		sg = d.Segments.Opt.Groups[sgSelection]
 		sg.Fg = pcol("text_selection_fg")
 		sg.Bg = pcol("text_selection_bg")
 
 		// word
-		sg = d.Segments.Opt.Groups[1]
+		sg = d.Segments.Opt.Groups[sgWord]
 		sg.Fg = pcol("text_highlightword_fg")
 		sg.Bg = pcol("text_highlightword_bg")
 
 		// parenthesis
-		sg = d.Segments.Opt.Groups[2]
+		sg = d.Segments.Opt.Groups[sgParenthesis]
 		sg.Fg = pcol("text_parenthesis_fg")
 		sg.Bg = pcol("text_parenthesis_bg")
 
@@ -513,6 +694,23 @@ func (te *TextEditX) OnThemeChange() {
 
 		d.ColorizeSyntax.Opt.String.Fg = pcol("text_colorize_string_fg")
 		d.ColorizeSyntax.Opt.Comment.Fg = pcol("text_colorize_comments_fg")
+		for c, name := range syntaxClassPaletteNames {
+			if name == "" {
+				continue
+			}
+			fg := pcol(name)
+			d.ColorizeSyntax.Opt.Token.Fg[c] = fg
+			d.Segments.Opt.Groups[sgSyntax+c].Fg = fg
+		}
+		sg = d.Segments.Opt.Groups[sgSyntax+int(syntaxutil.Unresolved)]
+		sg.Bg = pcol("text_colorize_unresolved_bg")
+
+		d.Gutter.Opt.Inserted = pcol("text_gutter_inserted")
+		d.Gutter.Opt.Modified = pcol("text_gutter_modified")
+		d.Gutter.Opt.Deleted = pcol("text_gutter_deleted")
+
+		d.Fold.Opt.Fg = pcol("text_fold_fg")
+		d.Fold.Opt.Bg = pcol("text_fold_bg")
 
 		d.Annotations.Opt.Fg = pcol("text_annotations_fg")
 		d.Annotations.Opt.Bg = pcol("text_annotations_bg")
@@ -521,6 +719,25 @@ func (te *TextEditX) OnThemeChange() {
 	}
 }
 
+// palette names of the syntax classes colors
+var syntaxClassPaletteNames = [syntaxutil.NClasses]string{
+	syntaxutil.Keyword:    "text_colorize_keyword_fg",
+	syntaxutil.Type:       "text_colorize_type_fg",
+	syntaxutil.Builtin:    "text_colorize_builtin_fg",
+	syntaxutil.Number:     "text_colorize_number_fg",
+	syntaxutil.String:     "text_colorize_string_fg",
+	syntaxutil.Comment:    "text_colorize_comments_fg",
+	syntaxutil.Key:        "text_colorize_key_fg",
+	syntaxutil.Heading:    "text_colorize_heading_fg",
+	syntaxutil.Local:      "text_colorize_local_fg",
+	syntaxutil.Param:      "text_colorize_param_fg",
+	syntaxutil.Var:        "text_colorize_var_fg",
+	syntaxutil.Func:       "text_colorize_func_fg",
+	syntaxutil.Const:      "text_colorize_const_fg",
+	syntaxutil.Field:      "text_colorize_field_fg",
+	syntaxutil.Unresolved: "text_colorize_unresolved_fg",
+}
+
 //----------
 
 func (te *TextEditX) visibleTopIndex() int {
@@ -1,8 +1,12 @@
 package widget
 
 import (
+	"encoding/gob"
+	"fmt"
 	"image"
+	"io"
 	"log"
+	"time"
 
 	"github.com/jmigpin/editor/util/iout"
 	"github.com/jmigpin/editor/util/uiutil/event"
@@ -68,20 +72,17 @@ func (th *TextHistory) Append(ur *iout.UndoRedo) {
 //----------
 
 func (th *TextHistory) cursorState() interface{} {
-	return th.te.TextCursor.state
+	tc := th.te.TextCursor
+	extra := append([]TextCursorState{}, tc.extra...)
+	return &textHistoryCursorState{state: tc.state, extra: extra}
 }
 
 func (th *TextHistory) restoreCursorState(data interface{}) {
-	state := data.(TextCursorState)
+	hstate := data.(*textHistoryCursorState)
 
-	// set state through the proper function calls (can't assign directly)
 	tc := th.te.TextCursor
-	if state.selectionOn {
-		tc.SetSelection(state.selectionIndex, state.index)
-	} else {
-		tc.SetSelectionOff()
-		tc.SetIndex(state.index)
-	}
+	tc.setState(hstate.state)
+	tc.extra = append([]TextCursorState{}, hstate.extra...)
 
 	// make index visible
 	if !tc.SelectionOn() {
@@ -97,6 +98,106 @@ func (th *TextHistory) restoreCursorState(data interface{}) {
 
 //----------
 
+type textHistoryCursorState struct {
+	state TextCursorState
+	extra []TextCursorState
+}
+
+//----------
+
+// Encodes the history edits (with cursor states) to be restored later with Decode (ex: keep history across editor sessions).
+func (th *TextHistory) Encode(w io.Writer) error {
+	edits, parents, cur := th.hist.Edits()
+	eh := &encTextHistory{Parents: parents, Cur: cur}
+	for _, edit := range edits {
+		ee := &encTextHistoryEdit{
+			Time:      edit.Time,
+			Entries:   edit.Entries(),
+			PreState:  newEncTextHistoryCursorState(edit.PreState),
+			PostState: newEncTextHistoryCursorState(edit.PostState),
+		}
+		eh.Edits = append(eh.Edits, ee)
+	}
+	return gob.NewEncoder(w).Encode(eh)
+}
+
+// Replaces the history with the edits from Encode. The caller is responsible for the text content matching the history position.
+func (th *TextHistory) Decode(r io.Reader) error {
+	eh := &encTextHistory{}
+	if err := gob.NewDecoder(r).Decode(eh); err != nil {
+		return err
+	}
+	edits := []*history.Edit{}
+	for _, ee := range eh.Edits {
+		edit := &history.Edit{
+			Time:      ee.Time,
+			PreState:  ee.PreState.cursorState(),
+			PostState: ee.PostState.cursorState(),
+		}
+		for _, ur := range ee.Entries {
+			edit.Append(ur)
+		}
+		edits = append(edits, edit)
+	}
+	if !th.hist.SetEdits(edits, eh.Parents, eh.Cur) {
+		return fmt.Errorf("bad history tree")
+	}
+	return nil
+}
+
+//----------
+
+type encTextHistory struct {
+	Edits   []*encTextHistoryEdit
+	Parents []int // index of the parent edit (-1 is the initial state)
+	Cur     int   // index of the current edit (-1 is the initial state)
+}
+
+type encTextHistoryEdit struct {
+	Time      time.Time
+	Entries   []*iout.UndoRedo
+	PreState  encTextHistoryCursorState
+	PostState encTextHistoryCursorState
+}
+
+// Primary cursor first, followed by the extra cursors.
+type encTextHistoryCursorState []encTextCursorState
+
+type encTextCursorState struct {
+	Index          int
+	SelectionOn    bool
+	SelectionIndex int
+}
+
+func newEncTextHistoryCursorState(data interface{}) encTextHistoryCursorState {
+	hstate, ok := data.(*textHistoryCursorState)
+	if !ok {
+		return nil
+	}
+	u := append([]TextCursorState{hstate.state}, hstate.extra...)
+	w := encTextHistoryCursorState{}
+	for _, s := range u {
+		es := encTextCursorState{s.index, s.selectionOn, s.selectionIndex}
+		w = append(w, es)
+	}
+	return w
+}
+
+func (w encTextHistoryCursorState) cursorState() *textHistoryCursorState {
+	hstate := &textHistoryCursorState{}
+	for i, es := range w {
+		s := TextCursorState{es.Index, es.SelectionOn, es.SelectionIndex}
+		if i == 0 {
+			hstate.state = s
+		} else {
+			hstate.extra = append(hstate.extra, s)
+		}
+	}
+	return hstate
+}
+
+//----------
+
 func (th *TextHistory) Undo() error { return th.undoRedo(false) }
 func (th *TextHistory) Redo() error { return th.undoRedo(true) }
 
@@ -113,7 +214,46 @@ func (th *TextHistory) undoRedo(redo bool) error {
 	}
 
 	defer th.te.changes()
-	return edit.ApplyUndoRedo(th.te.brw, redo, restore)
+	return edit.ApplyUndoRedo(th.te.notifyW(), redo, restore)
+}
+
+//----------
+
+// Undo tree states (see history.History.States).
+func (th *TextHistory) States() []*history.State {
+	return th.hist.States()
+}
+
+// Undoes/redoes the edits needed to reach the state with the given id, possibly in another branch.
+func (th *TextHistory) Goto(id int) error {
+	th.te.TextCursor.panicIfEditing()
+
+	undos, redos, ok := th.hist.Goto(id)
+	if !ok {
+		return fmt.Errorf("history state not found: %v", id)
+	}
+
+	restore := func(data interface{}) {
+		th.restoreCursorState(data)
+	}
+
+	defer th.te.changes()
+	for _, edit := range undos {
+		if err := edit.ApplyUndoRedo(th.te.notifyW(), false, restore); err != nil {
+			return err
+		}
+	}
+	for _, edit := range redos {
+		if err := edit.ApplyUndoRedo(th.te.notifyW(), true, restore); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Goes to the most recent state created at or before the given time.
+func (th *TextHistory) GotoTime(t time.Time) error {
+	return th.Goto(th.hist.StateAt(t))
 }
 
 //----------
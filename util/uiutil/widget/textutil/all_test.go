@@ -1,8 +1,12 @@
 package textutil
 
 import (
+	"bytes"
+	"image"
+	"regexp"
 	"testing"
 
+	"github.com/jmigpin/editor/util/drawutil"
 	"github.com/jmigpin/editor/util/uiutil/event"
 	"github.com/jmigpin/editor/util/uiutil/widget"
 )
@@ -15,6 +19,12 @@ func (*cctx) GetCPPaste(i event.CopyPasteIndex, fn func(string, bool)) {}
 func (*cctx) SetCPCopy(i event.CopyPasteIndex, v string)               {}
 func (*cctx) RunOnUIGoRoutine(f func())                                { f() }
 
+func setupTestDrawer(tex *widget.TextEditX) {
+	tex.Drawer.SetFace(drawutil.GetTestFace())
+	tex.Drawer.SetBounds(image.Rect(0, 0, 500, 500))
+	tex.EnableColorizeSyntax(true)
+}
+
 //----------
 
 func TestAll1(t *testing.T) {
@@ -140,7 +150,7 @@ func TestAll1(t *testing.T) {
 			s:  "01234\nabc",
 			es: "01234\nabc", esi: 6, eci: 8, eson: true,
 			f: func(tex *widget.TextEditX) error {
-				_, err := Find(tex.TextEdit, "ab")
+				_, err := Find(tex.TextEdit, "ab", true)
 				return err
 			},
 		},
@@ -148,10 +158,43 @@ func TestAll1(t *testing.T) {
 			s: "01234\nabc", ci: 7,
 			es: "01234\nabc", esi: 6, eci: 8, eson: true,
 			f: func(tex *widget.TextEditX) error {
-				_, err := Find(tex.TextEdit, "ab")
+				_, err := Find(tex.TextEdit, "ab", true)
+				return err
+			},
+		},
+		{
+			s: "01234\nabc", ci: 7,
+			es: "01234\nabc", esi: 2, eci: 4, eson: true,
+			f: func(tex *widget.TextEditX) error {
+				_, err := FindRegexp(tex.TextEdit, regexp.MustCompile(`[2-3]+`))
+				return err
+			},
+		},
+		{
+			s: "foo bar", ci: 2,
+			es: "foo bar", eci: 2,
+			f: func(tex *widget.TextEditX) error {
+				_, err := FindRegexp(tex.TextEdit, regexp.MustCompile(`^o`))
+				return err
+			},
+		},
+		{
+			s: "foobar bar", ci: 0,
+			es: "foobar bar", esi: 7, eci: 10, eson: true,
+			f: func(tex *widget.TextEditX) error {
+				_, err := FindRegexp(tex.TextEdit, regexp.MustCompile(`\bbar`))
+				return err
+			},
+		},
+		{
+			s: "aBc abc", ci: 0,
+			es: "aBc abc", esi: 4, eci: 7, eson: true,
+			f: func(tex *widget.TextEditX) error {
+				_, err := Find(tex.TextEdit, "abc", false)
 				return err
 			},
 		},
+
 		{
 			s: "0123", ci: 2,
 			es: "01ab23", eci: 4,
@@ -239,6 +282,51 @@ func TestAll1(t *testing.T) {
 				return err
 			},
 		},
+		{
+			s: "a=1 b=2", ci: 7,
+			es: "1=a 2=b", eci: 7,
+			f: func(tex *widget.TextEditX) error {
+				re := regexp.MustCompile(`(\w)=(\w)`)
+				_, err := ReplaceRegexp(tex.TextEdit, re, "$2=$1")
+				return err
+			},
+		},
+		{
+			s: "a=1 b=2 c=3", si: 4, ci: 11, son: true,
+			es: "a=1 2=b 3=c", esi: 4, eci: 11, eson: true,
+			f: func(tex *widget.TextEditX) error {
+				re := regexp.MustCompile(`(\w)=(\w)`)
+				_, err := ReplaceRegexp(tex.TextEdit, re, "${2}=$1")
+				return err
+			},
+		},
+		{
+			s: "aaa\nbab", ci: 0,
+			es: "Xaa\nbab", eci: 0,
+			f: func(tex *widget.TextEditX) error {
+				re := regexp.MustCompile(`^a`)
+				_, err := ReplaceRegexp(tex.TextEdit, re, "X")
+				return err
+			},
+		},
+		{
+			s: "aaa\nab a", ci: 0,
+			es: "Xaa\nXb X", eci: 0,
+			f: func(tex *widget.TextEditX) error {
+				re := regexp.MustCompile(`(?m)^a|\ba\b`)
+				_, err := ReplaceRegexp(tex.TextEdit, re, "X")
+				return err
+			},
+		},
+		{
+			s: "baaac", ci: 0,
+			es: "-b-c-", eci: 0,
+			f: func(tex *widget.TextEditX) error {
+				re := regexp.MustCompile(`a*`)
+				_, err := ReplaceRegexp(tex.TextEdit, re, "-")
+				return err
+			},
+		},
 
 		{
 			s: "012 -- abc", ci: 4,
@@ -297,6 +385,102 @@ func TestAll1(t *testing.T) {
 			},
 		},
 
+		{
+			s: "abc", ci: 0,
+			es: "-ab-c", eci: 4,
+			f: func(tex *widget.TextEditX) error {
+				tex.TextCursor.AddCursor(2, 2)
+				return EditEachCursor(tex.TextEdit, func() error {
+					return InsertString(tex.TextEdit, "-")
+				})
+			},
+		},
+		{
+			s: "abc", ci: 0,
+			es: "abc", eci: 2,
+			f: func(tex *widget.TextEditX) error {
+				tex.TextCursor.AddCursor(2, 2)
+				err := EditEachCursor(tex.TextEdit, func() error {
+					return InsertString(tex.TextEdit, "-")
+				})
+				if err != nil {
+					return err
+				}
+				return tex.TextHistory.Undo() // single undo
+			},
+		},
+		{
+			s: "ab ab ab", ci: 0, si: 2, son: true,
+			es: "- - ab", eci: 3,
+			f: func(tex *widget.TextEditX) error {
+				if err := AddCursorNextOccurrence(tex.TextEdit); err != nil {
+					return err
+				}
+				return EditEachCursor(tex.TextEdit, func() error {
+					return InsertString(tex.TextEdit, "-")
+				})
+			},
+		},
+		{
+			s: "ab\ncd\nef", ci: 0,
+			es: "b\nd\nf", eci: 4,
+			f: func(tex *widget.TextEditX) error {
+				tex.TextCursor.SetCursors([][2]int{{0, 1}, {3, 4}, {6, 7}})
+				return Cut(tex.TextEdit)
+			},
+		},
+		{
+			s: "ab\ncd\nef", ci: 0,
+			es: "a-b\nc-d\nef", eci: 6,
+			f: func(tex *widget.TextEditX) error {
+				tex.TextCursor.SetCursors([][2]int{{1, 1}, {4, 4}})
+				return EditEachCursor(tex.TextEdit, func() error {
+					return InsertString(tex.TextEdit, "-")
+				})
+			},
+		},
+
+		{
+			s: "f(a, \")\") + g(b)", ci: 2,
+			es: "f(a, \")\") + g(b)", eci: 8,
+			f: func(tex *widget.TextEditX) error {
+				setupTestDrawer(tex)
+				return GotoMatchingBracket(tex)
+			},
+		},
+		{
+			s: "f(a, \")\") + g(b)", ci: 9,
+			es: "f(a, \")\") + g(b)", eci: 1,
+			f: func(tex *widget.TextEditX) error {
+				setupTestDrawer(tex)
+				return GotoMatchingBracket(tex)
+			},
+		},
+		{
+			s: "f(a, [b]) ", ci: 3,
+			es: "f(a, [b]) ", esi: 2, eci: 8, eson: true,
+			f: func(tex *widget.TextEditX) error {
+				setupTestDrawer(tex)
+				return SelectInsideBrackets(tex)
+			},
+		},
+		{
+			s: "f(a, [b]) ", ci: 8, si: 2, son: true,
+			es: "f(a, [b]) ", esi: 1, eci: 9, eson: true,
+			f: func(tex *widget.TextEditX) error {
+				setupTestDrawer(tex)
+				return SelectInsideBrackets(tex)
+			},
+		},
+		{
+			s: "a\nf() {\n\tb\n}\nc", ci: 9,
+			es: "a\nf() {\n\tb\n}\nc", esi: 2, eci: 13, eson: true,
+			f: func(tex *widget.TextEditX) error {
+				setupTestDrawer(tex)
+				return SelectEnclosingBlock(tex)
+			},
+		},
+
 		// secondary
 		// TODO: movecursorup/movecursordown
 		// TODO: copy
@@ -343,3 +527,101 @@ func TestAll1(t *testing.T) {
 		}
 	}
 }
+
+//----------
+
+func TestHistoryEncode1(t *testing.T) {
+	tex := widget.NewTextEditX(nil, &cctx{})
+	tex.Text.SetStr("abc")
+	tex.TextCursor.SetIndex(3)
+	if err := InsertString(tex.TextEdit, "d"); err != nil {
+		t.Fatal(err)
+	}
+	tex.TextCursor.SetIndex(0)
+	if err := InsertString(tex.TextEdit, "0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tex.TextHistory.Undo(); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := tex.TextHistory.Encode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// new textedit with the same content
+	tex2 := widget.NewTextEditX(nil, &cctx{})
+	tex2.Text.SetStr(tex.Str())
+	if err := tex2.TextHistory.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tex2.TextHistory.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	if s := tex2.Str(); s != "0abcd" {
+		t.Fatal(s)
+	}
+	if err := tex2.TextHistory.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tex2.TextHistory.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if s := tex2.Str(); s != "abc" {
+		t.Fatal(s)
+	}
+	if tex2.TextCursor.Index() != 3 {
+		t.Fatal(tex2.TextCursor.Index())
+	}
+}
+
+func TestHistoryBranch1(t *testing.T) {
+	tex := widget.NewTextEditX(nil, &cctx{})
+	tex.Text.SetStr("abc")
+	th := tex.TextHistory
+
+	insert := func(i int, s string) {
+		t.Helper()
+		tex.TextCursor.SetIndex(i)
+		if err := InsertString(tex.TextEdit, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	insert(3, "1")
+	insert(0, "2")
+	if err := th.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	insert(1, "3") // new branch
+
+	if s := tex.Str(); s != "a3bc1" {
+		t.Fatal(s)
+	}
+
+	// find the undone state (branch)
+	var id int
+	for _, s := range th.States() {
+		if s.Edit != nil && s.Depth == 1 {
+			id = s.Id
+		}
+	}
+	if id == 0 {
+		t.Fatal("branch not found")
+	}
+	if err := th.Goto(id); err != nil {
+		t.Fatal(err)
+	}
+	if s := tex.Str(); s != "2abc1" {
+		t.Fatal(s)
+	}
+
+	// back to the initial state
+	if err := th.Goto(th.States()[0].Id); err != nil {
+		t.Fatal(err)
+	}
+	if s := tex.Str(); s != "abc" {
+		t.Fatal(s)
+	}
+}
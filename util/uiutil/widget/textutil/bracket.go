@@ -0,0 +1,109 @@
+package textutil
+
+import (
+	"fmt"
+
+	"github.com/jmigpin/editor/util/iout"
+	"github.com/jmigpin/editor/util/uiutil/event"
+	"github.com/jmigpin/editor/util/uiutil/widget"
+)
+
+// Moves the cursor to the bracket matching the one at (or before) the cursor.
+func GotoMatchingBracket(tex *widget.TextEditX) error {
+	tc := tex.TextCursor
+	ci := tc.Index()
+	mi, ok := tex.MatchingBracket(ci)
+	if !ok && ci > 0 {
+		mi, ok = tex.MatchingBracket(ci - 1)
+	}
+	if !ok {
+		return fmt.Errorf("no matching bracket")
+	}
+	tc.SetSelectionOff()
+	tc.SetIndex(mi)
+	tex.MakeIndexVisible(mi)
+	return nil
+}
+
+// Selects the content inside the brackets enclosing the selection. If the content was already selected, the brackets are included. Repeating expands to the outer brackets.
+func SelectInsideBrackets(tex *widget.TextEditX) error {
+	a, b := selectionOrIndex(tex)
+	found := false
+	tex.EnclosingOpenBrackets(a, func(o int) bool {
+		c, ok := tex.MatchingBracket(o)
+		if !ok || c < b {
+			return true
+		}
+		if a == o+1 && b == c {
+			selectAndCopy(tex, o, c+1)
+		} else {
+			selectAndCopy(tex, o+1, c)
+		}
+		found = true
+		return false
+	})
+	if !found {
+		return fmt.Errorf("no enclosing brackets")
+	}
+	return nil
+}
+
+// Selects the lines of the "{}" block enclosing the selection. Repeating expands to the outer block.
+func SelectEnclosingBlock(tex *widget.TextEditX) error {
+	a, b := selectionOrIndex(tex)
+	rw := tex.TextCursor.RW()
+	found := false
+	var err error
+	tex.EnclosingOpenBrackets(a, func(o int) bool {
+		if ru, _, err := rw.ReadRuneAt(o); err != nil || ru != '{' {
+			return true
+		}
+		c, ok := tex.MatchingBracket(o)
+		if !ok || c < b {
+			return true
+		}
+		a2, b2, _, err2 := iout.LinesIndexes(rw, o, c)
+		if err2 != nil {
+			err = err2
+			return false
+		}
+		if a2 == a && b2 == b {
+			return true // already selected
+		}
+		selectAndCopy(tex, a2, b2)
+		found = true
+		return false
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("no enclosing block")
+	}
+	return nil
+}
+
+//----------
+
+func selectionOrIndex(tex *widget.TextEditX) (int, int) {
+	tc := tex.TextCursor
+	if tc.SelectionOn() {
+		return tc.SelectionIndexes()
+	}
+	ci := tc.Index()
+	return ci, ci
+}
+
+func selectAndCopy(tex *widget.TextEditX, a, b int) {
+	tc := tex.TextCursor
+	tc.SetSelection(a, b)
+	tex.MakeIndexVisible(a)
+
+	// set primary copy
+	if tc.SelectionOn() {
+		s, err := tc.Selection()
+		if err == nil {
+			tex.SetCPCopy(event.CPIPrimary, string(s))
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package textutil
+
+import (
+	"image"
+
+	"github.com/jmigpin/editor/util/uiutil/widget"
+)
+
+// Selects the same column range (x coordinates) in every line between the points. Each line gets its own cursor (multi-cursor), so editing operations apply to all lines.
+func ColumnSelection(te *widget.TextEdit, p1, p2 *image.Point) {
+	lh := te.LineHeight()
+	if lh <= 0 {
+		return
+	}
+
+	// lines top y (points outside the text are clamped)
+	y1 := te.GetPoint(te.GetIndex(*p1)).Y
+	y2 := te.GetPoint(te.GetIndex(*p2)).Y
+	dy := lh
+	if y2 < y1 {
+		dy = -lh
+	}
+	nlines := (y2-y1)/dy + 1
+
+	ranges := [][2]int{}
+	for k := 0; k < nlines; k++ {
+		y := y1 + k*dy
+		a := te.GetIndex(image.Point{p1.X, y})
+		b := te.GetIndex(image.Point{p2.X, y})
+		ranges = append(ranges, [2]int{a, b})
+	}
+
+	// last range is the primary cursor (at the point being dragged)
+	te.TextCursor.SetCursors(ranges)
+}
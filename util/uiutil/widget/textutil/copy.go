@@ -1,6 +1,9 @@
 package textutil
 
 import (
+	"bytes"
+	"sort"
+
 	"github.com/jmigpin/editor/util/uiutil/event"
 	"github.com/jmigpin/editor/util/uiutil/widget"
 )
@@ -10,10 +13,32 @@ func Copy(te *widget.TextEdit) error {
 	if !tc.SelectionOn() {
 		return nil
 	}
-	s, err := tc.Selection()
+	s, err := selectionsBytes(tc)
 	if err != nil {
 		return err
 	}
 	te.SetCPCopy(event.CPIClipboard, string(s))
 	return nil
 }
+
+//----------
+
+// Selections of all cursors (multi-cursor) ordered by position and joined with newlines.
+func selectionsBytes(tc *widget.TextCursor) ([]byte, error) {
+	if !tc.ExtraCursorsOn() {
+		return tc.Selection()
+	}
+	_, sels := tc.CursorsIndexes()
+	sort.Slice(sels, func(a, b int) bool {
+		return sels[a][0] < sels[b][0]
+	})
+	u := [][]byte{}
+	for _, s := range sels {
+		b, err := tc.RW().ReadNAt(s[0], s[1]-s[0])
+		if err != nil {
+			return nil, err
+		}
+		u = append(u, b)
+	}
+	return bytes.Join(u, []byte("\n")), nil
+}
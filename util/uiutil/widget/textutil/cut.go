@@ -15,17 +15,22 @@ func Cut(te *widget.TextEdit) error {
 	tc.BeginEdit()
 	defer tc.EndEdit()
 
-	a, b := tc.SelectionIndexes()
-	s, err := tc.RW().ReadNAt(a, b-a)
+	s, err := selectionsBytes(tc)
 	if err != nil {
 		return err
 	}
 	te.SetCPCopy(event.CPIClipboard, string(s))
 
-	if err := tc.RW().Delete(a, b-a); err != nil {
-		return err
-	}
-	tc.SetSelectionOff()
-	tc.SetIndex(a)
-	return nil
+	return tc.ForEachCursor(func() error {
+		if !tc.SelectionOn() {
+			return nil
+		}
+		a, b := tc.SelectionIndexes()
+		if err := tc.RW().Delete(a, b-a); err != nil {
+			return err
+		}
+		tc.SetSelectionOff()
+		tc.SetIndex(a)
+		return nil
+	})
 }
@@ -2,21 +2,24 @@ package textutil
 
 import (
 	"bytes"
+	"regexp"
 
 	"github.com/jmigpin/editor/util/iout"
 	"github.com/jmigpin/editor/util/uiutil/widget"
 )
 
-func Find(te *widget.TextEdit, str string) (bool, error) {
+func Find(te *widget.TextEdit, str string, ignoreCase bool) (bool, error) {
 	if str == "" {
 		return false, nil
 	}
 
-	// ignore case
-	strb := bytes.ToLower([]byte(str))
+	strb := []byte(str)
+	if ignoreCase {
+		strb = bytes.ToLower(strb)
+	}
 
 	tc := te.TextCursor
-	i, err := find2(tc, strb)
+	i, err := find2(tc, strb, ignoreCase)
 	if err != nil {
 		return false, err
 	}
@@ -28,12 +31,12 @@ func Find(te *widget.TextEdit, str string) (bool, error) {
 	return false, nil
 }
 
-func find2(tc *widget.TextCursor, s []byte) (int, error) {
+func find2(tc *widget.TextCursor, s []byte, ignoreCase bool) (int, error) {
 	ci := tc.Index()
 	l := tc.RW().Len()
 
 	// index to end
-	i, err := iout.Index(tc.RW(), ci, l, s, true)
+	i, err := iout.Index(tc.RW(), ci, l, s, ignoreCase)
 	if err != nil || i >= 0 {
 		return i, err
 	}
@@ -43,5 +46,42 @@ func find2(tc *widget.TextCursor, s []byte) (int, error) {
 	if w > l {
 		w = l
 	}
-	return iout.Index(tc.RW(), 0, w, s, true)
+	return iout.Index(tc.RW(), 0, w, s, ignoreCase)
+}
+
+//----------
+
+func FindRegexp(te *widget.TextEdit, re *regexp.Regexp) (bool, error) {
+	tc := te.TextCursor
+	m, err := findRegexp2(tc, re)
+	if err != nil {
+		return false, err
+	}
+	if m != nil {
+		tc.SetSelection(m[0], m[1])
+		te.MakeIndexVisible(m[0])
+		return true, nil
+	}
+	return false, nil
+}
+
+func findRegexp2(tc *widget.TextCursor, re *regexp.Regexp) ([]int, error) {
+	ci := tc.Index()
+	ms, err := iout.FindAllRegexp(tc.RW(), 0, tc.RW().Len(), re)
+	if err != nil {
+		return nil, err
+	}
+
+	// index to end (empty match at the cursor would not advance)
+	for _, m := range ms {
+		if m[0] > ci || (m[0] == ci && m[1] > ci) {
+			return m, nil
+		}
+	}
+
+	// start to index
+	if len(ms) > 0 {
+		return ms[0], nil
+	}
+	return nil, nil
 }
@@ -0,0 +1,72 @@
+package textutil
+
+import (
+	"image"
+
+	"github.com/jmigpin/editor/util/iout"
+	"github.com/jmigpin/editor/util/uiutil/widget"
+)
+
+// Runs fn at every cursor. All the edits are kept as a single history edit (one undo reverts them all).
+func EditEachCursor(te *widget.TextEdit, fn func() error) error {
+	tc := te.TextCursor
+	if !tc.ExtraCursorsOn() {
+		return fn()
+	}
+	tc.BeginEdit()
+	defer tc.EndEdit()
+	return tc.ForEachCursor(fn)
+}
+
+//----------
+
+func AddCursorToPoint(te *widget.TextEdit, p *image.Point) {
+	i := te.GetIndex(*p)
+	te.TextCursor.AddCursor(i, i)
+}
+
+// Adds a cursor selecting the next occurrence of the selection. Selects the word at the cursor if there is no selection.
+func AddCursorNextOccurrence(te *widget.TextEdit) error {
+	tc := te.TextCursor
+	if !tc.SelectionOn() {
+		return SelectWord(te)
+	}
+
+	s, err := tc.Selection()
+	if err != nil {
+		return err
+	}
+
+	// search after the last cursor/selection
+	indexes, sels := tc.CursorsIndexes()
+	ci := 0
+	for _, i := range indexes {
+		if i > ci {
+			ci = i
+		}
+	}
+	for _, u := range sels {
+		if u[1] > ci {
+			ci = u[1]
+		}
+	}
+	l := tc.RW().Len()
+	i, err := iout.Index(tc.RW(), ci, l-ci, s, false)
+	if err != nil {
+		return err
+	}
+	if i < 0 {
+		// wrap around
+		i, err = iout.Index(tc.RW(), 0, l, s, false)
+		if err != nil {
+			return err
+		}
+		if i < 0 {
+			return nil
+		}
+	}
+
+	tc.AddCursor(i, i+len(s))
+	te.MakeIndexVisible(i)
+	return nil
+}
@@ -9,7 +9,10 @@ func Paste(te *widget.TextEdit, i event.CopyPasteIndex) {
 	te.GetCPPaste(i, func(str string, ok bool) {
 		if ok {
 			te.RunOnUIGoRoutine(func() {
-				if err := InsertString(te, str); err != nil {
+				err := EditEachCursor(te, func() error {
+					return InsertString(te, str)
+				})
+				if err != nil {
 					// TODO: error
 				}
 			})
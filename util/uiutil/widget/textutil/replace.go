@@ -1,6 +1,8 @@
 package textutil
 
 import (
+	"regexp"
+
 	"github.com/jmigpin/editor/util/iout"
 	"github.com/jmigpin/editor/util/uiutil/widget"
 )
@@ -17,14 +19,7 @@ func Replace(te *widget.TextEdit, old, new string) (bool, error) {
 	oldb := []byte(old)
 	newb := []byte(new)
 
-	var a, b int
-	if tc.SelectionOn() {
-		a, b = tc.SelectionIndexes()
-	} else {
-		a = 0
-		b = tc.RW().Len()
-	}
-
+	a, b := replaceRange(tc)
 	ci, replaced, err := replace2(tc, oldb, newb, a, b)
 	if err == nil {
 		tc.SetIndex(ci)
@@ -64,3 +59,73 @@ func replace2(tc *widget.TextCursor, oldb, newb []byte, a, b int) (int, bool, er
 	}
 	return ci, replaced, nil
 }
+
+//----------
+
+// Template can reference submatches with $1 or ${name} (see regexp.Expand).
+func ReplaceRegexp(te *widget.TextEdit, re *regexp.Regexp, template string) (bool, error) {
+	tc := te.TextCursor
+	tc.BeginEdit()
+	defer tc.EndEdit()
+
+	a, b := replaceRange(tc)
+	ci, replaced, err := replaceRegexp2(tc, re, template, a, b)
+	if err == nil {
+		tc.SetIndex(ci)
+	}
+
+	return replaced, err
+}
+
+func replaceRegexp2(tc *widget.TextCursor, re *regexp.Regexp, template string, a, b int) (int, bool, error) {
+	ci := tc.Index()
+
+	// all matches of the range in a single pass (same as regexp.ReplaceAll)
+	ms, err := iout.FindAllRegexp(tc.RW(), a, b-a, re)
+	if err != nil || len(ms) == 0 {
+		return ci, false, err
+	}
+
+	// expand templates before changing the content (indexes relative to the range)
+	src, err := tc.RW().ReadNAt(a, b-a)
+	if err != nil {
+		return ci, false, err
+	}
+	news := make([][]byte, len(ms))
+	for k, m := range ms {
+		m2 := make([]int, len(m))
+		for j, v := range m {
+			m2[j] = v
+			if v >= 0 {
+				m2[j] = v - a
+			}
+		}
+		news[k] = re.Expand(nil, []byte(template), src, m2)
+	}
+
+	// replace from the end to keep the indexes valid
+	for k := len(ms) - 1; k >= 0; k-- {
+		i, le := ms[k][0], ms[k][1]-ms[k][0]
+		newb := news[k]
+		if err := iout.DeleteInsert(tc.RW(), i, le, newb); err != nil {
+			return ci, true, err
+		}
+		if i < ci {
+			ci += -le + len(newb)
+			if ci < i {
+				ci = i
+			}
+		}
+	}
+	return ci, true, nil
+}
+
+//----------
+
+// Selection range if on, otherwise the whole content.
+func replaceRange(tc *widget.TextCursor) (int, int) {
+	if tc.SelectionOn() {
+		return tc.SelectionIndexes()
+	}
+	return 0, tc.RW().Len()
+}
@@ -10,6 +10,11 @@ import (
 
 type TextEditInputHandler struct {
 	tex *widget.TextEditX
+
+	column struct { // column selection (alt+drag)
+		on    bool
+		start image.Point
+	}
 }
 
 func NewTextEditInputHandler(tex *widget.TextEditX) *TextEditInputHandler {
@@ -34,21 +39,39 @@ func (eh *TextEditInputHandler) handleInputEvent2(ev0 interface{}, p image.Point
 	case *event.MouseDown:
 		switch ev.Button {
 		case event.ButtonLeft:
-			if ev.Mods.ClearLocks().Is(event.ModShift) {
+			eh.column.on = false
+			switch {
+			case ev.Mods.ClearLocks().Is(event.ModAlt):
+				eh.column.on = true
+				eh.column.start = ev.Point
+				ColumnSelection(te, &ev.Point, &ev.Point)
+			case ev.Mods.ClearLocks().Is(event.ModCtrl):
+				AddCursorToPoint(te, &ev.Point)
+			case ev.Mods.ClearLocks().Is(event.ModShift):
 				MoveCursorToPoint(te, &ev.Point, true)
-			} else {
+			default:
+				te.TextCursor.ClearExtraCursors()
 				MoveCursorToPoint(te, &ev.Point, false)
 			}
 		}
 
 	case *event.MouseDragMove:
 		if ev.Buttons.Has(event.ButtonLeft) {
+			if eh.column.on {
+				ColumnSelection(te, &eh.column.start, &ev.Point)
+				break
+			}
 			MoveCursorToPoint(te, &ev.Point, true)
 			// TODO: make cursor visible?
 		}
 	case *event.MouseDragEnd:
 		switch ev.Button {
 		case event.ButtonLeft:
+			if eh.column.on {
+				eh.column.on = false
+				ColumnSelection(te, &eh.column.start, &ev.Point)
+				break
+			}
 			MoveCursorToPoint(te, &ev.Point, true)
 		}
 
@@ -104,6 +127,17 @@ func (eh *TextEditInputHandler) onKeyDown(ev *event.KeyDown) {
 		te.MakeIndexVisible(te.TextCursor.Index())
 	}
 
+	// run at every cursor (multi-cursor)
+	moveEach := func(fn func()) {
+		_ = te.TextCursor.ForEachCursor(func() error {
+			fn()
+			return nil
+		})
+	}
+	editEach := func(fn func() error) {
+		_ = EditEachCursor(te, fn)
+	}
+
 	switch ev.KeySym {
 	case event.KSymAltL,
 		event.KSymAltGr,
@@ -116,22 +150,23 @@ func (eh *TextEditInputHandler) onKeyDown(ev *event.KeyDown) {
 		event.KSymInsert,
 		event.KSymPageUp,
 		event.KSymPageDown,
-		event.KSymEscape,
 		event.KSymSuperL: // windows key
 		// ignore these
+	case event.KSymEscape:
+		te.TextCursor.ClearExtraCursors()
 	case event.KSymRight:
 		makeCursorVisible()       // make a partially visible cursor visible
 		defer makeCursorVisible() // adjust adjacent lines just one line instead of centralizing
 
 		switch {
 		case ev.Mods.ClearLocks().Is(event.ModCtrl | event.ModShift):
-			MoveCursorJumpRight(te, true)
+			moveEach(func() { MoveCursorJumpRight(te, true) })
 		case ev.Mods.ClearLocks().Is(event.ModCtrl):
-			MoveCursorJumpRight(te, false)
+			moveEach(func() { MoveCursorJumpRight(te, false) })
 		case ev.Mods.ClearLocks().Is(event.ModShift):
-			MoveCursorRight(te, true)
+			moveEach(func() { MoveCursorRight(te, true) })
 		default:
-			MoveCursorRight(te, false)
+			moveEach(func() { MoveCursorRight(te, false) })
 		}
 	case event.KSymLeft:
 		makeCursorVisible()
@@ -139,13 +174,13 @@ func (eh *TextEditInputHandler) onKeyDown(ev *event.KeyDown) {
 
 		switch {
 		case ev.Mods.ClearLocks().Is(event.ModCtrl | event.ModShift):
-			MoveCursorJumpLeft(te, true)
+			moveEach(func() { MoveCursorJumpLeft(te, true) })
 		case ev.Mods.ClearLocks().Is(event.ModCtrl):
-			MoveCursorJumpLeft(te, false)
+			moveEach(func() { MoveCursorJumpLeft(te, false) })
 		case ev.Mods.ClearLocks().Is(event.ModShift):
-			MoveCursorLeft(te, true)
+			moveEach(func() { MoveCursorLeft(te, true) })
 		default:
-			MoveCursorLeft(te, false)
+			moveEach(func() { MoveCursorLeft(te, false) })
 		}
 	case event.KSymUp:
 		makeCursorVisible()
@@ -155,9 +190,9 @@ func (eh *TextEditInputHandler) onKeyDown(ev *event.KeyDown) {
 		case ev.Mods.ClearLocks().Is(event.ModCtrl | event.ModAlt):
 			MoveLineUp(te)
 		case ev.Mods.ClearLocks().HasAny(event.ModShift):
-			MoveCursorUp(te, true)
+			moveEach(func() { MoveCursorUp(te, true) })
 		default:
-			MoveCursorUp(te, false)
+			moveEach(func() { MoveCursorUp(te, false) })
 		}
 	case event.KSymDown:
 		makeCursorVisible()
@@ -169,59 +204,59 @@ func (eh *TextEditInputHandler) onKeyDown(ev *event.KeyDown) {
 		case ev.Mods.ClearLocks().Is(event.ModCtrl | event.ModAlt):
 			MoveLineDown(te)
 		case ev.Mods.ClearLocks().HasAny(event.ModShift):
-			MoveCursorDown(te, true)
+			moveEach(func() { MoveCursorDown(te, true) })
 		default:
-			MoveCursorDown(te, false)
+			moveEach(func() { MoveCursorDown(te, false) })
 		}
 	case event.KSymHome:
 		switch {
 		case ev.Mods.ClearLocks().Is(event.ModCtrl | event.ModShift):
-			StartOfString(te, true)
+			moveEach(func() { StartOfString(te, true) })
 		case ev.Mods.ClearLocks().Is(event.ModCtrl):
-			StartOfString(te, false)
+			moveEach(func() { StartOfString(te, false) })
 		case ev.Mods.ClearLocks().Is(event.ModShift):
-			StartOfLine(te, true)
+			moveEach(func() { StartOfLine(te, true) })
 		default:
-			StartOfLine(te, false)
+			moveEach(func() { StartOfLine(te, false) })
 		}
 		makeCursorVisible()
 	case event.KSymEnd:
 		switch {
 		case ev.Mods.ClearLocks().Is(event.ModCtrl | event.ModShift):
-			EndOfString(te, true)
+			moveEach(func() { EndOfString(te, true) })
 		case ev.Mods.ClearLocks().Is(event.ModCtrl):
-			EndOfString(te, false)
+			moveEach(func() { EndOfString(te, false) })
 		case ev.Mods.ClearLocks().Is(event.ModShift):
-			EndOfLine(te, true)
+			moveEach(func() { EndOfLine(te, true) })
 		default:
-			EndOfLine(te, false)
+			moveEach(func() { EndOfLine(te, false) })
 		}
 		makeCursorVisible()
 	case event.KSymBackspace:
-		Backspace(te)
+		editEach(func() error { return Backspace(te) })
 		makeCursorVisible()
 	case event.KSymDelete:
-		Delete(te)
+		editEach(func() error { return Delete(te) })
 	case event.KSymReturn:
-		AutoIndent(te)
+		editEach(func() error { return AutoIndent(te) })
 		makeCursorVisible()
 	case event.KSymTabLeft:
-		TabLeft(te)
+		editEach(func() error { return TabLeft(te) })
 		makeCursorVisible()
 	case event.KSymTab:
 		switch {
 
 		// using KSymTabLeft case, this still needed?
 		case ev.Mods.ClearLocks().Is(event.ModShift):
-			TabLeft(te)
+			editEach(func() error { return TabLeft(te) })
 
 		default:
-			TabRight(te)
+			editEach(func() error { return TabRight(te) })
 		}
 		makeCursorVisible()
 	case ' ':
 		// ensure space even if modifiers are present
-		InsertString(te, " ")
+		editEach(func() error { return InsertString(te, " ") })
 		makeCursorVisible()
 	default:
 		switch {
@@ -232,12 +267,19 @@ func (eh *TextEditInputHandler) onKeyDown(ev *event.KeyDown) {
 		case ev.Mods.ClearLocks().Is(event.ModCtrl | event.ModShift):
 			switch ev.LowerRune() {
 			case 'd':
-				Uncomment(eh.tex)
+				editEach(func() error { return Uncomment(eh.tex) })
+			case 'b':
+				SelectInsideBrackets(eh.tex)
+			}
+		case ev.Mods.ClearLocks().Is(event.ModCtrl | event.ModAlt):
+			switch ev.LowerRune() {
+			case 'b':
+				SelectEnclosingBlock(eh.tex)
 			}
 		case ev.Mods.ClearLocks().Is(event.ModCtrl):
 			switch ev.LowerRune() {
 			case 'd':
-				Comment(eh.tex)
+				editEach(func() error { return Comment(eh.tex) })
 			case 'c':
 				Copy(te)
 			case 'x':
@@ -248,9 +290,13 @@ func (eh *TextEditInputHandler) onKeyDown(ev *event.KeyDown) {
 				RemoveLines(te)
 			case 'a':
 				SelectAll(te)
+			case 'n':
+				AddCursorNextOccurrence(te)
+			case 'b':
+				GotoMatchingBracket(eh.tex)
 			}
 		default:
-			InsertString(te, string(ev.Rune))
+			editEach(func() error { return InsertString(te, string(ev.Rune)) })
 			makeCursorVisible()
 		}
 	}
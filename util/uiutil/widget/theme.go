@@ -14,23 +14,44 @@ import (
 //----------
 
 var DefaultPalette = Palette{
-	"text_cursor_fg":             nil, // present but nil uses the current fg
-	"text_fg":                    cint(0x0),
-	"text_bg":                    cint(0xffffff),
-	"text_selection_fg":          nil,
-	"text_selection_bg":          cint(0xeeee9e), // yellow
-	"text_colorize_string_fg":    cint(0x008b00), // green
-	"text_colorize_comments_fg":  cint(0x757575), // grey 600
-	"text_highlightword_fg":      nil,
-	"text_highlightword_bg":      cint(0xc6ee9e), // green
-	"text_wrapline_fg":           cint(0x0),
-	"text_wrapline_bg":           cint(0xd8d8d8),
-	"text_parenthesis_fg":        cint(0x0),
-	"text_parenthesis_bg":        cint(0xc3c3c3),
-	"text_annotations_fg":        cint(0x0),
-	"text_annotations_bg":        cint(0xb0e0ef),
-	"text_annotations_select_fg": cint(0x0),
-	"text_annotations_select_bg": cint(0xefc7b0),
+	"text_cursor_fg":              nil, // present but nil uses the current fg
+	"text_fg":                     cint(0x0),
+	"text_bg":                     cint(0xffffff),
+	"text_selection_fg":           nil,
+	"text_selection_bg":           cint(0xeeee9e), // yellow
+	"text_colorize_string_fg":     cint(0x008b00), // green
+	"text_colorize_comments_fg":   cint(0x757575), // grey 600
+	"text_colorize_keyword_fg":    cint(0x0d47a1), // blue 900
+	"text_colorize_type_fg":       cint(0x00695c), // teal 800
+	"text_colorize_builtin_fg":    cint(0x6a1b9a), // purple 800
+	"text_colorize_number_fg":     cint(0xbf360c), // deep orange 900
+	"text_colorize_key_fg":        cint(0x0d47a1), // blue 900
+	"text_colorize_heading_fg":    cint(0x0d47a1), // blue 900
+	"text_colorize_local_fg":      nil,
+	"text_colorize_param_fg":      cint(0x795548), // brown
+	"text_colorize_var_fg":        cint(0x283593), // indigo 800
+	"text_colorize_func_fg":       cint(0xad1457), // pink 800
+	"text_colorize_const_fg":      cint(0xbf360c), // deep orange 900
+	"text_colorize_field_fg":      cint(0x455a64), // blue grey 700
+	"text_colorize_unresolved_fg": nil,
+	"text_colorize_unresolved_bg": cint(0xffcdd2), // red 100
+	"text_highlightword_fg":       nil,
+	"text_highlightword_bg":       cint(0xc6ee9e), // green
+	"text_wrapline_fg":            cint(0x0),
+	"text_wrapline_bg":            cint(0xd8d8d8),
+	"text_parenthesis_fg":         cint(0x0),
+	"text_parenthesis_bg":         cint(0xc3c3c3),
+	"text_annotations_fg":         cint(0x0),
+	"text_annotations_bg":         cint(0xb0e0ef),
+	"text_annotations_select_fg":  cint(0x0),
+	"text_annotations_select_bg":  cint(0xefc7b0),
+	"text_diffinsert_bg":          cint(0xd4f4d4), // green
+	"text_diffdelete_bg":          cint(0xf8d4d4), // red
+	"text_gutter_inserted":        cint(0x4caf50), // green
+	"text_gutter_modified":        cint(0x2196f3), // blue
+	"text_gutter_deleted":         cint(0xf44336), // red
+	"text_fold_fg":                cint(0x0),
+	"text_fold_bg":                cint(0xd8d8d8),
 
 	"scrollbar_bg":        cint(0xf2f2f2),
 	"scrollhandle_normal": cint(0xb2b2b2),